@@ -29,4 +29,8 @@ import (
 	_ "github.com/digitalocean/clusterlint/checks/security"
 	// Side-effect import to get all the checks in containerd package registered.
 	_ "github.com/digitalocean/clusterlint/checks/containerd"
+	// Side-effect import to get all the checks in networking package registered.
+	_ "github.com/digitalocean/clusterlint/checks/networking"
+	// Side-effect import to get all the checks in scheduling package registered.
+	_ "github.com/digitalocean/clusterlint/checks/scheduling"
 )
@@ -46,6 +46,11 @@ func (w *webhookCheck) Description() string {
 	return "Check for admission control webhooks"
 }
 
+// Resources returns the resources whose objects this check reads.
+func (w *webhookCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.MutatingWebhookConfigurations, kube.Namespaces, kube.Services, kube.ValidatingWebhookConfigurations}
+}
+
 // Run runs this check on a set of Kubernetes objects.
 func (w *webhookCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
 	const apiserverServiceName = "kubernetes"
@@ -46,6 +46,11 @@ func (b *barePodCheck) Description() string {
 	return "Check if there are bare pods in the cluster"
 }
 
+// Resources returns the resources whose objects this check reads.
+func (b *barePodCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Nodes, kube.Pods}
+}
+
 // Run runs this check on a set of Kubernetes objects.
 func (b *barePodCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
 	var diagnostics []checks.Diagnostic
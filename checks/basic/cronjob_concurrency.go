@@ -48,6 +48,11 @@ func (c *cronJobConcurrencyCheck) Description() string {
 	return "Check if any cronjobs have a concurrency policy of 'Allow'"
 }
 
+// Resources returns the resources whose objects this check reads.
+func (c *cronJobConcurrencyCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.CronJobs}
+}
+
 // Run runs this check on a set of Kubernetes objects. It can return warnings
 // (low-priority problems) and errors (high-priority problems) as well as an
 // error value indicating that the check failed to run.
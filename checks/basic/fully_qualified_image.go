@@ -47,6 +47,11 @@ func (fq *fullyQualifiedImageCheck) Description() string {
 	return "Checks if containers have fully qualified image names"
 }
 
+// Resources returns the resources whose objects this check reads.
+func (fq *fullyQualifiedImageCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Pods}
+}
+
 // Run runs this check on a set of Kubernetes objects. It can return warnings
 // (low-priority problems) and errors (high-priority problems) as well as an
 // error value indicating that the check failed to run.
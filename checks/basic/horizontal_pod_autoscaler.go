@@ -0,0 +1,320 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package basic
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func init() {
+	checks.Register(&hpaTargetCheck{})
+	checks.Register(&hpaResourceRequestsCheck{})
+	checks.Register(&hpaReplicaRangeCheck{})
+	checks.Register(&hpaDuplicateTargetCheck{})
+}
+
+type hpaTargetCheck struct{}
+
+// Name returns a unique name for this check.
+func (h *hpaTargetCheck) Name() string {
+	return "hpa-target"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (h *hpaTargetCheck) Groups() []string {
+	return []string{"basic"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (h *hpaTargetCheck) Description() string {
+	return "Checks if horizontal pod autoscalers target workloads that do not exist"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (h *hpaTargetCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Deployments, kube.HorizontalPodAutoscalers, kube.StatefulSets}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (h *hpaTargetCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	for _, hpa := range objects.HorizontalPodAutoscalers.Items {
+		if _, known, found := hpaTarget(objects, hpa); !known || found {
+			continue
+		}
+		hpa := hpa
+		d := checks.Diagnostic{
+			Severity: checks.Error,
+			Message:  fmt.Sprintf("Scale target %s does not exist", describeScaleTarget(hpa.Spec.ScaleTargetRef)),
+			Kind:     checks.HorizontalPodAutoscaler,
+			Object:   &hpa.ObjectMeta,
+			Owners:   hpa.ObjectMeta.GetOwnerReferences(),
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics, nil
+}
+
+type hpaResourceRequestsCheck struct{}
+
+// Name returns a unique name for this check.
+func (h *hpaResourceRequestsCheck) Name() string {
+	return "hpa-resource-requests"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (h *hpaResourceRequestsCheck) Groups() []string {
+	return []string{"basic"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (h *hpaResourceRequestsCheck) Description() string {
+	return "Checks if horizontal pod autoscalers scale on resource utilization of containers without resource requests"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (h *hpaResourceRequestsCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Deployments, kube.HorizontalPodAutoscalers, kube.LimitRanges, kube.StatefulSets}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (h *hpaResourceRequestsCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	limitRanges := limitRangesByNamespace(objects)
+
+	for _, hpa := range objects.HorizontalPodAutoscalers.Items {
+		template, _, found := hpaTarget(objects, hpa)
+		if !found {
+			continue
+		}
+		defaults, _ := limitRangeDefaults(limitRanges[hpa.Namespace])
+
+		for _, metric := range hpa.Spec.Metrics {
+			resource, container, ok := utilizationMetric(metric)
+			if !ok {
+				continue
+			}
+			var missing []string
+			for _, c := range template.Spec.Containers {
+				if container != "" && c.Name != container {
+					continue
+				}
+				if !hasResource(resource, c.Resources.Requests, c.Resources.Limits, defaults) {
+					missing = append(missing, c.Name)
+				}
+			}
+			if len(missing) == 0 {
+				continue
+			}
+			hpa := hpa
+			d := checks.Diagnostic{
+				Severity: checks.Error,
+				Message:  fmt.Sprintf("Horizontal pod autoscaler scales on `%s` utilization, but containers of %s do not request `%s`. The utilization cannot be computed.", resource, describeScaleTarget(hpa.Spec.ScaleTargetRef), resource),
+				Kind:     checks.HorizontalPodAutoscaler,
+				Object:   &hpa.ObjectMeta,
+				Owners:   hpa.ObjectMeta.GetOwnerReferences(),
+				Details:  fmt.Sprintf("Containers without requests: %s", strings.Join(missing, ", ")),
+			}
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	return diagnostics, nil
+}
+
+type hpaReplicaRangeCheck struct{}
+
+// Name returns a unique name for this check.
+func (h *hpaReplicaRangeCheck) Name() string {
+	return "hpa-replica-range"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (h *hpaReplicaRangeCheck) Groups() []string {
+	return []string{"basic"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (h *hpaReplicaRangeCheck) Description() string {
+	return "Checks if horizontal pod autoscalers have the same minimum and maximum number of replicas"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (h *hpaReplicaRangeCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.HorizontalPodAutoscalers}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (h *hpaReplicaRangeCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	for _, hpa := range objects.HorizontalPodAutoscalers.Items {
+		// minReplicas defaults to 1.
+		minReplicas := int32(1)
+		if hpa.Spec.MinReplicas != nil {
+			minReplicas = *hpa.Spec.MinReplicas
+		}
+		if minReplicas != hpa.Spec.MaxReplicas {
+			continue
+		}
+		hpa := hpa
+		d := checks.Diagnostic{
+			Severity: checks.Warning,
+			Message:  fmt.Sprintf("Horizontal pod autoscaler never scales because minReplicas and maxReplicas are both %d", minReplicas),
+			Kind:     checks.HorizontalPodAutoscaler,
+			Object:   &hpa.ObjectMeta,
+			Owners:   hpa.ObjectMeta.GetOwnerReferences(),
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics, nil
+}
+
+type hpaDuplicateTargetCheck struct{}
+
+// Name returns a unique name for this check.
+func (h *hpaDuplicateTargetCheck) Name() string {
+	return "hpa-duplicate-target"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (h *hpaDuplicateTargetCheck) Groups() []string {
+	return []string{"basic"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (h *hpaDuplicateTargetCheck) Description() string {
+	return "Checks if more than one horizontal pod autoscaler targets the same workload"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (h *hpaDuplicateTargetCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.HorizontalPodAutoscalers}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (h *hpaDuplicateTargetCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+
+	byTarget := make(map[string][]string)
+	for _, hpa := range objects.HorizontalPodAutoscalers.Items {
+		key := scaleTargetKey(hpa)
+		byTarget[key] = append(byTarget[key], hpa.Name)
+	}
+
+	for _, hpa := range objects.HorizontalPodAutoscalers.Items {
+		var others []string
+		for _, name := range byTarget[scaleTargetKey(hpa)] {
+			if name != hpa.Name {
+				others = append(others, name)
+			}
+		}
+		if len(others) == 0 {
+			continue
+		}
+		sort.Strings(others)
+		hpa := hpa
+		d := checks.Diagnostic{
+			Severity: checks.Error,
+			Message:  fmt.Sprintf("%s is also scaled by other horizontal pod autoscalers. The autoscalers will keep overriding each other's replica count.", describeScaleTarget(hpa.Spec.ScaleTargetRef)),
+			Kind:     checks.HorizontalPodAutoscaler,
+			Object:   &hpa.ObjectMeta,
+			Owners:   hpa.ObjectMeta.GetOwnerReferences(),
+			Details:  fmt.Sprintf("Other horizontal pod autoscalers: %s", strings.Join(others, ", ")),
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics, nil
+}
+
+// hpaTarget returns the pod template of the workload scaled by an HPA. known
+// is false if clusterlint does not fetch workloads of the target's kind, and
+// found is false if the workload does not exist.
+func hpaTarget(objects *kube.Objects, hpa autoscalingv2.HorizontalPodAutoscaler) (template *corev1.PodTemplateSpec, known, found bool) {
+	target := hpa.Spec.ScaleTargetRef
+	gv, err := schema.ParseGroupVersion(target.APIVersion)
+	if err != nil || gv.Group != appsv1.GroupName {
+		return nil, false, false
+	}
+
+	switch target.Kind {
+	case "Deployment":
+		for _, deployment := range objects.Deployments.Items {
+			if deployment.Namespace == hpa.Namespace && deployment.Name == target.Name {
+				return &deployment.Spec.Template, true, true
+			}
+		}
+		return nil, true, false
+	case "StatefulSet":
+		for _, statefulSet := range objects.StatefulSets.Items {
+			if statefulSet.Namespace == hpa.Namespace && statefulSet.Name == target.Name {
+				return &statefulSet.Spec.Template, true, true
+			}
+		}
+		return nil, true, false
+	}
+	return nil, false, false
+}
+
+// utilizationMetric returns the resource and, for container resource metrics,
+// the container whose utilization an HPA metric targets. Utilization is
+// relative to the resource requests, other target types are not.
+func utilizationMetric(metric autoscalingv2.MetricSpec) (resource corev1.ResourceName, container string, ok bool) {
+	switch metric.Type {
+	case autoscalingv2.ResourceMetricSourceType:
+		if metric.Resource != nil && metric.Resource.Target.Type == autoscalingv2.UtilizationMetricType {
+			return metric.Resource.Name, "", true
+		}
+	case autoscalingv2.ContainerResourceMetricSourceType:
+		if metric.ContainerResource != nil && metric.ContainerResource.Target.Type == autoscalingv2.UtilizationMetricType {
+			return metric.ContainerResource.Name, metric.ContainerResource.Container, true
+		}
+	}
+	return "", "", false
+}
+
+func scaleTargetKey(hpa autoscalingv2.HorizontalPodAutoscaler) string {
+	target := hpa.Spec.ScaleTargetRef
+	group := ""
+	if gv, err := schema.ParseGroupVersion(target.APIVersion); err == nil {
+		group = gv.Group
+	}
+	return strings.Join([]string{hpa.Namespace, group, target.Kind, target.Name}, "/")
+}
+
+func describeScaleTarget(target autoscalingv2.CrossVersionObjectReference) string {
+	return fmt.Sprintf("%s `%s`", target.Kind, target.Name)
+}
@@ -0,0 +1,287 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package basic
+
+import (
+	"testing"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHPAChecksMeta(t *testing.T) {
+	for name, check := range map[string]checks.Check{
+		"hpa-target":            &hpaTargetCheck{},
+		"hpa-resource-requests": &hpaResourceRequestsCheck{},
+		"hpa-replica-range":     &hpaReplicaRangeCheck{},
+		"hpa-duplicate-target":  &hpaDuplicateTargetCheck{},
+	} {
+		assert.Equal(t, name, check.Name())
+		assert.Equal(t, []string{"basic"}, check.Groups())
+		assert.NotEmpty(t, check.Description())
+
+		registered, err := checks.Get(name)
+		assert.NoError(t, err)
+		assert.IsType(t, check, registered)
+	}
+}
+
+func TestHPATargetError(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   autoscalingv2.CrossVersionObjectReference
+		expected []string
+	}{
+		{
+			name:     "existing deployment",
+			target:   autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"},
+			expected: nil,
+		},
+		{
+			name:     "existing stateful set",
+			target:   autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "StatefulSet", Name: "db"},
+			expected: nil,
+		},
+		{
+			name:     "missing deployment",
+			target:   autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "api"},
+			expected: []string{"Scale target Deployment `api` does not exist"},
+		},
+		{
+			name:     "custom resource",
+			target:   autoscalingv2.CrossVersionObjectReference{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout", Name: "web"},
+			expected: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := initHPAs()
+			withHPA(objs, "web", test.target)
+
+			d, err := (&hpaTargetCheck{}).Run(objs)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, hpaMessages(t, d, objs))
+		})
+	}
+}
+
+func TestHPAResourceRequestsError(t *testing.T) {
+	cpu := func(container string) autoscalingv2.MetricSpec {
+		target := autoscalingv2.MetricTarget{Type: autoscalingv2.UtilizationMetricType}
+		if container != "" {
+			return autoscalingv2.MetricSpec{
+				Type:              autoscalingv2.ContainerResourceMetricSourceType,
+				ContainerResource: &autoscalingv2.ContainerResourceMetricSource{Name: corev1.ResourceCPU, Container: container, Target: target},
+			}
+		}
+		return autoscalingv2.MetricSpec{
+			Type:     autoscalingv2.ResourceMetricSourceType,
+			Resource: &autoscalingv2.ResourceMetricSource{Name: corev1.ResourceCPU, Target: target},
+		}
+	}
+	averageValue := resource.MustParse("500m")
+
+	tests := []struct {
+		name        string
+		metric      autoscalingv2.MetricSpec
+		limitRange  bool
+		expected    []string
+		withDetails string
+	}{
+		{
+			name:        "resource utilization without requests",
+			metric:      cpu(""),
+			expected:    []string{"Horizontal pod autoscaler scales on `cpu` utilization, but containers of Deployment `web` do not request `cpu`. The utilization cannot be computed."},
+			withDetails: "Containers without requests: sidecar",
+		},
+		{
+			name:   "container resource utilization with requests",
+			metric: cpu("app"),
+		},
+		{
+			name:        "container resource utilization without requests",
+			metric:      cpu("sidecar"),
+			expected:    []string{"Horizontal pod autoscaler scales on `cpu` utilization, but containers of Deployment `web` do not request `cpu`. The utilization cannot be computed."},
+			withDetails: "Containers without requests: sidecar",
+		},
+		{
+			name:       "limit range supplies default requests",
+			metric:     cpu(""),
+			limitRange: true,
+		},
+		{
+			name: "average value",
+			metric: autoscalingv2.MetricSpec{
+				Type: autoscalingv2.ResourceMetricSourceType,
+				Resource: &autoscalingv2.ResourceMetricSource{
+					Name:   corev1.ResourceCPU,
+					Target: autoscalingv2.MetricTarget{Type: autoscalingv2.AverageValueMetricType, AverageValue: &averageValue},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := initHPAs()
+			withHPA(objs, "web", autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"})
+			objs.HorizontalPodAutoscalers.Items[0].Spec.Metrics = []autoscalingv2.MetricSpec{test.metric}
+			if test.limitRange {
+				objs.LimitRanges.Items = []corev1.LimitRange{{
+					ObjectMeta: metav1.ObjectMeta{Name: "defaults", Namespace: "k8s"},
+					Spec: corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{
+						Type:           corev1.LimitTypeContainer,
+						DefaultRequest: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+					}}},
+				}}
+			}
+
+			d, err := (&hpaResourceRequestsCheck{}).Run(objs)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, hpaMessages(t, d, objs))
+			for _, diagnostic := range d {
+				assert.Equal(t, test.withDetails, diagnostic.Details)
+			}
+		})
+	}
+}
+
+func TestHPAReplicaRangeWarning(t *testing.T) {
+	replicas := func(n int32) *int32 { return &n }
+
+	tests := []struct {
+		name     string
+		min      *int32
+		max      int32
+		expected []string
+	}{
+		{
+			name: "range",
+			min:  replicas(2),
+			max:  10,
+		},
+		{
+			name:     "equal",
+			min:      replicas(3),
+			max:      3,
+			expected: []string{"Horizontal pod autoscaler never scales because minReplicas and maxReplicas are both 3"},
+		},
+		{
+			name:     "default minimum",
+			max:      1,
+			expected: []string{"Horizontal pod autoscaler never scales because minReplicas and maxReplicas are both 1"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := initHPAs()
+			withHPA(objs, "web", autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"})
+			objs.HorizontalPodAutoscalers.Items[0].Spec.MinReplicas = test.min
+			objs.HorizontalPodAutoscalers.Items[0].Spec.MaxReplicas = test.max
+
+			d, err := (&hpaReplicaRangeCheck{}).Run(objs)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, hpaMessages(t, d, objs))
+		})
+	}
+}
+
+func TestHPADuplicateTargetError(t *testing.T) {
+	objs := initHPAs()
+	withHPA(objs, "web-cpu", autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"})
+	withHPA(objs, "web-memory", autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"})
+	withHPA(objs, "db", autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "StatefulSet", Name: "db"})
+
+	d, err := (&hpaDuplicateTargetCheck{}).Run(objs)
+	assert.NoError(t, err)
+	assert.Equal(t, []checks.Diagnostic{
+		{
+			Severity: checks.Error,
+			Message:  "Deployment `web` is also scaled by other horizontal pod autoscalers. The autoscalers will keep overriding each other's replica count.",
+			Kind:     checks.HorizontalPodAutoscaler,
+			Object:   &objs.HorizontalPodAutoscalers.Items[0].ObjectMeta,
+			Details:  "Other horizontal pod autoscalers: web-memory",
+		},
+		{
+			Severity: checks.Error,
+			Message:  "Deployment `web` is also scaled by other horizontal pod autoscalers. The autoscalers will keep overriding each other's replica count.",
+			Kind:     checks.HorizontalPodAutoscaler,
+			Object:   &objs.HorizontalPodAutoscalers.Items[1].ObjectMeta,
+			Details:  "Other horizontal pod autoscalers: web-cpu",
+		},
+	}, d)
+}
+
+func initHPAs() *kube.Objects {
+	cpu := corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m")}}
+	return &kube.Objects{
+		Deployments: &appsv1.DeploymentList{
+			Items: []appsv1.Deployment{{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "k8s"},
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{Name: "app", Resources: cpu},
+								{Name: "sidecar"},
+							},
+						},
+					},
+				},
+			}},
+		},
+		StatefulSets: &appsv1.StatefulSetList{
+			Items: []appsv1.StatefulSet{{
+				ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "k8s"},
+			}},
+		},
+		LimitRanges:              &corev1.LimitRangeList{},
+		HorizontalPodAutoscalers: &autoscalingv2.HorizontalPodAutoscalerList{},
+	}
+}
+
+func withHPA(objs *kube.Objects, name string, target autoscalingv2.CrossVersionObjectReference) {
+	minReplicas := int32(1)
+	objs.HorizontalPodAutoscalers.Items = append(objs.HorizontalPodAutoscalers.Items, autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "k8s"},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: target,
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    5,
+		},
+	})
+}
+
+// hpaMessages returns the messages of the diagnostics after asserting that
+// they are all about the first HPA.
+func hpaMessages(t *testing.T, diagnostics []checks.Diagnostic, objs *kube.Objects) []string {
+	var messages []string
+	for _, d := range diagnostics {
+		assert.Equal(t, checks.HorizontalPodAutoscaler, d.Kind)
+		assert.Equal(t, &objs.HorizontalPodAutoscalers.Items[0].ObjectMeta, d.Object)
+		messages = append(messages, d.Message)
+	}
+	return messages
+}
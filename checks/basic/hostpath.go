@@ -45,6 +45,11 @@ func (h *hostPathCheck) Description() string {
 	return "Check if there are pods using hostpath volumes"
 }
 
+// Resources returns the resources whose objects this check reads.
+func (h *hostPathCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Pods}
+}
+
 // Run runs this check on a set of Kubernetes objects. It can return warnings
 // (low-priority problems) and errors (high-priority problems) as well as an
 // error value indicating that the check failed to run.
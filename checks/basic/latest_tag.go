@@ -48,6 +48,11 @@ func (l *latestTagCheck) Description() string {
 	return "Checks if there are pods with container images having latest tag"
 }
 
+// Resources returns the resources whose objects this check reads.
+func (l *latestTagCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Pods}
+}
+
 // Run runs this check on a set of Kubernetes objects. It can return warnings
 // (low-priority problems) and errors (high-priority problems) as well as an
 // error value indicating that the check failed to run.
@@ -0,0 +1,180 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package basic
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func init() {
+	checks.Register(&limitRangeConflictCheck{})
+}
+
+type limitRangeConflictCheck struct{}
+
+// Name returns a unique name for this check.
+func (l *limitRangeConflictCheck) Name() string {
+	return "limit-range-conflict"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (l *limitRangeConflictCheck) Groups() []string {
+	return []string{"basic", "doks"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (l *limitRangeConflictCheck) Description() string {
+	return "Checks if there are pods whose resource requirements conflict with the limit ranges in their namespace"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (l *limitRangeConflictCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.LimitRanges, kube.Pods}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (l *limitRangeConflictCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	limitRanges := limitRangesByNamespace(objects)
+
+	for _, pod := range objects.Pods.Items {
+		pod := pod
+		ranges := limitRanges[pod.Namespace]
+		if len(ranges) == 0 {
+			continue
+		}
+		var containers []corev1.Container
+		containers = append(containers, pod.Spec.Containers...)
+		containers = append(containers, pod.Spec.InitContainers...)
+		for _, container := range containers {
+			for _, lr := range ranges {
+				conflicts := limitRangeConflicts(container, lr)
+				if len(conflicts) == 0 {
+					continue
+				}
+				d := checks.Diagnostic{
+					Severity: checks.Warning,
+					Message:  fmt.Sprintf("Container `%s` conflicts with limit range `%s` and would be rejected if the pod is recreated", container.Name, lr.Name),
+					Kind:     checks.Pod,
+					Object:   &pod.ObjectMeta,
+					Owners:   pod.ObjectMeta.GetOwnerReferences(),
+					Details:  strings.Join(conflicts, "; "),
+				}
+				diagnostics = append(diagnostics, d)
+			}
+		}
+	}
+
+	return diagnostics, nil
+}
+
+// limitRangeConflicts returns a description of every constraint of a limit
+// range's container items that the container violates.
+func limitRangeConflicts(container corev1.Container, lr corev1.LimitRange) []string {
+	var conflicts []string
+	for _, item := range lr.Spec.Limits {
+		if item.Type != corev1.LimitTypeContainer {
+			continue
+		}
+		for _, name := range sortedResourceNames(item.Min) {
+			min := item.Min[name]
+			if req, ok := container.Resources.Requests[name]; ok && req.Cmp(min) < 0 {
+				conflicts = append(conflicts, fmt.Sprintf("%s request %s is below minimum %s", name, req.String(), min.String()))
+			}
+			if limit, ok := container.Resources.Limits[name]; ok && limit.Cmp(min) < 0 {
+				conflicts = append(conflicts, fmt.Sprintf("%s limit %s is below minimum %s", name, limit.String(), min.String()))
+			}
+		}
+		for _, name := range sortedResourceNames(item.Max) {
+			max := item.Max[name]
+			if req, ok := container.Resources.Requests[name]; ok && req.Cmp(max) > 0 {
+				conflicts = append(conflicts, fmt.Sprintf("%s request %s is above maximum %s", name, req.String(), max.String()))
+			}
+			if limit, ok := container.Resources.Limits[name]; ok && limit.Cmp(max) > 0 {
+				conflicts = append(conflicts, fmt.Sprintf("%s limit %s is above maximum %s", name, limit.String(), max.String()))
+			}
+		}
+		for _, name := range sortedResourceNames(item.Default) {
+			def := item.Default[name]
+			if _, ok := container.Resources.Limits[name]; ok {
+				continue
+			}
+			// The default limit is applied to containers without a limit,
+			// and admission rejects requests that exceed it.
+			if req, ok := container.Resources.Requests[name]; ok && req.Cmp(def) > 0 {
+				conflicts = append(conflicts, fmt.Sprintf("%s request %s is above default limit %s", name, req.String(), def.String()))
+			}
+		}
+	}
+	return conflicts
+}
+
+// limitRangesByNamespace groups the limit ranges in the cluster by namespace.
+func limitRangesByNamespace(objects *kube.Objects) map[string][]corev1.LimitRange {
+	ret := make(map[string][]corev1.LimitRange)
+	if objects.LimitRanges == nil {
+		return ret
+	}
+	for _, lr := range objects.LimitRanges.Items {
+		ret[lr.Namespace] = append(ret[lr.Namespace], lr)
+	}
+	return ret
+}
+
+// limitRangeDefaults returns the requests and limits that the given limit
+// ranges apply to containers which do not set their own.
+func limitRangeDefaults(ranges []corev1.LimitRange) (requests, limits corev1.ResourceList) {
+	requests = corev1.ResourceList{}
+	limits = corev1.ResourceList{}
+	for _, lr := range ranges {
+		for _, item := range lr.Spec.Limits {
+			if item.Type != corev1.LimitTypeContainer {
+				continue
+			}
+			for name, q := range item.Default {
+				limits[name] = q
+				// Requests default to the limit when no default request is
+				// given.
+				if _, ok := requests[name]; !ok {
+					requests[name] = q
+				}
+			}
+			for name, q := range item.DefaultRequest {
+				requests[name] = q
+			}
+		}
+	}
+	return requests, limits
+}
+
+func sortedResourceNames(list corev1.ResourceList) []corev1.ResourceName {
+	names := make([]corev1.ResourceName, 0, len(list))
+	for name := range list {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
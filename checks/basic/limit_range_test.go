@@ -0,0 +1,175 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package basic
+
+import (
+	"testing"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLimitRangeConflictCheckMeta(t *testing.T) {
+	limitRangeConflictCheck := limitRangeConflictCheck{}
+	assert.Equal(t, "limit-range-conflict", limitRangeConflictCheck.Name())
+	assert.Equal(t, []string{"basic", "doks"}, limitRangeConflictCheck.Groups())
+	assert.NotEmpty(t, limitRangeConflictCheck.Description())
+}
+
+func TestLimitRangeConflictCheckRegistration(t *testing.T) {
+	limitRangeConflictCheck := &limitRangeConflictCheck{}
+	check, err := checks.Get("limit-range-conflict")
+	assert.NoError(t, err)
+	assert.Equal(t, check, limitRangeConflictCheck)
+}
+
+func TestLimitRangeConflictWarning(t *testing.T) {
+	const message = "Container `bar` conflicts with limit range `limits` and would be rejected if the pod is recreated"
+
+	limitRangeConflictCheck := limitRangeConflictCheck{}
+
+	tests := []struct {
+		name     string
+		objs     *kube.Objects
+		expected []checks.Diagnostic
+	}{
+		{
+			name:     "no limit ranges",
+			objs:     containerResources(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}, nil),
+			expected: nil,
+		},
+		{
+			name: "requests within range",
+			objs: withLimitRange(containerResources(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")}, nil), corev1.LimitRangeItem{
+				Type: corev1.LimitTypeContainer,
+				Min:  corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+				Max:  corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			}),
+			expected: nil,
+		},
+		{
+			name: "request above max",
+			objs: withLimitRange(containerResources(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}, nil), corev1.LimitRangeItem{
+				Type: corev1.LimitTypeContainer,
+				Max:  corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			}),
+			expected: []checks.Diagnostic{
+				{
+					Severity: checks.Warning,
+					Message:  message,
+					Kind:     checks.Pod,
+					Object:   GetObjectMeta(),
+					Owners:   GetOwners(),
+					Details:  "cpu request 2 is above maximum 1",
+				},
+			},
+		},
+		{
+			name: "limit below min",
+			objs: withLimitRange(containerResources(nil, corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")}), corev1.LimitRangeItem{
+				Type: corev1.LimitTypeContainer,
+				Min:  corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+			}),
+			expected: []checks.Diagnostic{
+				{
+					Severity: checks.Warning,
+					Message:  message,
+					Kind:     checks.Pod,
+					Object:   GetObjectMeta(),
+					Owners:   GetOwners(),
+					Details:  "memory limit 64Mi is below minimum 128Mi",
+				},
+			},
+		},
+		{
+			name: "request above default limit",
+			objs: withLimitRange(containerResources(corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}, nil), corev1.LimitRangeItem{
+				Type:    corev1.LimitTypeContainer,
+				Default: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+			}),
+			expected: []checks.Diagnostic{
+				{
+					Severity: checks.Warning,
+					Message:  message,
+					Kind:     checks.Pod,
+					Object:   GetObjectMeta(),
+					Owners:   GetOwners(),
+					Details:  "memory request 1Gi is above default limit 512Mi",
+				},
+			},
+		},
+		{
+			name: "request above default limit with explicit limit",
+			objs: withLimitRange(containerResources(
+				corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+				corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+			), corev1.LimitRangeItem{
+				Type:    corev1.LimitTypeContainer,
+				Default: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+			}),
+			expected: nil,
+		},
+		{
+			name: "pod limit range item",
+			objs: withLimitRange(containerResources(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}, nil), corev1.LimitRangeItem{
+				Type: corev1.LimitTypePod,
+				Max:  corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			}),
+			expected: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d, err := limitRangeConflictCheck.Run(test.objs)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, test.expected, d)
+		})
+	}
+}
+
+func containerResources(requests, limits corev1.ResourceList) *kube.Objects {
+	objs := initPod()
+	objs.Pods.Items[0].Spec = corev1.PodSpec{
+		Containers: []corev1.Container{
+			{
+				Name:  "bar",
+				Image: "alpine",
+				Resources: corev1.ResourceRequirements{
+					Requests: requests,
+					Limits:   limits,
+				},
+			}},
+	}
+	return objs
+}
+
+func withLimitRange(objs *kube.Objects, items ...corev1.LimitRangeItem) *kube.Objects {
+	objs.LimitRanges = &corev1.LimitRangeList{
+		Items: []corev1.LimitRange{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "limits", Namespace: "k8s"},
+				Spec:       corev1.LimitRangeSpec{Limits: items},
+			},
+		},
+	}
+	return objs
+}
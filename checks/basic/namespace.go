@@ -77,6 +77,17 @@ func (nc *defaultNamespaceCheck) Description() string {
 	return "Checks if there are any user created k8s objects in the default namespace."
 }
 
+// Resources returns the resources whose objects this check reads.
+func (nc *defaultNamespaceCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.ConfigMaps, kube.PersistentVolumeClaims, kube.PodTemplates, kube.Pods, kube.Secrets, kube.ServiceAccounts, kube.Services}
+}
+
+// MetadataOnly returns the resources whose objects this check only reads the
+// metadata of. Secrets are read for their type.
+func (nc *defaultNamespaceCheck) MetadataOnly() []kube.Resource {
+	return []kube.Resource{kube.ConfigMaps, kube.PersistentVolumeClaims, kube.PodTemplates, kube.Pods, kube.ServiceAccounts, kube.Services}
+}
+
 // checkPods checks if there are pods in the default namespace
 func (nc *defaultNamespaceCheck) checkPods(items *corev1.PodList, alert *alert) {
 	for _, item := range items.Items {
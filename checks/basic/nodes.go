@@ -0,0 +1,309 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package basic
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+// defaultMinCordonedHours is the number of hours after which cordoned nodes
+// are reported.
+const defaultMinCordonedHours = 24
+
+// pressureConditions maps the node conditions that report resource pressure
+// to the resource that is running low.
+var pressureConditions = map[corev1.NodeConditionType]string{
+	corev1.NodeMemoryPressure: "memory",
+	corev1.NodeDiskPressure:   "disk space",
+	corev1.NodePIDPressure:    "process IDs",
+}
+
+// now returns the current time. It is replaced in tests.
+var now = time.Now
+
+func init() {
+	checks.Register(&nodeConditionsCheck{})
+	checks.Register(&nodeCordonedCheck{minHours: defaultMinCordonedHours})
+	checks.Register(&kubeletVersionSkewCheck{})
+}
+
+type nodeConditionsCheck struct{}
+
+// Name returns a unique name for this check.
+func (n *nodeConditionsCheck) Name() string {
+	return "node-conditions"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (n *nodeConditionsCheck) Groups() []string {
+	return []string{"node-health"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (n *nodeConditionsCheck) Description() string {
+	return "Checks if there are nodes that are not ready or run low on memory, disk space or process IDs"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (n *nodeConditionsCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Nodes}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (n *nodeConditionsCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	for i := range objects.Nodes.Items {
+		node := &objects.Nodes.Items[i]
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == corev1.NodeReady {
+				if condition.Status != corev1.ConditionTrue {
+					diagnostics = append(diagnostics, checks.Diagnostic{
+						Severity: checks.Error,
+						Message:  "Node is not ready. Pods on the node are evicted and no new pods are scheduled onto it.",
+						Kind:     checks.Node,
+						Object:   &node.ObjectMeta,
+						Details:  conditionDetails(condition),
+					})
+				}
+				continue
+			}
+			if resource, ok := pressureConditions[condition.Type]; ok && condition.Status == corev1.ConditionTrue {
+				diagnostics = append(diagnostics, checks.Diagnostic{
+					Severity: checks.Warning,
+					Message:  fmt.Sprintf("Node runs low on %s (`%s`). Pods may be evicted from the node.", resource, condition.Type),
+					Kind:     checks.Node,
+					Object:   &node.ObjectMeta,
+					Details:  conditionDetails(condition),
+				})
+			}
+		}
+	}
+	return diagnostics, nil
+}
+
+// conditionDetails describes the status of a node condition and since when
+// the node has been in it.
+func conditionDetails(condition corev1.NodeCondition) string {
+	details := []string{fmt.Sprintf("Status: %s", condition.Status)}
+	if condition.Reason != "" {
+		details = append(details, fmt.Sprintf("Reason: %s", condition.Reason))
+	}
+	if condition.Message != "" {
+		details = append(details, fmt.Sprintf("Message: %s", strings.TrimSuffix(condition.Message, ".")))
+	}
+	if !condition.LastTransitionTime.IsZero() {
+		details = append(details, fmt.Sprintf("Since: %s", condition.LastTransitionTime.UTC().Format(time.RFC3339)))
+	}
+	return strings.Join(details, ". ")
+}
+
+type nodeCordonedCheck struct {
+	minHours int64
+}
+
+// Name returns a unique name for this check.
+func (n *nodeCordonedCheck) Name() string {
+	return "node-cordoned"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (n *nodeCordonedCheck) Groups() []string {
+	return []string{"node-health"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (n *nodeCordonedCheck) Description() string {
+	return "Checks if there are nodes that have been cordoned for a long time"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (n *nodeCordonedCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Nodes}
+}
+
+// Configure sets the thresholds used by the check. The only supported setting
+// is `min-hours`.
+func (n *nodeCordonedCheck) Configure(settings map[string]string) error {
+	for key, value := range settings {
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || v < 0 {
+			return fmt.Errorf("invalid value %q for %s", value, key)
+		}
+		switch key {
+		case "min-hours":
+			n.minHours = v
+		default:
+			return fmt.Errorf("unknown setting %q", key)
+		}
+	}
+	return nil
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (n *nodeCordonedCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	for i := range objects.Nodes.Items {
+		node := &objects.Nodes.Items[i]
+		if !node.Spec.Unschedulable {
+			continue
+		}
+		// The node lifecycle controller taints cordoned nodes and records
+		// when it did so. Without the taint it is unknown since when the
+		// node has been cordoned.
+		for _, taint := range node.Spec.Taints {
+			if taint.Key != corev1.TaintNodeUnschedulable || taint.TimeAdded == nil {
+				continue
+			}
+			hours := int64(now().Sub(taint.TimeAdded.Time).Hours())
+			if hours < n.minHours {
+				break
+			}
+			diagnostics = append(diagnostics, checks.Diagnostic{
+				Severity: checks.Warning,
+				Message:  fmt.Sprintf("Node has been cordoned for %d hours. No new pods are scheduled onto the node, which reduces the capacity of the cluster.", hours),
+				Kind:     checks.Node,
+				Object:   &node.ObjectMeta,
+				Details:  fmt.Sprintf("Cordoned since: %s", taint.TimeAdded.UTC().Format(time.RFC3339)),
+			})
+			break
+		}
+	}
+	return diagnostics, nil
+}
+
+type kubeletVersionSkewCheck struct{}
+
+// Name returns a unique name for this check.
+func (k *kubeletVersionSkewCheck) Name() string {
+	return "kubelet-version-skew"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (k *kubeletVersionSkewCheck) Groups() []string {
+	return []string{"node-health"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (k *kubeletVersionSkewCheck) Description() string {
+	return "Checks if the kubelet versions of nodes are within the version skew supported by the control plane"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (k *kubeletVersionSkewCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Nodes}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (k *kubeletVersionSkewCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	controlPlane, ok := serverVersion(objects.ServerVersion)
+	if !ok {
+		return nil, nil
+	}
+	maxSkew := supportedKubeletSkew(controlPlane)
+
+	var diagnostics []checks.Diagnostic
+	for i := range objects.Nodes.Items {
+		node := &objects.Nodes.Items[i]
+		kubelet, ok := parseMinorVersion(node.Status.NodeInfo.KubeletVersion)
+		if !ok || kubelet.major != controlPlane.major {
+			continue
+		}
+		var message string
+		switch skew := controlPlane.minor - kubelet.minor; {
+		case skew < 0:
+			message = fmt.Sprintf("Kubelet version %s is newer than the control plane version %s. Kubelets must not be newer than the API server.", kubelet, controlPlane)
+		case skew > maxSkew:
+			message = fmt.Sprintf("Kubelet version %s is %d minor versions older than the control plane version %s. Kubelets may be at most %d minor versions older than the API server.", kubelet, skew, controlPlane, maxSkew)
+		default:
+			continue
+		}
+		diagnostics = append(diagnostics, checks.Diagnostic{
+			Severity: checks.Error,
+			Message:  message,
+			Kind:     checks.Node,
+			Object:   &node.ObjectMeta,
+			Details:  fmt.Sprintf("Kubelet version: %s", node.Status.NodeInfo.KubeletVersion),
+		})
+	}
+	return diagnostics, nil
+}
+
+// minorVersion is the major and minor part of a Kubernetes version.
+type minorVersion struct {
+	major, minor int
+}
+
+func (v minorVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.major, v.minor)
+}
+
+// serverVersion returns the minor version of the API server. Some providers
+// append a `+` to the minor version, so the git version is preferred.
+func serverVersion(info *version.Info) (minorVersion, bool) {
+	if info == nil {
+		return minorVersion{}, false
+	}
+	if v, ok := parseMinorVersion(info.GitVersion); ok {
+		return v, true
+	}
+	return parseMinorVersion(info.Major + "." + strings.TrimSuffix(info.Minor, "+"))
+}
+
+// parseMinorVersion parses versions like `v1.30.2-do.0` and returns their
+// major and minor part.
+func parseMinorVersion(s string) (minorVersion, bool) {
+	parts := strings.SplitN(strings.TrimPrefix(s, "v"), ".", 3)
+	if len(parts) < 2 {
+		return minorVersion{}, false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return minorVersion{}, false
+	}
+	minor, err := strconv.Atoi(strings.TrimSuffix(parts[1], "+"))
+	if err != nil {
+		return minorVersion{}, false
+	}
+	return minorVersion{major: major, minor: minor}, true
+}
+
+// supportedKubeletSkew returns how many minor versions kubelets may be older
+// than the API server. The supported skew was increased from 2 to 3 minor
+// versions in Kubernetes 1.28.
+func supportedKubeletSkew(controlPlane minorVersion) int {
+	if controlPlane.major == 1 && controlPlane.minor < 28 {
+		return 2
+	}
+	return 3
+}
@@ -0,0 +1,253 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package basic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+func TestNodeChecksMeta(t *testing.T) {
+	for name, check := range map[string]checks.Check{
+		"node-conditions":      &nodeConditionsCheck{},
+		"node-cordoned":        &nodeCordonedCheck{},
+		"kubelet-version-skew": &kubeletVersionSkewCheck{},
+	} {
+		assert.Equal(t, name, check.Name())
+		assert.Equal(t, []string{"node-health"}, check.Groups())
+		assert.NotEmpty(t, check.Description())
+
+		registered, err := checks.Get(name)
+		assert.NoError(t, err)
+		assert.IsType(t, check, registered)
+	}
+}
+
+func TestNodeConditions(t *testing.T) {
+	since := metav1.NewTime(time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC))
+	tests := []struct {
+		name       string
+		conditions []corev1.NodeCondition
+		expected   []checks.Diagnostic
+	}{
+		{
+			name: "healthy node",
+			conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+				{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionFalse},
+			},
+			expected: nil,
+		},
+		{
+			name: "not ready",
+			conditions: []corev1.NodeCondition{{
+				Type:               corev1.NodeReady,
+				Status:             corev1.ConditionUnknown,
+				Reason:             "NodeStatusUnknown",
+				Message:            "Kubelet stopped posting node status.",
+				LastTransitionTime: since,
+			}},
+			expected: []checks.Diagnostic{{
+				Severity: checks.Error,
+				Message:  "Node is not ready. Pods on the node are evicted and no new pods are scheduled onto it.",
+				Kind:     checks.Node,
+				Details:  "Status: Unknown. Reason: NodeStatusUnknown. Message: Kubelet stopped posting node status. Since: 2022-03-01T12:00:00Z",
+			}},
+		},
+		{
+			name: "disk and PID pressure",
+			conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+				{Type: corev1.NodeDiskPressure, Status: corev1.ConditionTrue, Reason: "KubeletHasDiskPressure"},
+				{Type: corev1.NodePIDPressure, Status: corev1.ConditionTrue},
+			},
+			expected: []checks.Diagnostic{
+				{
+					Severity: checks.Warning,
+					Message:  "Node runs low on disk space (`DiskPressure`). Pods may be evicted from the node.",
+					Kind:     checks.Node,
+					Details:  "Status: True. Reason: KubeletHasDiskPressure",
+				},
+				{
+					Severity: checks.Warning,
+					Message:  "Node runs low on process IDs (`PIDPressure`). Pods may be evicted from the node.",
+					Kind:     checks.Node,
+					Details:  "Status: True",
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := initNode()
+			objs.Nodes.Items[0].Status.Conditions = test.conditions
+			for i := range test.expected {
+				test.expected[i].Object = &objs.Nodes.Items[0].ObjectMeta
+			}
+
+			d, err := (&nodeConditionsCheck{}).Run(objs)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, d)
+		})
+	}
+}
+
+func TestNodeCordoned(t *testing.T) {
+	current := time.Date(2022, 3, 3, 12, 0, 0, 0, time.UTC)
+	defer func() { now = time.Now }()
+	now = func() time.Time { return current }
+
+	cordoned := func(hours int) func(*corev1.Node) {
+		return func(node *corev1.Node) {
+			added := metav1.NewTime(current.Add(-time.Duration(hours) * time.Hour))
+			node.Spec.Unschedulable = true
+			node.Spec.Taints = []corev1.Taint{{Key: corev1.TaintNodeUnschedulable, Effect: corev1.TaintEffectNoSchedule, TimeAdded: &added}}
+		}
+	}
+
+	tests := []struct {
+		name     string
+		node     func(*corev1.Node)
+		expected []string
+	}{
+		{
+			name:     "schedulable node",
+			node:     func(*corev1.Node) {},
+			expected: nil,
+		},
+		{
+			name:     "recently cordoned",
+			node:     cordoned(3),
+			expected: nil,
+		},
+		{
+			name:     "cordoned for days",
+			node:     cordoned(50),
+			expected: []string{"Node has been cordoned for 50 hours. No new pods are scheduled onto the node, which reduces the capacity of the cluster."},
+		},
+		{
+			name:     "cordoned without taint",
+			node:     func(node *corev1.Node) { node.Spec.Unschedulable = true },
+			expected: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := initNode()
+			test.node(&objs.Nodes.Items[0])
+
+			d, err := (&nodeCordonedCheck{minHours: defaultMinCordonedHours}).Run(objs)
+			assert.NoError(t, err)
+			var messages []string
+			for _, diagnostic := range d {
+				assert.Equal(t, checks.Warning, diagnostic.Severity)
+				messages = append(messages, diagnostic.Message)
+			}
+			assert.Equal(t, test.expected, messages)
+		})
+	}
+}
+
+func TestNodeCordonedConfigure(t *testing.T) {
+	check := &nodeCordonedCheck{minHours: defaultMinCordonedHours}
+	assert.NoError(t, check.Configure(map[string]string{"min-hours": "72"}))
+	assert.Equal(t, int64(72), check.minHours)
+	assert.Error(t, check.Configure(map[string]string{"min-hours": "-1"}))
+	assert.Error(t, check.Configure(map[string]string{"min-days": "1"}))
+}
+
+func TestKubeletVersionSkew(t *testing.T) {
+	tests := []struct {
+		name     string
+		server   *version.Info
+		kubelet  string
+		expected []string
+	}{
+		{
+			name:     "same version",
+			server:   &version.Info{GitVersion: "v1.30.2"},
+			kubelet:  "v1.30.2",
+			expected: nil,
+		},
+		{
+			name:     "supported skew",
+			server:   &version.Info{GitVersion: "v1.30.2"},
+			kubelet:  "v1.27.9",
+			expected: nil,
+		},
+		{
+			name:     "kubelet too old",
+			server:   &version.Info{GitVersion: "v1.30.2"},
+			kubelet:  "v1.26.5-do.0",
+			expected: []string{"Kubelet version 1.26 is 4 minor versions older than the control plane version 1.30. Kubelets may be at most 3 minor versions older than the API server."},
+		},
+		{
+			name:     "kubelet too old before 1.28",
+			server:   &version.Info{Major: "1", Minor: "27+"},
+			kubelet:  "v1.24.1",
+			expected: []string{"Kubelet version 1.24 is 3 minor versions older than the control plane version 1.27. Kubelets may be at most 2 minor versions older than the API server."},
+		},
+		{
+			name:     "kubelet newer",
+			server:   &version.Info{GitVersion: "v1.29.0"},
+			kubelet:  "v1.30.1",
+			expected: []string{"Kubelet version 1.30 is newer than the control plane version 1.29. Kubelets must not be newer than the API server."},
+		},
+		{
+			name:     "unknown control plane version",
+			server:   &version.Info{},
+			kubelet:  "v1.20.0",
+			expected: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := initNode()
+			objs.ServerVersion = test.server
+			objs.Nodes.Items[0].Status.NodeInfo.KubeletVersion = test.kubelet
+
+			d, err := (&kubeletVersionSkewCheck{}).Run(objs)
+			assert.NoError(t, err)
+			var messages []string
+			for _, diagnostic := range d {
+				assert.Equal(t, checks.Error, diagnostic.Severity)
+				assert.Equal(t, "Kubelet version: "+test.kubelet, diagnostic.Details)
+				messages = append(messages, diagnostic.Message)
+			}
+			assert.Equal(t, test.expected, messages)
+		})
+	}
+}
+
+func initNode() *kube.Objects {
+	return &kube.Objects{
+		ServerVersion: &version.Info{},
+		Nodes: &corev1.NodeList{
+			Items: []corev1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}},
+		},
+	}
+}
@@ -18,17 +18,39 @@ package basic
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/digitalocean/clusterlint/checks"
 	"github.com/digitalocean/clusterlint/kube"
 	corev1 "k8s.io/api/core/v1"
 )
 
+// defaultMinOOMRestarts is the number of restarts from which containers that
+// ran out of memory are reported.
+const defaultMinOOMRestarts = 3
+
+const oomKilled = "OOMKilled"
+
+// waitingReasons maps the reasons of waiting containers that need attention
+// to a description of the problem.
+var waitingReasons = map[string]string{
+	"CrashLoopBackOff":           "is crash looping",
+	"ImagePullBackOff":           "cannot pull its image",
+	"ErrImagePull":               "cannot pull its image",
+	"InvalidImageName":           "cannot pull its image",
+	"CreateContainerConfigError": "cannot be created",
+	"CreateContainerError":       "cannot be created",
+	"RunContainerError":          "cannot be started",
+}
+
 func init() {
-	checks.Register(&podStatusCheck{})
+	checks.Register(&podStatusCheck{minOOMRestarts: defaultMinOOMRestarts})
 }
 
-type podStatusCheck struct{}
+type podStatusCheck struct {
+	minOOMRestarts int32
+}
 
 // Name returns a unique name for this check.
 func (p *podStatusCheck) Name() string {
@@ -46,6 +68,29 @@ func (p *podStatusCheck) Description() string {
 	return "Check if there are unhealthy pods in the cluster"
 }
 
+// Resources returns the resources whose objects this check reads.
+func (p *podStatusCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Pods}
+}
+
+// Configure sets the thresholds used by the check. The only supported setting
+// is `min-oom-restarts`.
+func (p *podStatusCheck) Configure(settings map[string]string) error {
+	for key, value := range settings {
+		v, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || v < 0 {
+			return fmt.Errorf("invalid value %q for %s", value, key)
+		}
+		switch key {
+		case "min-oom-restarts":
+			p.minOOMRestarts = int32(v)
+		default:
+			return fmt.Errorf("unknown setting %q", key)
+		}
+	}
+	return nil
+}
+
 // Run runs this check on a set of Kubernetes objects. It can return warnings
 // (low-priority problems) and errors (high-priority problems) as well as an
 // error value indicating that the check failed to run.
@@ -53,17 +98,130 @@ func (p *podStatusCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error)
 	var diagnostics []checks.Diagnostic
 
 	for _, pod := range objects.Pods.Items {
-		if corev1.PodFailed == pod.Status.Phase || corev1.PodUnknown == pod.Status.Phase {
-			d := checks.Diagnostic{
+		pod := pod
+		diagnostic := func(message, details string) {
+			diagnostics = append(diagnostics, checks.Diagnostic{
 				Severity: checks.Warning,
-				Message:  fmt.Sprintf("Unhealthy pod. State: `%s`. Pod state should be `Running`, `Pending` or `Succeeded`.", pod.Status.Phase),
+				Message:  message,
 				Kind:     checks.Pod,
 				Object:   &pod.ObjectMeta,
 				Owners:   pod.ObjectMeta.GetOwnerReferences(),
+				Details:  details,
+			})
+		}
+
+		switch pod.Status.Phase {
+		case corev1.PodFailed, corev1.PodUnknown:
+			diagnostic(fmt.Sprintf("Unhealthy pod. State: `%s`. Pod state should be `Running`, `Pending` or `Succeeded`.", pod.Status.Phase), "")
+			continue
+		case corev1.PodSucceeded:
+			continue
+		case corev1.PodPending:
+			if condition := unschedulable(pod); condition != nil {
+				diagnostic("Pod is pending because it cannot be scheduled", fmt.Sprintf("Reason: %s. Message: %s", condition.Reason, condition.Message))
+				continue
+			}
+			for _, status := range pod.Status.InitContainerStatuses {
+				if reason, ok := initContainerStuck(pod, status); ok {
+					diagnostic(fmt.Sprintf("Init container `%s` does not complete", status.Name), containerDetails(reason, status))
+				}
+			}
+		}
+
+		containerProblems := func(kind string, status corev1.ContainerStatus) {
+			if waiting := status.State.Waiting; waiting != nil {
+				if problem, ok := waitingReasons[waiting.Reason]; ok {
+					diagnostic(fmt.Sprintf("%s `%s` %s", kind, status.Name, problem), containerDetails(waiting.Reason, status))
+					return
+				}
 			}
-			diagnostics = append(diagnostics, d)
+			if t := lastTermination(status); t != nil && t.Reason == oomKilled && status.RestartCount >= p.minOOMRestarts {
+				diagnostic(fmt.Sprintf("%s `%s` runs out of memory and has restarted %d times", kind, status.Name, status.RestartCount), containerDetails(oomKilled, status))
+			}
+		}
+
+		if pod.Status.Phase == corev1.PodRunning {
+			// Sidecars keep running next to the regular containers and
+			// are restarted the same way when they fail.
+			for _, status := range pod.Status.InitContainerStatuses {
+				if sidecar(pod, status.Name) {
+					containerProblems("Sidecar container", status)
+				}
+			}
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			containerProblems("Container", status)
 		}
 	}
 
 	return diagnostics, nil
 }
+
+// unschedulable returns the PodScheduled condition of a pod if the scheduler
+// could not find a node for it.
+func unschedulable(pod corev1.Pod) *corev1.PodCondition {
+	for i, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+			return &pod.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// initContainerStuck reports whether an init container has failed or cannot
+// start, which keeps the pod from ever starting its containers. Sidecar
+// containers are meant to keep running and are not considered.
+func initContainerStuck(pod corev1.Pod, status corev1.ContainerStatus) (string, bool) {
+	if sidecar(pod, status.Name) {
+		return "", false
+	}
+	if waiting := status.State.Waiting; waiting != nil {
+		if _, ok := waitingReasons[waiting.Reason]; ok {
+			return waiting.Reason, true
+		}
+	}
+	if t := status.State.Terminated; t != nil && t.ExitCode != 0 {
+		return t.Reason, true
+	}
+	return "", false
+}
+
+// sidecar reports whether the named init container of a pod is a restartable
+// sidecar container.
+func sidecar(pod corev1.Pod, name string) bool {
+	for _, container := range pod.Spec.InitContainers {
+		if container.Name == name {
+			return container.RestartPolicy != nil && *container.RestartPolicy == corev1.ContainerRestartPolicyAlways
+		}
+	}
+	return false
+}
+
+// lastTermination returns the state of the container's current or, if it is
+// running again, previous termination.
+func lastTermination(status corev1.ContainerStatus) *corev1.ContainerStateTerminated {
+	if status.State.Terminated != nil {
+		return status.State.Terminated
+	}
+	return status.LastTerminationState.Terminated
+}
+
+// containerDetails describes the reason a container is unhealthy, its restart
+// count and how it last terminated.
+func containerDetails(reason string, status corev1.ContainerStatus) string {
+	details := []string{
+		fmt.Sprintf("Reason: %s", reason),
+		fmt.Sprintf("Restart count: %d", status.RestartCount),
+	}
+	if waiting := status.State.Waiting; waiting != nil && waiting.Message != "" {
+		details = append(details, fmt.Sprintf("Message: %s", strings.TrimSpace(waiting.Message)))
+	}
+	if t := lastTermination(status); t != nil {
+		termination := fmt.Sprintf("Last termination: %s (exit code %d)", t.Reason, t.ExitCode)
+		if message := strings.TrimSpace(t.Message); message != "" {
+			termination += ": " + message
+		}
+		details = append(details, termination)
+	}
+	return strings.Join(details, ". ")
+}
@@ -33,7 +33,7 @@ func TestMeta(t *testing.T) {
 }
 
 func TestPodStateCheckRegistration(t *testing.T) {
-	podStatusCheck := &podStatusCheck{}
+	podStatusCheck := &podStatusCheck{minOOMRestarts: defaultMinOOMRestarts}
 	check, err := checks.Get("pod-state")
 	assert.NoError(t, err)
 	assert.Equal(t, check, podStatusCheck)
@@ -103,6 +103,178 @@ func TestPodStateError(t *testing.T) {
 	}
 }
 
+func TestPodStateContainers(t *testing.T) {
+	podStatusCheck := podStatusCheck{minOOMRestarts: defaultMinOOMRestarts}
+	sidecar := corev1.ContainerRestartPolicyAlways
+	tests := []struct {
+		name     string
+		pod      func(*corev1.Pod)
+		expected []string
+		details  []string
+	}{
+		{
+			name: "unschedulable pending pod",
+			pod: func(pod *corev1.Pod) {
+				pod.Status.Phase = corev1.PodPending
+				pod.Status.Conditions = []corev1.PodCondition{{
+					Type:    corev1.PodScheduled,
+					Status:  corev1.ConditionFalse,
+					Reason:  corev1.PodReasonUnschedulable,
+					Message: "0/3 nodes are available: 3 Insufficient memory.",
+				}}
+			},
+			expected: []string{"Pod is pending because it cannot be scheduled"},
+			details:  []string{"Reason: Unschedulable. Message: 0/3 nodes are available: 3 Insufficient memory."},
+		},
+		{
+			name: "crash looping container",
+			pod: func(pod *corev1.Pod) {
+				pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+					Name:                 "bar",
+					RestartCount:         7,
+					State:                waiting("CrashLoopBackOff", "back-off 5m0s restarting failed container"),
+					LastTerminationState: terminated("Error", 1, "panic: missing config\n"),
+				}}
+			},
+			expected: []string{"Container `bar` is crash looping"},
+			details:  []string{"Reason: CrashLoopBackOff. Restart count: 7. Message: back-off 5m0s restarting failed container. Last termination: Error (exit code 1): panic: missing config"},
+		},
+		{
+			name: "image pull back-off",
+			pod: func(pod *corev1.Pod) {
+				pod.Status.Phase = corev1.PodPending
+				pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+					Name:  "bar",
+					State: waiting("ImagePullBackOff", ""),
+				}}
+			},
+			expected: []string{"Container `bar` cannot pull its image"},
+			details:  []string{"Reason: ImagePullBackOff. Restart count: 0"},
+		},
+		{
+			name: "out of memory with many restarts",
+			pod: func(pod *corev1.Pod) {
+				pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+					Name:                 "bar",
+					RestartCount:         3,
+					State:                corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+					LastTerminationState: terminated("OOMKilled", 137, ""),
+				}}
+			},
+			expected: []string{"Container `bar` runs out of memory and has restarted 3 times"},
+			details:  []string{"Reason: OOMKilled. Restart count: 3. Last termination: OOMKilled (exit code 137)"},
+		},
+		{
+			name: "out of memory with few restarts",
+			pod: func(pod *corev1.Pod) {
+				pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+					Name:                 "bar",
+					RestartCount:         1,
+					State:                corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+					LastTerminationState: terminated("OOMKilled", 137, ""),
+				}}
+			},
+			expected: nil,
+		},
+		{
+			name: "failing init container",
+			pod: func(pod *corev1.Pod) {
+				pod.Status.Phase = corev1.PodPending
+				pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+					Name:         "migrate",
+					RestartCount: 2,
+					State:        terminated("Error", 2, "connection refused"),
+				}}
+			},
+			expected: []string{"Init container `migrate` does not complete"},
+			details:  []string{"Reason: Error. Restart count: 2. Last termination: Error (exit code 2): connection refused"},
+		},
+		{
+			name: "running init container",
+			pod: func(pod *corev1.Pod) {
+				pod.Status.Phase = corev1.PodPending
+				pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+					Name:  "migrate",
+					State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				}}
+			},
+			expected: nil,
+		},
+		{
+			name: "crash looping sidecar",
+			pod: func(pod *corev1.Pod) {
+				pod.Status.Phase = corev1.PodPending
+				pod.Spec.InitContainers = []corev1.Container{{Name: "proxy", RestartPolicy: &sidecar}}
+				pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+					Name:  "proxy",
+					State: waiting("CrashLoopBackOff", ""),
+				}}
+			},
+			expected: nil,
+		},
+		{
+			name: "crash looping sidecar of running pod",
+			pod: func(pod *corev1.Pod) {
+				pod.Spec.InitContainers = []corev1.Container{{Name: "proxy", RestartPolicy: &sidecar}}
+				pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+					Name:         "proxy",
+					RestartCount: 4,
+					State:        waiting("CrashLoopBackOff", ""),
+				}}
+			},
+			expected: []string{"Sidecar container `proxy` is crash looping"},
+			details:  []string{"Reason: CrashLoopBackOff. Restart count: 4"},
+		},
+		{
+			name: "completed init container of running pod",
+			pod: func(pod *corev1.Pod) {
+				pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+					Name:                 "migrate",
+					RestartCount:         5,
+					State:                terminated("Completed", 0, ""),
+					LastTerminationState: terminated("OOMKilled", 137, ""),
+				}}
+			},
+			expected: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := status(corev1.PodRunning)
+			test.pod(&objs.Pods.Items[0])
+
+			d, err := podStatusCheck.Run(objs)
+			assert.NoError(t, err)
+			var messages, details []string
+			for _, diagnostic := range d {
+				assert.Equal(t, checks.Warning, diagnostic.Severity)
+				assert.Equal(t, checks.Pod, diagnostic.Kind)
+				messages = append(messages, diagnostic.Message)
+				details = append(details, diagnostic.Details)
+			}
+			assert.Equal(t, test.expected, messages)
+			assert.Equal(t, test.details, details)
+		})
+	}
+}
+
+func TestPodStateConfigure(t *testing.T) {
+	check := &podStatusCheck{minOOMRestarts: defaultMinOOMRestarts}
+	assert.NoError(t, check.Configure(map[string]string{"min-oom-restarts": "10"}))
+	assert.Equal(t, int32(10), check.minOOMRestarts)
+	assert.Error(t, check.Configure(map[string]string{"min-oom-restarts": "many"}))
+	assert.Error(t, check.Configure(map[string]string{"max-restarts": "1"}))
+}
+
+func waiting(reason, message string) corev1.ContainerState {
+	return corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason, Message: message}}
+}
+
+func terminated(reason string, exitCode int32, message string) corev1.ContainerState {
+	return corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: reason, ExitCode: exitCode, Message: message}}
+}
+
 func status(status corev1.PodPhase) *kube.Objects {
 	objs := initPod()
 	objs.Pods.Items[0].Status = corev1.PodStatus{
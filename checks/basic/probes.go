@@ -0,0 +1,383 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package basic
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// Defaults the API server applies to probes that don't set these fields.
+	defaultProbePeriodSeconds    = 10
+	defaultProbeFailureThreshold = 3
+
+	// Default thresholds for the liveness-probe-startup check.
+	defaultMinStartupSeconds           = 10
+	defaultMinLivenessFailureThreshold = 2
+)
+
+func init() {
+	checks.Register(&readinessProbeCheck{})
+	checks.Register(&identicalProbesCheck{})
+	checks.Register(&livenessProbeStartupCheck{
+		minStartupSeconds:   defaultMinStartupSeconds,
+		minFailureThreshold: defaultMinLivenessFailureThreshold,
+	})
+	checks.Register(&probePortCheck{})
+}
+
+// probeFinding describes a problem with the probes of a container.
+type probeFinding struct {
+	severity checks.Severity
+	message  string
+	details  string
+}
+
+// runPerWorkload calls f for each container of each workload and turns the
+// findings into diagnostics. Pods belonging to the same workload share a pod
+// template, so only the first pod of each workload is inspected.
+func runPerWorkload(objects *kube.Objects, f func(corev1.Pod, corev1.Container) []probeFinding) []checks.Diagnostic {
+	var diagnostics []checks.Diagnostic
+	forEachWorkload(objects, func(pod corev1.Pod, workload checks.Workload) {
+		for _, container := range pod.Spec.Containers {
+			for _, finding := range f(pod, container) {
+				d := checks.Diagnostic{
+					Severity: finding.severity,
+					Message:  finding.message,
+					Kind:     workload.Kind,
+					Object:   workload.Object,
+					Owners:   pod.ObjectMeta.GetOwnerReferences(),
+					Details:  finding.details,
+				}
+				diagnostics = append(diagnostics, d)
+			}
+		}
+	})
+	return diagnostics
+}
+
+// forEachWorkload calls f with the first pod of each workload.
+func forEachWorkload(objects *kube.Objects, f func(corev1.Pod, checks.Workload)) {
+	seen := make(map[string]struct{})
+	for _, pod := range objects.Pods.Items {
+		pod := pod
+		workload := checks.WorkloadForPod(&pod)
+		if _, ok := seen[workload.Key()]; ok {
+			continue
+		}
+		seen[workload.Key()] = struct{}{}
+		f(pod, workload)
+	}
+}
+
+type readinessProbeCheck struct{}
+
+// Name returns a unique name for this check.
+func (r *readinessProbeCheck) Name() string {
+	return "readiness-probe"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (r *readinessProbeCheck) Groups() []string {
+	return []string{"workload-health"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (r *readinessProbeCheck) Description() string {
+	return "Checks if there are containers exposing ports without a readiness probe"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (r *readinessProbeCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Pods}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (r *readinessProbeCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	return runPerWorkload(objects, func(_ corev1.Pod, container corev1.Container) []probeFinding {
+		if len(container.Ports) == 0 || container.ReadinessProbe != nil {
+			return nil
+		}
+		return []probeFinding{{
+			severity: checks.Warning,
+			message:  fmt.Sprintf("Container `%s` exposes ports but has no readiness probe. Traffic may be sent to it before it is ready.", container.Name),
+		}}
+	}), nil
+}
+
+type identicalProbesCheck struct{}
+
+// Name returns a unique name for this check.
+func (i *identicalProbesCheck) Name() string {
+	return "liveness-readiness-probe-identical"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (i *identicalProbesCheck) Groups() []string {
+	return []string{"workload-health"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (i *identicalProbesCheck) Description() string {
+	return "Checks if there are containers whose liveness probe is identical to their readiness probe"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (i *identicalProbesCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Pods}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (i *identicalProbesCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	return runPerWorkload(objects, func(_ corev1.Pod, container corev1.Container) []probeFinding {
+		if container.LivenessProbe == nil || container.ReadinessProbe == nil {
+			return nil
+		}
+		if !equality.Semantic.DeepEqual(container.LivenessProbe, container.ReadinessProbe) {
+			return nil
+		}
+		return []probeFinding{{
+			severity: checks.Warning,
+			message:  fmt.Sprintf("Liveness probe of container `%s` is identical to its readiness probe. A container that is temporarily not ready will be restarted.", container.Name),
+		}}
+	}), nil
+}
+
+type livenessProbeStartupCheck struct {
+	minStartupSeconds   int32
+	minFailureThreshold int32
+}
+
+// Name returns a unique name for this check.
+func (l *livenessProbeStartupCheck) Name() string {
+	return "liveness-probe-startup"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (l *livenessProbeStartupCheck) Groups() []string {
+	return []string{"workload-health"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (l *livenessProbeStartupCheck) Description() string {
+	return "Checks if there are liveness probes that may restart containers before they finish starting up"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (l *livenessProbeStartupCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Pods}
+}
+
+// Configure sets the thresholds used by the check. Supported settings are
+// `min-startup-seconds` and `min-failure-threshold`.
+func (l *livenessProbeStartupCheck) Configure(settings map[string]string) error {
+	for key, value := range settings {
+		v, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || v < 0 {
+			return fmt.Errorf("invalid value %q for %s", value, key)
+		}
+		switch key {
+		case "min-startup-seconds":
+			l.minStartupSeconds = int32(v)
+		case "min-failure-threshold":
+			l.minFailureThreshold = int32(v)
+		default:
+			return fmt.Errorf("unknown setting %q", key)
+		}
+	}
+	return nil
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (l *livenessProbeStartupCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	return runPerWorkload(objects, func(pod corev1.Pod, container corev1.Container) []probeFinding {
+		probe := container.LivenessProbe
+		// A startup probe holds off the liveness probe until the container
+		// has started.
+		if probe == nil || container.StartupProbe != nil {
+			return nil
+		}
+		period := probe.PeriodSeconds
+		if period == 0 {
+			period = defaultProbePeriodSeconds
+		}
+		failureThreshold := probe.FailureThreshold
+		if failureThreshold == 0 {
+			failureThreshold = defaultProbeFailureThreshold
+		}
+		window := probe.InitialDelaySeconds + failureThreshold*period
+		details := fmt.Sprintf("initialDelaySeconds: %d, periodSeconds: %d, failureThreshold: %d", probe.InitialDelaySeconds, period, failureThreshold)
+
+		var findings []probeFinding
+		if failureThreshold < l.minFailureThreshold {
+			findings = append(findings, probeFinding{
+				severity: checks.Warning,
+				message:  fmt.Sprintf("Liveness probe of container `%s` restarts it after %d failure(s). Use a failure threshold of at least %d.", container.Name, failureThreshold, l.minFailureThreshold),
+				details:  details,
+			})
+		}
+		if window < l.minStartupSeconds {
+			findings = append(findings, probeFinding{
+				severity: checks.Warning,
+				message:  fmt.Sprintf("Liveness probe of container `%s` allows only %ds to start up. Allow at least %ds or add a startup probe.", container.Name, window, l.minStartupSeconds),
+				details:  details,
+			})
+		} else if startup, ok := observedStartup(pod, container.Name); ok && startup > time.Duration(window)*time.Second {
+			findings = append(findings, probeFinding{
+				severity: checks.Warning,
+				message:  fmt.Sprintf("Liveness probe of container `%s` allows only %ds to start up, but it took %s to become ready. Add a startup probe.", container.Name, window, startup),
+				details:  details,
+			})
+		}
+		return findings
+	}), nil
+}
+
+// observedStartup returns the time it took a running container to become
+// ready, as recorded in the pod status.
+func observedStartup(pod corev1.Pod, name string) (time.Duration, bool) {
+	var ready *corev1.PodCondition
+	for i, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.ContainersReady && condition.Status == corev1.ConditionTrue {
+			ready = &pod.Status.Conditions[i]
+		}
+	}
+	if ready == nil {
+		return 0, false
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != name || status.State.Running == nil {
+			continue
+		}
+		startup := ready.LastTransitionTime.Sub(status.State.Running.StartedAt.Time)
+		return startup, startup > 0
+	}
+	return 0, false
+}
+
+type probePortCheck struct{}
+
+// Name returns a unique name for this check.
+func (p *probePortCheck) Name() string {
+	return "probe-port"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (p *probePortCheck) Groups() []string {
+	return []string{"workload-health"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (p *probePortCheck) Description() string {
+	return "Checks if there are probes that point at ports not declared on the container"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (p *probePortCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Pods}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (p *probePortCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	return runPerWorkload(objects, func(_ corev1.Pod, container corev1.Container) []probeFinding {
+		var findings []probeFinding
+		probes := []struct {
+			kind  string
+			probe *corev1.Probe
+		}{
+			{"liveness", container.LivenessProbe},
+			{"readiness", container.ReadinessProbe},
+			{"startup", container.StartupProbe},
+		}
+		for _, pr := range probes {
+			port, ok := probePort(pr.probe)
+			if !ok {
+				continue
+			}
+			if port.Type == intstr.String {
+				if !hasNamedPort(container, port.StrVal) {
+					findings = append(findings, probeFinding{
+						severity: checks.Error,
+						message:  fmt.Sprintf("The %s probe of container `%s` refers to port `%s`, which is not declared on the container. The probe will always fail.", pr.kind, container.Name, port.StrVal),
+					})
+				}
+				continue
+			}
+			// Undeclared numeric ports are valid, so only flag them when the
+			// container declares its ports.
+			if len(container.Ports) > 0 && !hasPortNumber(container, port.IntVal) {
+				findings = append(findings, probeFinding{
+					severity: checks.Warning,
+					message:  fmt.Sprintf("The %s probe of container `%s` uses port %d, which is not declared on the container.", pr.kind, container.Name, port.IntVal),
+				})
+			}
+		}
+		return findings
+	}), nil
+}
+
+func probePort(probe *corev1.Probe) (intstr.IntOrString, bool) {
+	switch {
+	case probe == nil:
+		return intstr.IntOrString{}, false
+	case probe.HTTPGet != nil:
+		return probe.HTTPGet.Port, true
+	case probe.TCPSocket != nil:
+		return probe.TCPSocket.Port, true
+	case probe.GRPC != nil:
+		return intstr.FromInt32(probe.GRPC.Port), true
+	}
+	return intstr.IntOrString{}, false
+}
+
+func hasNamedPort(container corev1.Container, name string) bool {
+	for _, port := range container.Ports {
+		if port.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPortNumber(container corev1.Container, number int32) bool {
+	for _, port := range container.Ports {
+		if port.ContainerPort == number {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,331 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package basic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestProbeChecksMeta(t *testing.T) {
+	for name, check := range map[string]checks.Check{
+		"readiness-probe":                    &readinessProbeCheck{},
+		"liveness-readiness-probe-identical": &identicalProbesCheck{},
+		"liveness-probe-startup":             &livenessProbeStartupCheck{},
+		"probe-port":                         &probePortCheck{},
+	} {
+		assert.Equal(t, name, check.Name())
+		assert.Equal(t, []string{"workload-health"}, check.Groups())
+		assert.NotEmpty(t, check.Description())
+
+		registered, err := checks.Get(name)
+		assert.NoError(t, err)
+		assert.IsType(t, check, registered)
+	}
+}
+
+func TestReadinessProbeWarning(t *testing.T) {
+	readinessProbeCheck := readinessProbeCheck{}
+
+	tests := []struct {
+		name     string
+		objs     *kube.Objects
+		expected []checks.Diagnostic
+	}{
+		{
+			name:     "no ports",
+			objs:     probes(nil, nil, nil),
+			expected: nil,
+		},
+		{
+			name: "ports with readiness probe",
+			objs: probes([]corev1.ContainerPort{{ContainerPort: 8080}}, nil, httpProbe(intstr.FromInt32(8080))),
+		},
+		{
+			name: "ports without readiness probe",
+			objs: probes([]corev1.ContainerPort{{ContainerPort: 8080}}, nil, nil),
+			expected: []checks.Diagnostic{
+				{
+					Severity: checks.Warning,
+					Message:  "Container `bar` exposes ports but has no readiness probe. Traffic may be sent to it before it is ready.",
+					Kind:     checks.Deployment,
+					Object:   workloadMeta(),
+					Owners:   workloadOwners(),
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d, err := readinessProbeCheck.Run(test.objs)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, test.expected, d)
+		})
+	}
+}
+
+func TestIdenticalProbesWarning(t *testing.T) {
+	identicalProbesCheck := identicalProbesCheck{}
+
+	tests := []struct {
+		name     string
+		objs     *kube.Objects
+		expected []checks.Diagnostic
+	}{
+		{
+			name:     "no probes",
+			objs:     probes(nil, nil, nil),
+			expected: nil,
+		},
+		{
+			name:     "different probes",
+			objs:     probes(nil, httpProbe(intstr.FromInt32(8080)), httpProbe(intstr.FromInt32(8081))),
+			expected: nil,
+		},
+		{
+			name: "identical probes",
+			objs: probes(nil, httpProbe(intstr.FromInt32(8080)), httpProbe(intstr.FromInt32(8080))),
+			expected: []checks.Diagnostic{
+				{
+					Severity: checks.Warning,
+					Message:  "Liveness probe of container `bar` is identical to its readiness probe. A container that is temporarily not ready will be restarted.",
+					Kind:     checks.Deployment,
+					Object:   workloadMeta(),
+					Owners:   workloadOwners(),
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d, err := identicalProbesCheck.Run(test.objs)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, test.expected, d)
+		})
+	}
+}
+
+func TestLivenessProbeStartupWarning(t *testing.T) {
+	aggressive := httpProbe(intstr.FromInt32(8080))
+	aggressive.PeriodSeconds = 2
+	aggressive.FailureThreshold = 1
+
+	slowStart := probes(nil, httpProbe(intstr.FromInt32(8080)), nil)
+	started := metav1.NewTime(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+	slowStart.Pods.Items[0].Status = corev1.PodStatus{
+		Conditions: []corev1.PodCondition{{
+			Type:               corev1.ContainersReady,
+			Status:             corev1.ConditionTrue,
+			LastTransitionTime: metav1.NewTime(started.Add(time.Minute)),
+		}},
+		ContainerStatuses: []corev1.ContainerStatus{{
+			Name:  "bar",
+			State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: started}},
+		}},
+	}
+
+	withStartupProbe := probes(nil, aggressive, nil)
+	withStartupProbe.Pods.Items[0].Spec.Containers[0].StartupProbe = httpProbe(intstr.FromInt32(8080))
+
+	tests := []struct {
+		name     string
+		objs     *kube.Objects
+		settings map[string]string
+		expected []checks.Diagnostic
+	}{
+		{
+			name:     "default probe settings",
+			objs:     probes(nil, httpProbe(intstr.FromInt32(8080)), nil),
+			expected: nil,
+		},
+		{
+			name: "aggressive probe settings",
+			objs: probes(nil, aggressive, nil),
+			expected: []checks.Diagnostic{
+				{
+					Severity: checks.Warning,
+					Message:  "Liveness probe of container `bar` restarts it after 1 failure(s). Use a failure threshold of at least 2.",
+					Kind:     checks.Deployment,
+					Object:   workloadMeta(),
+					Owners:   workloadOwners(),
+					Details:  "initialDelaySeconds: 0, periodSeconds: 2, failureThreshold: 1",
+				},
+				{
+					Severity: checks.Warning,
+					Message:  "Liveness probe of container `bar` allows only 2s to start up. Allow at least 10s or add a startup probe.",
+					Kind:     checks.Deployment,
+					Object:   workloadMeta(),
+					Owners:   workloadOwners(),
+					Details:  "initialDelaySeconds: 0, periodSeconds: 2, failureThreshold: 1",
+				},
+			},
+		},
+		{
+			name:     "aggressive probe settings with startup probe",
+			objs:     withStartupProbe,
+			expected: nil,
+		},
+		{
+			name:     "configured thresholds",
+			objs:     probes(nil, aggressive, nil),
+			settings: map[string]string{"min-startup-seconds": "2", "min-failure-threshold": "1"},
+			expected: nil,
+		},
+		{
+			name: "observed startup longer than probe allows",
+			objs: slowStart,
+			expected: []checks.Diagnostic{
+				{
+					Severity: checks.Warning,
+					Message:  "Liveness probe of container `bar` allows only 30s to start up, but it took 1m0s to become ready. Add a startup probe.",
+					Kind:     checks.Deployment,
+					Object:   workloadMeta(),
+					Owners:   workloadOwners(),
+					Details:  "initialDelaySeconds: 0, periodSeconds: 10, failureThreshold: 3",
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			check := livenessProbeStartupCheck{
+				minStartupSeconds:   defaultMinStartupSeconds,
+				minFailureThreshold: defaultMinLivenessFailureThreshold,
+			}
+			assert.NoError(t, check.Configure(test.settings))
+			d, err := check.Run(test.objs)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, test.expected, d)
+		})
+	}
+}
+
+func TestLivenessProbeStartupConfigure(t *testing.T) {
+	check := livenessProbeStartupCheck{}
+	assert.Error(t, check.Configure(map[string]string{"min-startup-seconds": "soon"}))
+	assert.Error(t, check.Configure(map[string]string{"min-startup-seconds": "-1"}))
+	assert.Error(t, check.Configure(map[string]string{"unknown": "1"}))
+}
+
+func TestProbePortWarning(t *testing.T) {
+	probePortCheck := probePortCheck{}
+	ports := []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}}
+
+	tests := []struct {
+		name     string
+		objs     *kube.Objects
+		expected []checks.Diagnostic
+	}{
+		{
+			name:     "declared named port",
+			objs:     probes(ports, httpProbe(intstr.FromString("http")), nil),
+			expected: nil,
+		},
+		{
+			name:     "declared port number",
+			objs:     probes(ports, nil, httpProbe(intstr.FromInt32(8080))),
+			expected: nil,
+		},
+		{
+			name:     "undeclared port number without declared ports",
+			objs:     probes(nil, nil, httpProbe(intstr.FromInt32(8080))),
+			expected: nil,
+		},
+		{
+			name: "undeclared named port",
+			objs: probes(ports, httpProbe(intstr.FromString("metrics")), nil),
+			expected: []checks.Diagnostic{
+				{
+					Severity: checks.Error,
+					Message:  "The liveness probe of container `bar` refers to port `metrics`, which is not declared on the container. The probe will always fail.",
+					Kind:     checks.Deployment,
+					Object:   workloadMeta(),
+					Owners:   workloadOwners(),
+				},
+			},
+		},
+		{
+			name: "undeclared port number",
+			objs: probes(ports, nil, httpProbe(intstr.FromInt32(9090))),
+			expected: []checks.Diagnostic{
+				{
+					Severity: checks.Warning,
+					Message:  "The readiness probe of container `bar` uses port 9090, which is not declared on the container.",
+					Kind:     checks.Deployment,
+					Object:   workloadMeta(),
+					Owners:   workloadOwners(),
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d, err := probePortCheck.Run(test.objs)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, test.expected, d)
+		})
+	}
+}
+
+// probes returns two pods of the same deployment with the given container
+// ports and probes.
+func probes(ports []corev1.ContainerPort, liveness, readiness *corev1.Probe) *kube.Objects {
+	objs := initMultiplePods()
+	for i := range objs.Pods.Items {
+		objs.Pods.Items[i].Labels = map[string]string{"pod-template-hash": "6d4cf56db6"}
+		objs.Pods.Items[i].OwnerReferences = workloadOwners()
+		objs.Pods.Items[i].Spec = corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:           "bar",
+					Image:          "alpine",
+					Ports:          ports,
+					LivenessProbe:  liveness,
+					ReadinessProbe: readiness,
+				}},
+		}
+	}
+	return objs
+}
+
+func httpProbe(port intstr.IntOrString) *corev1.Probe {
+	return &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: port},
+		},
+	}
+}
+
+func workloadMeta() *metav1.ObjectMeta {
+	return &metav1.ObjectMeta{Name: "web", Namespace: "k8s"}
+}
+
+func workloadOwners() []metav1.OwnerReference {
+	controller := true
+	return []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-6d4cf56db6", Controller: &controller}}
+}
@@ -0,0 +1,275 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package basic
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// quotaUsageThreshold is the fraction of a hard quota limit above which usage
+// is considered too high to leave room for surge pods.
+const quotaUsageThreshold = 0.9
+
+func init() {
+	checks.Register(&quotaUsageCheck{})
+	checks.Register(&quotaRequirementsCheck{})
+}
+
+type quotaUsageCheck struct{}
+
+// Name returns a unique name for this check.
+func (q *quotaUsageCheck) Name() string {
+	return "resource-quota-usage"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (q *quotaUsageCheck) Groups() []string {
+	return []string{"basic", "doks"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (q *quotaUsageCheck) Description() string {
+	return "Checks if there are resource quotas whose usage is close to the hard limit"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (q *quotaUsageCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.ResourceQuotas}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (q *quotaUsageCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+
+	for _, quota := range objects.ResourceQuotas.Items {
+		quota := quota
+		hard := quota.Status.Hard
+		if len(hard) == 0 {
+			hard = quota.Spec.Hard
+		}
+		var exhausted []string
+		for _, name := range sortedResourceNames(hard) {
+			limit := hard[name]
+			used, ok := quota.Status.Used[name]
+			if !ok || limit.IsZero() {
+				continue
+			}
+			ratio := used.AsApproximateFloat64() / limit.AsApproximateFloat64()
+			if ratio >= quotaUsageThreshold {
+				exhausted = append(exhausted, fmt.Sprintf("%s: %s/%s (%.0f%%)", name, used.String(), limit.String(), ratio*100))
+			}
+		}
+		if len(exhausted) == 0 {
+			continue
+		}
+		d := checks.Diagnostic{
+			Severity: checks.Warning,
+			Message:  "Resource quota usage is close to the hard limit. New pods created during rollouts or node replacement may be rejected.",
+			Kind:     checks.ResourceQuota,
+			Object:   &quota.ObjectMeta,
+			Owners:   quota.ObjectMeta.GetOwnerReferences(),
+			Details:  strings.Join(exhausted, ", "),
+		}
+		diagnostics = append(diagnostics, d)
+	}
+
+	return diagnostics, nil
+}
+
+type quotaRequirementsCheck struct{}
+
+// Name returns a unique name for this check.
+func (q *quotaRequirementsCheck) Name() string {
+	return "resource-quota-requirements"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (q *quotaRequirementsCheck) Groups() []string {
+	return []string{"basic", "doks"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (q *quotaRequirementsCheck) Description() string {
+	return "Checks if there are pods missing resource requests or limits required by a resource quota"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (q *quotaRequirementsCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.LimitRanges, kube.Pods, kube.ResourceQuotas}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (q *quotaRequirementsCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	quotas := make(map[string][]corev1.ResourceQuota)
+	for _, quota := range objects.ResourceQuotas.Items {
+		quotas[quota.Namespace] = append(quotas[quota.Namespace], quota)
+	}
+	limitRanges := limitRangesByNamespace(objects)
+
+	for _, pod := range objects.Pods.Items {
+		pod := pod
+		if len(quotas[pod.Namespace]) == 0 {
+			continue
+		}
+		defaultRequests, defaultLimits := limitRangeDefaults(limitRanges[pod.Namespace])
+		var containers []corev1.Container
+		containers = append(containers, pod.Spec.Containers...)
+		containers = append(containers, pod.Spec.InitContainers...)
+		for _, quota := range quotas[pod.Namespace] {
+			if !quotaAppliesToPod(quota, pod) {
+				continue
+			}
+			requests, limits := quotaComputeResources(quota)
+			for _, container := range containers {
+				var missing []string
+				for _, name := range requests {
+					if !hasResource(name, defaultRequests, container.Resources.Requests, container.Resources.Limits) {
+						missing = append(missing, fmt.Sprintf("requests.%s", name))
+					}
+				}
+				for _, name := range limits {
+					if !hasResource(name, defaultLimits, container.Resources.Limits) {
+						missing = append(missing, fmt.Sprintf("limits.%s", name))
+					}
+				}
+				if len(missing) == 0 {
+					continue
+				}
+				d := checks.Diagnostic{
+					Severity: checks.Warning,
+					Message:  fmt.Sprintf("Container `%s` does not set resources required by resource quota `%s`. The pod will be rejected if it is recreated.", container.Name, quota.Name),
+					Kind:     checks.Pod,
+					Object:   &pod.ObjectMeta,
+					Owners:   pod.ObjectMeta.GetOwnerReferences(),
+					Details:  fmt.Sprintf("Missing: %s", strings.Join(missing, ", ")),
+				}
+				diagnostics = append(diagnostics, d)
+			}
+		}
+	}
+
+	return diagnostics, nil
+}
+
+// quotaComputeResources returns the compute resources for which a quota
+// requires every container to specify a request or a limit.
+func quotaComputeResources(quota corev1.ResourceQuota) (requests, limits []corev1.ResourceName) {
+	compute := []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourceEphemeralStorage}
+	for _, name := range compute {
+		if _, ok := quota.Spec.Hard[name]; ok {
+			requests = append(requests, name)
+		} else if _, ok := quota.Spec.Hard[corev1.ResourceName("requests."+name)]; ok {
+			requests = append(requests, name)
+		}
+		if _, ok := quota.Spec.Hard[corev1.ResourceName("limits."+name)]; ok {
+			limits = append(limits, name)
+		}
+	}
+	return requests, limits
+}
+
+// quotaAppliesToPod reports whether the scopes of a quota select the pod.
+func quotaAppliesToPod(quota corev1.ResourceQuota, pod corev1.Pod) bool {
+	for _, scope := range quota.Spec.Scopes {
+		if !scopeMatchesPod(scope, corev1.ScopeSelectorOpExists, nil, pod) {
+			return false
+		}
+	}
+	if quota.Spec.ScopeSelector != nil {
+		for _, expr := range quota.Spec.ScopeSelector.MatchExpressions {
+			if !scopeMatchesPod(expr.ScopeName, expr.Operator, expr.Values, pod) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func scopeMatchesPod(scope corev1.ResourceQuotaScope, op corev1.ScopeSelectorOperator, values []string, pod corev1.Pod) bool {
+	var matches bool
+	switch scope {
+	case corev1.ResourceQuotaScopeTerminating:
+		matches = pod.Spec.ActiveDeadlineSeconds != nil
+	case corev1.ResourceQuotaScopeNotTerminating:
+		matches = pod.Spec.ActiveDeadlineSeconds == nil
+	case corev1.ResourceQuotaScopeBestEffort:
+		matches = isBestEffort(pod)
+	case corev1.ResourceQuotaScopeNotBestEffort:
+		matches = !isBestEffort(pod)
+	case corev1.ResourceQuotaScopePriorityClass:
+		switch op {
+		case corev1.ScopeSelectorOpIn:
+			return contains(values, pod.Spec.PriorityClassName)
+		case corev1.ScopeSelectorOpNotIn:
+			return !contains(values, pod.Spec.PriorityClassName)
+		case corev1.ScopeSelectorOpDoesNotExist:
+			return pod.Spec.PriorityClassName == ""
+		default:
+			return pod.Spec.PriorityClassName != ""
+		}
+	default:
+		// Scopes that can't be evaluated from the pod spec are assumed to
+		// match.
+		return true
+	}
+	if op == corev1.ScopeSelectorOpDoesNotExist {
+		return !matches
+	}
+	return matches
+}
+
+func isBestEffort(pod corev1.Pod) bool {
+	var containers []corev1.Container
+	containers = append(containers, pod.Spec.Containers...)
+	containers = append(containers, pod.Spec.InitContainers...)
+	for _, container := range containers {
+		if len(container.Resources.Requests) > 0 || len(container.Resources.Limits) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func hasResource(name corev1.ResourceName, lists ...corev1.ResourceList) bool {
+	for _, list := range lists {
+		if _, ok := list[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, name string) bool {
+	for _, l := range list {
+		if l == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package basic
+
+import (
+	"testing"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestQuotaUsageCheckMeta(t *testing.T) {
+	quotaUsageCheck := quotaUsageCheck{}
+	assert.Equal(t, "resource-quota-usage", quotaUsageCheck.Name())
+	assert.Equal(t, []string{"basic", "doks"}, quotaUsageCheck.Groups())
+	assert.NotEmpty(t, quotaUsageCheck.Description())
+}
+
+func TestQuotaUsageCheckRegistration(t *testing.T) {
+	quotaUsageCheck := &quotaUsageCheck{}
+	check, err := checks.Get("resource-quota-usage")
+	assert.NoError(t, err)
+	assert.Equal(t, check, quotaUsageCheck)
+}
+
+func TestQuotaUsageWarning(t *testing.T) {
+	quotaUsageCheck := quotaUsageCheck{}
+
+	tests := []struct {
+		name     string
+		hard     corev1.ResourceList
+		used     corev1.ResourceList
+		expected []checks.Diagnostic
+	}{
+		{
+			name:     "no usage",
+			hard:     corev1.ResourceList{corev1.ResourcePods: resource.MustParse("10")},
+			used:     nil,
+			expected: nil,
+		},
+		{
+			name:     "usage below threshold",
+			hard:     corev1.ResourceList{corev1.ResourcePods: resource.MustParse("10")},
+			used:     corev1.ResourceList{corev1.ResourcePods: resource.MustParse("5")},
+			expected: nil,
+		},
+		{
+			name: "usage above threshold",
+			hard: corev1.ResourceList{
+				corev1.ResourcePods:           resource.MustParse("10"),
+				corev1.ResourceRequestsMemory: resource.MustParse("1Gi"),
+			},
+			used: corev1.ResourceList{
+				corev1.ResourcePods:           resource.MustParse("9"),
+				corev1.ResourceRequestsMemory: resource.MustParse("512Mi"),
+			},
+			expected: []checks.Diagnostic{
+				{
+					Severity: checks.Warning,
+					Message:  "Resource quota usage is close to the hard limit. New pods created during rollouts or node replacement may be rejected.",
+					Kind:     checks.ResourceQuota,
+					Object:   &metav1.ObjectMeta{Name: "quota", Namespace: "k8s"},
+					Details:  "pods: 9/10 (90%)",
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := &kube.Objects{
+				ResourceQuotas: &corev1.ResourceQuotaList{
+					Items: []corev1.ResourceQuota{
+						{
+							ObjectMeta: metav1.ObjectMeta{Name: "quota", Namespace: "k8s"},
+							Spec:       corev1.ResourceQuotaSpec{Hard: test.hard},
+							Status:     corev1.ResourceQuotaStatus{Hard: test.hard, Used: test.used},
+						},
+					},
+				},
+			}
+			d, err := quotaUsageCheck.Run(objs)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, test.expected, d)
+		})
+	}
+}
+
+func TestQuotaRequirementsCheckMeta(t *testing.T) {
+	quotaRequirementsCheck := quotaRequirementsCheck{}
+	assert.Equal(t, "resource-quota-requirements", quotaRequirementsCheck.Name())
+	assert.Equal(t, []string{"basic", "doks"}, quotaRequirementsCheck.Groups())
+	assert.NotEmpty(t, quotaRequirementsCheck.Description())
+}
+
+func TestQuotaRequirementsCheckRegistration(t *testing.T) {
+	quotaRequirementsCheck := &quotaRequirementsCheck{}
+	check, err := checks.Get("resource-quota-requirements")
+	assert.NoError(t, err)
+	assert.Equal(t, check, quotaRequirementsCheck)
+}
+
+func TestQuotaRequirementsWarning(t *testing.T) {
+	const message = "Container `bar` does not set resources required by resource quota `quota`. The pod will be rejected if it is recreated."
+
+	quotaRequirementsCheck := quotaRequirementsCheck{}
+
+	computeQuota := corev1.ResourceQuotaSpec{
+		Hard: corev1.ResourceList{
+			corev1.ResourceRequestsCPU:  resource.MustParse("4"),
+			corev1.ResourceLimitsMemory: resource.MustParse("4Gi"),
+		},
+	}
+
+	tests := []struct {
+		name     string
+		objs     *kube.Objects
+		expected []checks.Diagnostic
+	}{
+		{
+			name:     "no quotas",
+			objs:     withQuota(container("alpine"), nil),
+			expected: nil,
+		},
+		{
+			name: "quota without compute resources",
+			objs: withQuota(container("alpine"), &corev1.ResourceQuotaSpec{
+				Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("10")},
+			}),
+			expected: nil,
+		},
+		{
+			name: "container missing requests and limits",
+			objs: withQuota(container("alpine"), &computeQuota),
+			expected: []checks.Diagnostic{
+				{
+					Severity: checks.Warning,
+					Message:  message,
+					Kind:     checks.Pod,
+					Object:   GetObjectMeta(),
+					Owners:   GetOwners(),
+					Details:  "Missing: requests.cpu, limits.memory",
+				},
+			},
+		},
+		{
+			name: "request implied by limit",
+			objs: withQuota(containerResources(nil, corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("1"),
+				corev1.ResourceMemory: resource.MustParse("1Gi"),
+			}), &computeQuota),
+			expected: nil,
+		},
+		{
+			name: "limit range supplies defaults",
+			objs: withLimitRange(withQuota(container("alpine"), &computeQuota), corev1.LimitRangeItem{
+				Type: corev1.LimitTypeContainer,
+				Default: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("1"),
+					corev1.ResourceMemory: resource.MustParse("1Gi"),
+				},
+			}),
+			expected: nil,
+		},
+		{
+			name: "quota scoped to terminating pods",
+			objs: withQuota(container("alpine"), &corev1.ResourceQuotaSpec{
+				Hard:   computeQuota.Hard,
+				Scopes: []corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeTerminating},
+			}),
+			expected: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d, err := quotaRequirementsCheck.Run(test.objs)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, test.expected, d)
+		})
+	}
+}
+
+func withQuota(objs *kube.Objects, spec *corev1.ResourceQuotaSpec) *kube.Objects {
+	objs.ResourceQuotas = &corev1.ResourceQuotaList{}
+	if spec != nil {
+		objs.ResourceQuotas.Items = append(objs.ResourceQuotas.Items, corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "quota", Namespace: "k8s"},
+			Spec:       *spec,
+		})
+	}
+	return objs
+}
@@ -18,6 +18,7 @@ package basic
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/digitalocean/clusterlint/checks"
 	"github.com/digitalocean/clusterlint/kube"
@@ -46,26 +47,62 @@ func (r *resourceRequirementsCheck) Description() string {
 	return "Check if pods have resource requirements set"
 }
 
+// Resources returns the resources whose objects this check reads.
+func (r *resourceRequirementsCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.LimitRanges, kube.Pods}
+}
+
 // Run runs this check on a set of Kubernetes objects. It can return warnings
 // (low-priority problems) and errors (high-priority problems) as well as an
 // error value indicating that the check failed to run.
 func (r *resourceRequirementsCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
 	var diagnostics []checks.Diagnostic
+	limitRanges := limitRangesByNamespace(objects)
 
 	for _, pod := range objects.Pods.Items {
-		d := r.checkResourceRequirements(pod.Spec.Containers, pod)
+		requests, limits := limitRangeDefaults(limitRanges[pod.Namespace])
+		d := r.checkResourceRequirements(pod.Spec.Containers, pod, requests, limits)
 		diagnostics = append(diagnostics, d...)
-		d = r.checkResourceRequirements(pod.Spec.InitContainers, pod)
+		d = r.checkResourceRequirements(pod.Spec.InitContainers, pod, requests, limits)
 		diagnostics = append(diagnostics, d...)
 	}
 
 	return diagnostics, nil
 }
 
+// requiredResources are the resources containers should set requests and
+// limits for.
+var requiredResources = []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}
+
+// missingDefaults returns the requests and limits of the required resources
+// that the defaults of a namespace's limit ranges do not supply.
+func missingDefaults(requests, limits corev1.ResourceList) []string {
+	var missing []string
+	for _, name := range requiredResources {
+		if _, ok := requests[name]; !ok {
+			missing = append(missing, fmt.Sprintf("%s request", name))
+		}
+		if _, ok := limits[name]; !ok {
+			missing = append(missing, fmt.Sprintf("%s limit", name))
+		}
+	}
+	return missing
+}
+
 // checkImage checks if the image name is fully qualified
 // Adds a warning if the container does not use a fully qualified image name
-func (r *resourceRequirementsCheck) checkResourceRequirements(containers []corev1.Container, pod corev1.Pod) []checks.Diagnostic {
+// Containers without resource requirements are only reported if the limit
+// ranges of the pod's namespace do not supply defaults for all of them.
+func (r *resourceRequirementsCheck) checkResourceRequirements(containers []corev1.Container, pod corev1.Pod, requests, limits corev1.ResourceList) []checks.Diagnostic {
 	var diagnostics []checks.Diagnostic
+	missing := missingDefaults(requests, limits)
+	if len(missing) == 0 {
+		return nil
+	}
+	var details string
+	if len(requests) > 0 || len(limits) > 0 {
+		details = fmt.Sprintf("Not set by limit range defaults: %s", strings.Join(missing, ", "))
+	}
 	for _, container := range containers {
 		if container.Resources.Size() == 0 {
 			d := checks.Diagnostic{
@@ -74,6 +111,7 @@ func (r *resourceRequirementsCheck) checkResourceRequirements(containers []corev
 				Kind:     checks.Pod,
 				Object:   &pod.ObjectMeta,
 				Owners:   pod.ObjectMeta.GetOwnerReferences(),
+				Details:  details,
 			}
 			diagnostics = append(diagnostics, d)
 		}
@@ -86,6 +86,36 @@ func TestResourceRequestsWarning(t *testing.T) {
 			objs:     resources(),
 			expected: nil,
 		},
+		{
+			name: "limit range supplies defaults",
+			objs: withLimitRange(container("alpine"), corev1.LimitRangeItem{
+				Type: corev1.LimitTypeContainer,
+				Default: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("500m"),
+					corev1.ResourceMemory: resource.MustParse("256Mi"),
+				},
+			}),
+			expected: nil,
+		},
+		{
+			name: "limit range supplies some defaults",
+			objs: withLimitRange(container("alpine"), corev1.LimitRangeItem{
+				Type: corev1.LimitTypeContainer,
+				Default: corev1.ResourceList{
+					corev1.ResourceMemory: resource.MustParse("256Mi"),
+				},
+			}),
+			expected: []checks.Diagnostic{
+				{
+					Severity: checks.Warning,
+					Message:  message,
+					Kind:     checks.Pod,
+					Object:   GetObjectMeta(),
+					Owners:   GetOwners(),
+					Details:  "Not set by limit range defaults: cpu request, cpu limit",
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
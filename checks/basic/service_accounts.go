@@ -0,0 +1,334 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package basic
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	"github.com/digitalocean/clusterlint/kube/references"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultServiceAccount = "default"
+	// serviceAccountsGroup is the group every service account belongs to.
+	// Service accounts also belong to serviceAccountsGroup:<namespace>.
+	serviceAccountsGroup = "system:serviceaccounts"
+	bootstrappingLabel   = "kubernetes.io/bootstrapping"
+)
+
+func init() {
+	checks.Register(&automountServiceAccountTokenCheck{})
+	checks.Register(&legacyServiceAccountTokenCheck{})
+	checks.Register(&unusedServiceAccountCheck{})
+	checks.Register(&defaultServiceAccountCheck{})
+}
+
+type automountServiceAccountTokenCheck struct{}
+
+// Name returns a unique name for this check.
+func (a *automountServiceAccountTokenCheck) Name() string {
+	return "automount-service-account-token"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (a *automountServiceAccountTokenCheck) Groups() []string {
+	return []string{"security"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (a *automountServiceAccountTokenCheck) Description() string {
+	return "Checks if there are pods that automount a service account token although the service account has no role bindings"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (a *automountServiceAccountTokenCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.ClusterRoleBindings, kube.Pods, kube.RoleBindings, kube.ServiceAccounts}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (a *automountServiceAccountTokenCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	accounts := make(map[kube.Identifier]*corev1.ServiceAccount)
+	for i := range objects.ServiceAccounts.Items {
+		sa := &objects.ServiceAccounts.Items[i]
+		accounts[kube.Identifier{Name: sa.Name, Namespace: sa.Namespace}] = sa
+	}
+	bound := boundServiceAccounts(objects)
+
+	forEachWorkload(objects, func(pod corev1.Pod, workload checks.Workload) {
+		if checks.IsSystemNamespace(pod.Namespace) {
+			return
+		}
+		id := kube.Identifier{Name: podServiceAccount(pod.Spec), Namespace: pod.Namespace}
+		if !automountsToken(pod.Spec, accounts[id]) || bound.has(id) {
+			return
+		}
+		d := checks.Diagnostic{
+			Severity: checks.Warning,
+			Message:  fmt.Sprintf("Pod automounts a token for service account `%s`, which has no role bindings. Set automountServiceAccountToken to false if it does not use the Kubernetes API.", id.Name),
+			Kind:     workload.Kind,
+			Object:   workload.Object,
+			Owners:   pod.ObjectMeta.GetOwnerReferences(),
+		}
+		diagnostics = append(diagnostics, d)
+	})
+	return diagnostics, nil
+}
+
+type legacyServiceAccountTokenCheck struct{}
+
+// Name returns a unique name for this check.
+func (l *legacyServiceAccountTokenCheck) Name() string {
+	return "legacy-service-account-token"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (l *legacyServiceAccountTokenCheck) Groups() []string {
+	return []string{"basic"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (l *legacyServiceAccountTokenCheck) Description() string {
+	return "Checks if there are long-lived service account token secrets in the cluster"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (l *legacyServiceAccountTokenCheck) Resources() []kube.Resource {
+	return append([]kube.Resource{kube.Secrets}, references.ResourcesFor(references.Secret, references.WorkloadKinds...)...)
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (l *legacyServiceAccountTokenCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	graph := references.NewGraph(objects)
+
+	for _, secret := range objects.Secrets.Items {
+		if secret.Type != corev1.SecretTypeServiceAccountToken {
+			continue
+		}
+		secret := secret
+		message := fmt.Sprintf("Long-lived token for service account `%s`. Use short-lived tokens from the TokenRequest API instead.", secret.Annotations[corev1.ServiceAccountNameKey])
+		if graph.IsReferenced(references.Ref{Kind: references.Secret, Namespace: secret.Namespace, Name: secret.Name}, references.WorkloadKinds...) {
+			message = fmt.Sprintf("Long-lived token for service account `%s` is mounted by pods. Use a projected service account token volume instead.", secret.Annotations[corev1.ServiceAccountNameKey])
+		}
+		d := checks.Diagnostic{
+			Severity: checks.Warning,
+			Message:  message,
+			Kind:     checks.Secret,
+			Object:   &secret.ObjectMeta,
+			Owners:   secret.ObjectMeta.GetOwnerReferences(),
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics, nil
+}
+
+type unusedServiceAccountCheck struct{}
+
+// Name returns a unique name for this check.
+func (u *unusedServiceAccountCheck) Name() string {
+	return "unused-service-account"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (u *unusedServiceAccountCheck) Groups() []string {
+	return []string{"basic"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (u *unusedServiceAccountCheck) Description() string {
+	return "Checks if there are service accounts that are not used by any workload. Ignores default service accounts and system namespaces"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (u *unusedServiceAccountCheck) Resources() []kube.Resource {
+	return append([]kube.Resource{kube.ServiceAccounts}, references.ResourcesFor(references.ServiceAccount, references.WorkloadKinds...)...)
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (u *unusedServiceAccountCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	graph := references.NewGraph(objects)
+
+	for _, sa := range objects.ServiceAccounts.Items {
+		if sa.Name == defaultServiceAccount {
+			continue
+		}
+		if checks.IsSystemNamespace(sa.Namespace) {
+			continue
+		}
+		if graph.IsReferenced(references.Ref{Kind: references.ServiceAccount, Namespace: sa.Namespace, Name: sa.Name}, references.WorkloadKinds...) {
+			continue
+		}
+		sa := sa
+		d := checks.Diagnostic{
+			Severity: checks.Warning,
+			Message:  "Unused service account",
+			Kind:     checks.ServiceAccount,
+			Object:   &sa.ObjectMeta,
+			Owners:   sa.ObjectMeta.GetOwnerReferences(),
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics, nil
+}
+
+type defaultServiceAccountCheck struct{}
+
+// Name returns a unique name for this check.
+func (d *defaultServiceAccountCheck) Name() string {
+	return "default-service-account"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (d *defaultServiceAccountCheck) Groups() []string {
+	return []string{"security"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (d *defaultServiceAccountCheck) Description() string {
+	return "Checks if there are pods outside system namespaces that run as the default service account"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (d *defaultServiceAccountCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Pods}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (d *defaultServiceAccountCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	forEachWorkload(objects, func(pod corev1.Pod, workload checks.Workload) {
+		if checks.IsSystemNamespace(pod.Namespace) {
+			return
+		}
+		if podServiceAccount(pod.Spec) != defaultServiceAccount {
+			return
+		}
+		d := checks.Diagnostic{
+			Severity: checks.Suggestion,
+			Message:  "Pod runs as the default service account. Create a dedicated service account so that permissions granted to it are not shared with other workloads.",
+			Kind:     workload.Kind,
+			Object:   workload.Object,
+			Owners:   pod.ObjectMeta.GetOwnerReferences(),
+		}
+		diagnostics = append(diagnostics, d)
+	})
+	return diagnostics, nil
+}
+
+// podServiceAccount returns the name of the service account a pod runs as.
+func podServiceAccount(spec corev1.PodSpec) string {
+	if spec.ServiceAccountName != "" {
+		return spec.ServiceAccountName
+	}
+	// DeprecatedServiceAccount is still set by older clients.
+	if spec.DeprecatedServiceAccount != "" {
+		return spec.DeprecatedServiceAccount
+	}
+	return defaultServiceAccount
+}
+
+// automountsToken reports whether a service account token is mounted into a
+// pod. The pod's setting takes precedence over the service account's, and
+// tokens are mounted unless either opts out.
+func automountsToken(spec corev1.PodSpec, sa *corev1.ServiceAccount) bool {
+	if spec.AutomountServiceAccountToken != nil {
+		return *spec.AutomountServiceAccountToken
+	}
+	if sa != nil && sa.AutomountServiceAccountToken != nil {
+		return *sa.AutomountServiceAccountToken
+	}
+	return true
+}
+
+// serviceAccountBindings records the service accounts that are subjects of
+// user-managed role bindings, either directly or through the service account
+// groups.
+type serviceAccountBindings struct {
+	accounts   map[kube.Identifier]struct{}
+	namespaces map[string]struct{}
+	all        bool
+}
+
+func (b serviceAccountBindings) has(id kube.Identifier) bool {
+	if b.all {
+		return true
+	}
+	if _, ok := b.namespaces[id.Namespace]; ok {
+		return true
+	}
+	_, ok := b.accounts[id]
+	return ok
+}
+
+// boundServiceAccounts returns the service accounts bound to a role. Bindings
+// created by Kubernetes, such as the one granting every service account
+// access to the OIDC discovery endpoints, are ignored.
+func boundServiceAccounts(objects *kube.Objects) serviceAccountBindings {
+	bound := serviceAccountBindings{
+		accounts:   make(map[kube.Identifier]struct{}),
+		namespaces: make(map[string]struct{}),
+	}
+	add := func(meta metav1.ObjectMeta, subjects []rbacv1.Subject) {
+		if strings.HasPrefix(meta.Name, "system:") {
+			return
+		}
+		if _, ok := meta.Labels[bootstrappingLabel]; ok {
+			return
+		}
+		for _, subject := range subjects {
+			switch {
+			case subject.Kind == rbacv1.ServiceAccountKind:
+				namespace := subject.Namespace
+				if namespace == "" {
+					namespace = meta.Namespace
+				}
+				bound.accounts[kube.Identifier{Name: subject.Name, Namespace: namespace}] = struct{}{}
+			case subject.Kind == rbacv1.GroupKind && subject.Name == serviceAccountsGroup:
+				bound.all = true
+			case subject.Kind == rbacv1.GroupKind && strings.HasPrefix(subject.Name, serviceAccountsGroup+":"):
+				bound.namespaces[strings.TrimPrefix(subject.Name, serviceAccountsGroup+":")] = struct{}{}
+			}
+		}
+	}
+	for _, b := range objects.ClusterRoleBindings.Items {
+		add(b.ObjectMeta, b.Subjects)
+	}
+	for _, b := range objects.RoleBindings.Items {
+		add(b.ObjectMeta, b.Subjects)
+	}
+	return bound
+}
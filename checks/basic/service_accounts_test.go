@@ -0,0 +1,294 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package basic
+
+import (
+	"testing"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestServiceAccountChecksMeta(t *testing.T) {
+	for name, test := range map[string]struct {
+		check  checks.Check
+		groups []string
+	}{
+		"automount-service-account-token": {&automountServiceAccountTokenCheck{}, []string{"security"}},
+		"legacy-service-account-token":    {&legacyServiceAccountTokenCheck{}, []string{"basic"}},
+		"unused-service-account":          {&unusedServiceAccountCheck{}, []string{"basic"}},
+		"default-service-account":         {&defaultServiceAccountCheck{}, []string{"security"}},
+	} {
+		assert.Equal(t, name, test.check.Name())
+		assert.Equal(t, test.groups, test.check.Groups())
+		assert.NotEmpty(t, test.check.Description())
+
+		registered, err := checks.Get(name)
+		assert.NoError(t, err)
+		assert.IsType(t, test.check, registered)
+	}
+}
+
+func TestAutomountServiceAccountTokenWarning(t *testing.T) {
+	falseVal := false
+	trueVal := true
+
+	tests := []struct {
+		name     string
+		objs     func() *kube.Objects
+		expected []checks.Diagnostic
+	}{
+		{
+			name:     "service account without bindings",
+			objs:     initServiceAccounts,
+			expected: automountDiagnostic("app"),
+		},
+		{
+			name: "service account bound directly",
+			objs: func() *kube.Objects {
+				objs := initServiceAccounts()
+				objs.RoleBindings.Items = []rbacv1.RoleBinding{{
+					ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "k8s"},
+					Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "app"}},
+				}}
+				return objs
+			},
+			expected: nil,
+		},
+		{
+			name: "namespace service accounts group bound",
+			objs: func() *kube.Objects {
+				objs := initServiceAccounts()
+				objs.ClusterRoleBindings.Items = []rbacv1.ClusterRoleBinding{{
+					ObjectMeta: metav1.ObjectMeta{Name: "view"},
+					Subjects:   []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "system:serviceaccounts:k8s"}},
+				}}
+				return objs
+			},
+			expected: nil,
+		},
+		{
+			name: "system bindings are ignored",
+			objs: func() *kube.Objects {
+				objs := initServiceAccounts()
+				objs.ClusterRoleBindings.Items = []rbacv1.ClusterRoleBinding{{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "system:service-account-issuer-discovery",
+						Labels: map[string]string{"kubernetes.io/bootstrapping": "rbac-defaults"},
+					},
+					Subjects: []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "system:serviceaccounts"}},
+				}}
+				return objs
+			},
+			expected: automountDiagnostic("app"),
+		},
+		{
+			name: "pod opts out",
+			objs: func() *kube.Objects {
+				objs := initServiceAccounts()
+				objs.Pods.Items[0].Spec.AutomountServiceAccountToken = &falseVal
+				return objs
+			},
+			expected: nil,
+		},
+		{
+			name: "service account opts out",
+			objs: func() *kube.Objects {
+				objs := initServiceAccounts()
+				objs.ServiceAccounts.Items[1].AutomountServiceAccountToken = &falseVal
+				return objs
+			},
+			expected: nil,
+		},
+		{
+			name: "pod overrides service account",
+			objs: func() *kube.Objects {
+				objs := initServiceAccounts()
+				objs.ServiceAccounts.Items[1].AutomountServiceAccountToken = &falseVal
+				objs.Pods.Items[0].Spec.AutomountServiceAccountToken = &trueVal
+				return objs
+			},
+			expected: automountDiagnostic("app"),
+		},
+		{
+			name: "system namespace",
+			objs: func() *kube.Objects {
+				objs := initServiceAccounts()
+				objs.Pods.Items[0].Namespace = metav1.NamespaceSystem
+				return objs
+			},
+			expected: nil,
+		},
+	}
+
+	check := &automountServiceAccountTokenCheck{}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d, err := check.Run(test.objs())
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, test.expected, d)
+		})
+	}
+}
+
+func TestLegacyServiceAccountTokenWarning(t *testing.T) {
+	objs := initServiceAccounts()
+	objs.Secrets.Items = []corev1.Secret{
+		{ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: "k8s"}, Type: corev1.SecretTypeDockerConfigJson},
+		serviceAccountToken("ci-token", "ci"),
+		serviceAccountToken("app-token", "app"),
+	}
+	objs.Pods.Items[0].Spec.Volumes = []corev1.Volume{{
+		Name:         "token",
+		VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "app-token"}},
+	}}
+
+	d, err := (&legacyServiceAccountTokenCheck{}).Run(objs)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []checks.Diagnostic{
+		{
+			Severity: checks.Warning,
+			Message:  "Long-lived token for service account `ci`. Use short-lived tokens from the TokenRequest API instead.",
+			Kind:     checks.Secret,
+			Object:   &objs.Secrets.Items[1].ObjectMeta,
+		},
+		{
+			Severity: checks.Warning,
+			Message:  "Long-lived token for service account `app` is mounted by pods. Use a projected service account token volume instead.",
+			Kind:     checks.Secret,
+			Object:   &objs.Secrets.Items[2].ObjectMeta,
+		},
+	}, d)
+}
+
+func TestUnusedServiceAccountWarning(t *testing.T) {
+	objs := initServiceAccounts()
+	objs.ServiceAccounts.Items = append(objs.ServiceAccounts.Items,
+		corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "unused", Namespace: "k8s"}},
+		corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "k8s"}},
+		corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: metav1.NamespaceSystem}},
+	)
+	objs.CronJobs.Items = []batchv1.CronJob{{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "k8s"},
+		Spec: batchv1.CronJobSpec{
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{ServiceAccountName: "backup"},
+					},
+				},
+			},
+		},
+	}}
+
+	d, err := (&unusedServiceAccountCheck{}).Run(objs)
+	assert.NoError(t, err)
+	assert.Equal(t, []checks.Diagnostic{{
+		Severity: checks.Warning,
+		Message:  "Unused service account",
+		Kind:     checks.ServiceAccount,
+		Object:   &objs.ServiceAccounts.Items[2].ObjectMeta,
+	}}, d)
+}
+
+func TestDefaultServiceAccountSuggestion(t *testing.T) {
+	tests := []struct {
+		name           string
+		namespace      string
+		serviceAccount string
+		expected       []checks.Diagnostic
+	}{
+		{
+			name:      "default service account",
+			namespace: "k8s",
+			expected: []checks.Diagnostic{{
+				Severity: checks.Suggestion,
+				Message:  "Pod runs as the default service account. Create a dedicated service account so that permissions granted to it are not shared with other workloads.",
+				Kind:     checks.Pod,
+				Object:   GetObjectMeta(),
+				Owners:   GetOwners(),
+			}},
+		},
+		{
+			name:           "dedicated service account",
+			namespace:      "k8s",
+			serviceAccount: "app",
+			expected:       nil,
+		},
+		{
+			name:      "system namespace",
+			namespace: metav1.NamespaceSystem,
+			expected:  nil,
+		},
+	}
+
+	check := &defaultServiceAccountCheck{}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := initPod()
+			objs.Pods.Items[0].Namespace = test.namespace
+			objs.Pods.Items[0].Spec.ServiceAccountName = test.serviceAccount
+
+			d, err := check.Run(objs)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, test.expected, d)
+		})
+	}
+}
+
+func initServiceAccounts() *kube.Objects {
+	objs := initPod()
+	objs.Pods.Items[0].Spec.ServiceAccountName = "app"
+	objs.PodTemplates = &corev1.PodTemplateList{}
+	objs.CronJobs = &batchv1.CronJobList{}
+	objs.Secrets = &corev1.SecretList{}
+	objs.ServiceAccounts = &corev1.ServiceAccountList{
+		Items: []corev1.ServiceAccount{
+			{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "k8s"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "k8s"}},
+		},
+	}
+	objs.RoleBindings = &rbacv1.RoleBindingList{}
+	objs.ClusterRoleBindings = &rbacv1.ClusterRoleBindingList{}
+	return objs
+}
+
+func automountDiagnostic(serviceAccount string) []checks.Diagnostic {
+	return []checks.Diagnostic{{
+		Severity: checks.Warning,
+		Message:  "Pod automounts a token for service account `" + serviceAccount + "`, which has no role bindings. Set automountServiceAccountToken to false if it does not use the Kubernetes API.",
+		Kind:     checks.Pod,
+		Object:   GetObjectMeta(),
+		Owners:   GetOwners(),
+	}}
+}
+
+func serviceAccountToken(name, serviceAccount string) corev1.Secret {
+	return corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "k8s",
+			Annotations: map[string]string{corev1.ServiceAccountNameKey: serviceAccount},
+		},
+		Type: corev1.SecretTypeServiceAccountToken,
+	}
+}
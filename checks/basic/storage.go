@@ -0,0 +1,362 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package basic
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	"github.com/digitalocean/clusterlint/kube/references"
+	corev1 "k8s.io/api/core/v1"
+	st "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// minPendingClaimAge is how long a claim may wait for its volume to be
+// provisioned before it is reported.
+const minPendingClaimAge = 5 * time.Minute
+
+// betaStorageClassAnnotation is the deprecated way of setting the storage
+// class of a claim, which Kubernetes still honors.
+const betaStorageClassAnnotation = "volume.beta.kubernetes.io/storage-class"
+
+func init() {
+	checks.Register(&pendingClaimCheck{})
+	checks.Register(&volumePhaseCheck{})
+	checks.Register(&retainedVolumeCheck{})
+	checks.Register(&defaultStorageClassCheck{})
+}
+
+type pendingClaimCheck struct{}
+
+// Name returns a unique name for this check.
+func (c *pendingClaimCheck) Name() string {
+	return "pvc-pending"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (c *pendingClaimCheck) Groups() []string {
+	return []string{"basic"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (c *pendingClaimCheck) Description() string {
+	return "Checks if there are persistent volume claims that are stuck pending and explains why"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (c *pendingClaimCheck) Resources() []kube.Resource {
+	return append([]kube.Resource{kube.PersistentVolumeClaims, kube.PersistentVolumes, kube.StorageClasses},
+		references.ResourcesFor(references.PersistentVolumeClaim, references.Pod)...)
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (c *pendingClaimCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	graph := references.NewGraph(objects)
+	for i := range objects.PersistentVolumeClaims.Items {
+		claim := &objects.PersistentVolumeClaims.Items[i]
+		if claim.Status.Phase != corev1.ClaimPending {
+			continue
+		}
+		used := graph.IsReferenced(references.Ref{Kind: references.PersistentVolumeClaim, Namespace: claim.Namespace, Name: claim.Name}, references.Pod)
+		reason, class := pendingReason(objects, claim, used)
+		if reason == "" {
+			continue
+		}
+		var details []string
+		if class != "" {
+			details = append(details, fmt.Sprintf("Storage class: %s", class))
+		}
+		if storage, ok := claim.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
+			details = append(details, fmt.Sprintf("Requested storage: %s", storage.String()))
+		}
+		d := checks.Diagnostic{
+			Severity: checks.Warning,
+			Message:  fmt.Sprintf("Persistent volume claim is pending because %s", reason),
+			Kind:     checks.PersistentVolumeClaim,
+			Object:   &claim.ObjectMeta,
+			Owners:   claim.ObjectMeta.GetOwnerReferences(),
+			Details:  strings.Join(details, ". "),
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics, nil
+}
+
+// pendingReason explains why a pending claim is not bound, along with the
+// storage class it uses. It returns an empty reason for claims that are
+// expected to be pending.
+func pendingReason(objects *kube.Objects, claim *corev1.PersistentVolumeClaim, used bool) (string, string) {
+	if name := claim.Spec.VolumeName; name != "" {
+		pv := persistentVolume(objects, name)
+		switch {
+		case pv == nil:
+			return fmt.Sprintf("persistent volume `%s` does not exist", name), ""
+		case pv.Spec.ClaimRef != nil && (pv.Spec.ClaimRef.Namespace != claim.Namespace || pv.Spec.ClaimRef.Name != claim.Name):
+			return fmt.Sprintf("persistent volume `%s` is bound to claim `%s/%s`", name, pv.Spec.ClaimRef.Namespace, pv.Spec.ClaimRef.Name), pv.Spec.StorageClassName
+		}
+	}
+
+	className := claim.Spec.StorageClassName
+	if className == nil {
+		if name, ok := claim.Annotations[betaStorageClassAnnotation]; ok {
+			className = &name
+		}
+	}
+	if className == nil {
+		if objects.DefaultStorageClass == nil {
+			return "it does not set a storage class and the cluster has no default storage class", ""
+		}
+		className = &objects.DefaultStorageClass.Name
+	}
+	if *className == "" {
+		return "no persistent volume without a storage class matches the claim", ""
+	}
+
+	var class *st.StorageClass
+	for i := range objects.StorageClasses.Items {
+		if objects.StorageClasses.Items[i].Name == *className {
+			class = &objects.StorageClasses.Items[i]
+		}
+	}
+	if class == nil {
+		return fmt.Sprintf("storage class `%s` does not exist", *className), *className
+	}
+	// Volumes of such storage classes are only provisioned once a pod using
+	// the claim is scheduled.
+	if class.VolumeBindingMode != nil && *class.VolumeBindingMode == st.VolumeBindingWaitForFirstConsumer && !used {
+		return "", *className
+	}
+	if now().Sub(claim.CreationTimestamp.Time) < minPendingClaimAge {
+		return "", *className
+	}
+	return fmt.Sprintf("its volume has not been provisioned by `%s`", class.Provisioner), *className
+}
+
+func persistentVolume(objects *kube.Objects, name string) *corev1.PersistentVolume {
+	for i := range objects.PersistentVolumes.Items {
+		if objects.PersistentVolumes.Items[i].Name == name {
+			return &objects.PersistentVolumes.Items[i]
+		}
+	}
+	return nil
+}
+
+type volumePhaseCheck struct{}
+
+// Name returns a unique name for this check.
+func (c *volumePhaseCheck) Name() string {
+	return "pv-phase"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (c *volumePhaseCheck) Groups() []string {
+	return []string{"basic"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (c *volumePhaseCheck) Description() string {
+	return "Checks if there are persistent volumes that failed or were not reclaimed after their claim was deleted"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (c *volumePhaseCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.PersistentVolumes}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (c *volumePhaseCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	for i := range objects.PersistentVolumes.Items {
+		pv := &objects.PersistentVolumes.Items[i]
+		var message string
+		switch {
+		case pv.Status.Phase == corev1.VolumeFailed:
+			message = "Persistent volume is `Failed`. Kubernetes could not reclaim the volume after its claim was deleted."
+		case pv.Status.Phase == corev1.VolumeReleased && pv.Spec.PersistentVolumeReclaimPolicy != corev1.PersistentVolumeReclaimRetain:
+			// Released volumes that are retained are reported by the
+			// retained-pv check.
+			message = fmt.Sprintf("Persistent volume is `Released`, but was not reclaimed according to its `%s` reclaim policy.", pv.Spec.PersistentVolumeReclaimPolicy)
+		default:
+			continue
+		}
+		d := checks.Diagnostic{
+			Severity: checks.Warning,
+			Message:  message,
+			Kind:     checks.PersistentVolume,
+			Object:   &pv.ObjectMeta,
+			Owners:   pv.ObjectMeta.GetOwnerReferences(),
+			Details:  volumeDetails(pv),
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics, nil
+}
+
+type retainedVolumeCheck struct{}
+
+// Name returns a unique name for this check.
+func (c *retainedVolumeCheck) Name() string {
+	return "retained-pv"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (c *retainedVolumeCheck) Groups() []string {
+	return []string{"basic", "doks"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (c *retainedVolumeCheck) Description() string {
+	return "Checks if there are persistent volumes with the Retain reclaim policy whose claim no longer exists"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (c *retainedVolumeCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.PersistentVolumeClaims, kube.PersistentVolumes}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (c *retainedVolumeCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	claims := make(map[kube.Identifier]*corev1.PersistentVolumeClaim)
+	for i := range objects.PersistentVolumeClaims.Items {
+		claim := &objects.PersistentVolumeClaims.Items[i]
+		claims[kube.Identifier{Name: claim.Name, Namespace: claim.Namespace}] = claim
+	}
+
+	var diagnostics []checks.Diagnostic
+	for i := range objects.PersistentVolumes.Items {
+		pv := &objects.PersistentVolumes.Items[i]
+		ref := pv.Spec.ClaimRef
+		if pv.Spec.PersistentVolumeReclaimPolicy != corev1.PersistentVolumeReclaimRetain || ref == nil {
+			continue
+		}
+		// A claim with the same name but a different UID was recreated and
+		// does not use this volume.
+		if claim, ok := claims[kube.Identifier{Name: ref.Name, Namespace: ref.Namespace}]; ok && (ref.UID == "" || claim.UID == ref.UID) {
+			continue
+		}
+		d := checks.Diagnostic{
+			Severity: checks.Warning,
+			Message:  fmt.Sprintf("Persistent volume has the `Retain` reclaim policy and its claim `%s/%s` no longer exists. The underlying storage is kept, and billed, until the volume is deleted.", ref.Namespace, ref.Name),
+			Kind:     checks.PersistentVolume,
+			Object:   &pv.ObjectMeta,
+			Owners:   pv.ObjectMeta.GetOwnerReferences(),
+			Details:  volumeDetails(pv),
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics, nil
+}
+
+// volumeDetails describes the phase, size and storage class of a volume.
+func volumeDetails(pv *corev1.PersistentVolume) string {
+	details := []string{fmt.Sprintf("Phase: %s", pv.Status.Phase)}
+	if storage, ok := pv.Spec.Capacity[corev1.ResourceStorage]; ok {
+		details = append(details, fmt.Sprintf("Capacity: %s", storage.String()))
+	}
+	if pv.Spec.StorageClassName != "" {
+		details = append(details, fmt.Sprintf("Storage class: %s", pv.Spec.StorageClassName))
+	}
+	if pv.Spec.CSI != nil {
+		details = append(details, fmt.Sprintf("Volume handle: %s", pv.Spec.CSI.VolumeHandle))
+	}
+	if message := strings.TrimSuffix(pv.Status.Message, "."); message != "" {
+		details = append(details, fmt.Sprintf("Message: %s", message))
+	}
+	return strings.Join(details, ". ")
+}
+
+type defaultStorageClassCheck struct{}
+
+// Name returns a unique name for this check.
+func (c *defaultStorageClassCheck) Name() string {
+	return "default-storage-class"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (c *defaultStorageClassCheck) Groups() []string {
+	return []string{"basic"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (c *defaultStorageClassCheck) Description() string {
+	return "Checks if exactly one storage class is marked as the cluster's default"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (c *defaultStorageClassCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.StorageClasses}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (c *defaultStorageClassCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	defaults := kube.DefaultStorageClasses(objects.StorageClasses)
+	switch len(defaults) {
+	case 0:
+		// Clusters without storage classes don't provision volumes at all,
+		// which is not a problem of the default.
+		if len(objects.StorageClasses.Items) == 0 {
+			break
+		}
+		// The finding concerns the cluster rather than any one storage
+		// class, so it is reported once for the cluster.
+		diagnostics = append(diagnostics, checks.Diagnostic{
+			Severity: checks.Warning,
+			Message:  "No storage class is marked as the default. Persistent volume claims without a storage class are not provisioned.",
+			Kind:     checks.Cluster,
+			Object:   &metav1.ObjectMeta{},
+		})
+	case 1:
+	default:
+		var names []string
+		for _, class := range defaults {
+			names = append(names, class.Name)
+		}
+		for i := range objects.StorageClasses.Items {
+			class := &objects.StorageClasses.Items[i]
+			if !kube.IsDefaultStorageClass(*class) {
+				continue
+			}
+			diagnostics = append(diagnostics, checks.Diagnostic{
+				Severity: checks.Warning,
+				Message:  fmt.Sprintf("Storage class is one of %d default storage classes. Persistent volume claims without a storage class use the most recently created one, `%s`.", len(defaults), defaults[0].Name),
+				Kind:     checks.StorageClass,
+				Object:   &class.ObjectMeta,
+				Owners:   class.ObjectMeta.GetOwnerReferences(),
+				Details:  fmt.Sprintf("Default storage classes: %s", strings.Join(names, ", ")),
+			})
+		}
+	}
+	return diagnostics, nil
+}
@@ -0,0 +1,388 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package basic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	st "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStorageChecksMeta(t *testing.T) {
+	for name, check := range map[string]checks.Check{
+		"pvc-pending":           &pendingClaimCheck{},
+		"pv-phase":              &volumePhaseCheck{},
+		"retained-pv":           &retainedVolumeCheck{},
+		"default-storage-class": &defaultStorageClassCheck{},
+	} {
+		assert.Equal(t, name, check.Name())
+		assert.Contains(t, check.Groups(), "basic")
+		assert.NotEmpty(t, check.Description())
+
+		registered, err := checks.Get(name)
+		assert.NoError(t, err)
+		assert.IsType(t, check, registered)
+	}
+}
+
+func TestPendingClaim(t *testing.T) {
+	current := time.Date(2022, 3, 3, 12, 0, 0, 0, time.UTC)
+	defer func() { now = time.Now }()
+	now = func() time.Time { return current }
+	className := func(name string) *string { return &name }
+
+	tests := []struct {
+		name     string
+		objs     func(*kube.Objects)
+		expected []string
+		details  []string
+	}{
+		{
+			name:     "bound claim",
+			objs:     func(objs *kube.Objects) { objs.PersistentVolumeClaims.Items[0].Status.Phase = corev1.ClaimBound },
+			expected: nil,
+		},
+		{
+			name: "recently created claim",
+			objs: func(objs *kube.Objects) {
+				objs.PersistentVolumeClaims.Items[0].CreationTimestamp = metav1.NewTime(current.Add(-time.Minute))
+			},
+			expected: nil,
+		},
+		{
+			name:     "not provisioned",
+			expected: []string{"Persistent volume claim is pending because its volume has not been provisioned by `dobs.csi.digitalocean.com`"},
+			details:  []string{"Storage class: do-block-storage. Requested storage: 10Gi"},
+		},
+		{
+			name: "missing storage class",
+			objs: func(objs *kube.Objects) {
+				objs.PersistentVolumeClaims.Items[0].Spec.StorageClassName = className("fast")
+			},
+			expected: []string{"Persistent volume claim is pending because storage class `fast` does not exist"},
+			details:  []string{"Storage class: fast. Requested storage: 10Gi"},
+		},
+		{
+			name: "missing storage class in beta annotation",
+			objs: func(objs *kube.Objects) {
+				objs.PersistentVolumeClaims.Items[0].Annotations = map[string]string{betaStorageClassAnnotation: "fast"}
+			},
+			expected: []string{"Persistent volume claim is pending because storage class `fast` does not exist"},
+			details:  []string{"Storage class: fast. Requested storage: 10Gi"},
+		},
+		{
+			name:     "no default storage class",
+			objs:     func(objs *kube.Objects) { objs.DefaultStorageClass = nil },
+			expected: []string{"Persistent volume claim is pending because it does not set a storage class and the cluster has no default storage class"},
+			details:  []string{"Requested storage: 10Gi"},
+		},
+		{
+			name: "missing volume",
+			objs: func(objs *kube.Objects) {
+				objs.PersistentVolumeClaims.Items[0].Spec.StorageClassName = className("")
+				objs.PersistentVolumeClaims.Items[0].Spec.VolumeName = "pv-1"
+			},
+			expected: []string{"Persistent volume claim is pending because persistent volume `pv-1` does not exist"},
+			details:  []string{"Requested storage: 10Gi"},
+		},
+		{
+			name: "volume bound to another claim",
+			objs: func(objs *kube.Objects) {
+				objs.PersistentVolumeClaims.Items[0].Spec.VolumeName = "pv-1"
+				objs.PersistentVolumes.Items = []corev1.PersistentVolume{{
+					ObjectMeta: metav1.ObjectMeta{Name: "pv-1"},
+					Spec: corev1.PersistentVolumeSpec{
+						StorageClassName: "do-block-storage",
+						ClaimRef:         &corev1.ObjectReference{Namespace: "k8s", Name: "other"},
+					},
+				}}
+			},
+			expected: []string{"Persistent volume claim is pending because persistent volume `pv-1` is bound to claim `k8s/other`"},
+			details:  []string{"Storage class: do-block-storage. Requested storage: 10Gi"},
+		},
+		{
+			name: "waiting for first consumer",
+			objs: func(objs *kube.Objects) {
+				mode := st.VolumeBindingWaitForFirstConsumer
+				objs.StorageClasses.Items[0].VolumeBindingMode = &mode
+			},
+			expected: nil,
+		},
+		{
+			name: "waiting for first consumer with pod",
+			objs: func(objs *kube.Objects) {
+				mode := st.VolumeBindingWaitForFirstConsumer
+				objs.StorageClasses.Items[0].VolumeBindingMode = &mode
+				objs.Pods.Items[0].Spec.Volumes = []corev1.Volume{{
+					Name:         "data",
+					VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data"}},
+				}}
+			},
+			expected: []string{"Persistent volume claim is pending because its volume has not been provisioned by `dobs.csi.digitalocean.com`"},
+			details:  []string{"Storage class: do-block-storage. Requested storage: 10Gi"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := initStorage()
+			objs.Pods = initPod().Pods
+			objs.PersistentVolumeClaims.Items = []corev1.PersistentVolumeClaim{{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "data",
+					Namespace:         "k8s",
+					CreationTimestamp: metav1.NewTime(current.Add(-time.Hour)),
+				},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					Resources: corev1.VolumeResourceRequirements{Requests: corev1.ResourceList{
+						corev1.ResourceStorage: resource.MustParse("10Gi"),
+					}},
+				},
+				Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+			}}
+			if test.objs != nil {
+				test.objs(objs)
+			}
+
+			d, err := (&pendingClaimCheck{}).Run(objs)
+			assert.NoError(t, err)
+			var messages, details []string
+			for _, diagnostic := range d {
+				assert.Equal(t, checks.PersistentVolumeClaim, diagnostic.Kind)
+				messages = append(messages, diagnostic.Message)
+				details = append(details, diagnostic.Details)
+			}
+			assert.Equal(t, test.expected, messages)
+			assert.Equal(t, test.details, details)
+		})
+	}
+}
+
+func TestVolumePhase(t *testing.T) {
+	tests := []struct {
+		name     string
+		phase    corev1.PersistentVolumePhase
+		policy   corev1.PersistentVolumeReclaimPolicy
+		expected []string
+	}{
+		{
+			name:     "bound",
+			phase:    corev1.VolumeBound,
+			policy:   corev1.PersistentVolumeReclaimDelete,
+			expected: nil,
+		},
+		{
+			name:     "failed",
+			phase:    corev1.VolumeFailed,
+			policy:   corev1.PersistentVolumeReclaimRecycle,
+			expected: []string{"Persistent volume is `Failed`. Kubernetes could not reclaim the volume after its claim was deleted."},
+		},
+		{
+			name:     "released",
+			phase:    corev1.VolumeReleased,
+			policy:   corev1.PersistentVolumeReclaimDelete,
+			expected: []string{"Persistent volume is `Released`, but was not reclaimed according to its `Delete` reclaim policy."},
+		},
+		{
+			name:     "released and retained",
+			phase:    corev1.VolumeReleased,
+			policy:   corev1.PersistentVolumeReclaimRetain,
+			expected: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := initStorage()
+			objs.PersistentVolumes.Items = []corev1.PersistentVolume{
+				newPersistentVolume(test.policy, test.phase, &corev1.ObjectReference{Namespace: "k8s", Name: "data"}),
+			}
+
+			d, err := (&volumePhaseCheck{}).Run(objs)
+			assert.NoError(t, err)
+			var messages []string
+			for _, diagnostic := range d {
+				assert.Equal(t, checks.Warning, diagnostic.Severity)
+				messages = append(messages, diagnostic.Message)
+			}
+			assert.Equal(t, test.expected, messages)
+		})
+	}
+}
+
+func TestRetainedVolume(t *testing.T) {
+	claim := corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "k8s", UID: "uid-1"}}
+	tests := []struct {
+		name     string
+		policy   corev1.PersistentVolumeReclaimPolicy
+		claimRef *corev1.ObjectReference
+		claims   []corev1.PersistentVolumeClaim
+		expected bool
+	}{
+		{
+			name:     "claim exists",
+			policy:   corev1.PersistentVolumeReclaimRetain,
+			claimRef: &corev1.ObjectReference{Namespace: "k8s", Name: "data", UID: "uid-1"},
+			claims:   []corev1.PersistentVolumeClaim{claim},
+			expected: false,
+		},
+		{
+			name:     "claim deleted",
+			policy:   corev1.PersistentVolumeReclaimRetain,
+			claimRef: &corev1.ObjectReference{Namespace: "k8s", Name: "data", UID: "uid-1"},
+			expected: true,
+		},
+		{
+			name:     "claim recreated",
+			policy:   corev1.PersistentVolumeReclaimRetain,
+			claimRef: &corev1.ObjectReference{Namespace: "k8s", Name: "data", UID: "uid-0"},
+			claims:   []corev1.PersistentVolumeClaim{claim},
+			expected: true,
+		},
+		{
+			name:     "claim deleted with delete policy",
+			policy:   corev1.PersistentVolumeReclaimDelete,
+			claimRef: &corev1.ObjectReference{Namespace: "k8s", Name: "data", UID: "uid-1"},
+			expected: false,
+		},
+		{
+			name:     "never claimed",
+			policy:   corev1.PersistentVolumeReclaimRetain,
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := initStorage()
+			objs.PersistentVolumeClaims.Items = test.claims
+			objs.PersistentVolumes.Items = []corev1.PersistentVolume{newPersistentVolume(test.policy, corev1.VolumeReleased, test.claimRef)}
+
+			var expected []checks.Diagnostic
+			if test.expected {
+				expected = append(expected, checks.Diagnostic{
+					Severity: checks.Warning,
+					Message:  "Persistent volume has the `Retain` reclaim policy and its claim `k8s/data` no longer exists. The underlying storage is kept, and billed, until the volume is deleted.",
+					Kind:     checks.PersistentVolume,
+					Object:   &objs.PersistentVolumes.Items[0].ObjectMeta,
+					Details:  "Phase: Released. Capacity: 10Gi. Storage class: do-block-storage. Volume handle: 0a1b2c3d",
+				})
+			}
+
+			d, err := (&retainedVolumeCheck{}).Run(objs)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, d)
+		})
+	}
+}
+
+func TestDefaultStorageClass(t *testing.T) {
+	defaultClass := func(name string, created time.Time) st.StorageClass {
+		return st.StorageClass{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Annotations:       map[string]string{"storageclass.kubernetes.io/is-default-class": "true"},
+			CreationTimestamp: metav1.NewTime(created),
+		}}
+	}
+	created := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		classes  []st.StorageClass
+		kind     checks.Kind
+		expected []string
+	}{
+		{
+			name:     "no storage classes",
+			expected: nil,
+		},
+		{
+			name:     "one default",
+			classes:  []st.StorageClass{defaultClass("do-block-storage", created), {ObjectMeta: metav1.ObjectMeta{Name: "other"}}},
+			expected: nil,
+		},
+		{
+			name:     "no default",
+			classes:  []st.StorageClass{{ObjectMeta: metav1.ObjectMeta{Name: "other"}}, {ObjectMeta: metav1.ObjectMeta{Name: "fast"}}},
+			kind:     checks.Cluster,
+			expected: []string{"No storage class is marked as the default. Persistent volume claims without a storage class are not provisioned."},
+		},
+		{
+			name:    "two defaults",
+			classes: []st.StorageClass{defaultClass("do-block-storage", created), defaultClass("fast", created.Add(time.Hour))},
+			kind:    checks.StorageClass,
+			expected: []string{
+				"Storage class is one of 2 default storage classes. Persistent volume claims without a storage class use the most recently created one, `fast`.",
+				"Storage class is one of 2 default storage classes. Persistent volume claims without a storage class use the most recently created one, `fast`.",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := initStorage()
+			objs.StorageClasses.Items = test.classes
+
+			d, err := (&defaultStorageClassCheck{}).Run(objs)
+			assert.NoError(t, err)
+			var messages []string
+			for _, diagnostic := range d {
+				assert.Equal(t, test.kind, diagnostic.Kind)
+				messages = append(messages, diagnostic.Message)
+			}
+			assert.Equal(t, test.expected, messages)
+		})
+	}
+}
+
+func initStorage() *kube.Objects {
+	class := st.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: "do-block-storage"},
+		Provisioner: "dobs.csi.digitalocean.com",
+	}
+	return &kube.Objects{
+		Pods:                   &corev1.PodList{},
+		PersistentVolumes:      &corev1.PersistentVolumeList{},
+		PersistentVolumeClaims: &corev1.PersistentVolumeClaimList{},
+		StorageClasses:         &st.StorageClassList{Items: []st.StorageClass{class}},
+		DefaultStorageClass:    &class,
+	}
+}
+
+func newPersistentVolume(policy corev1.PersistentVolumeReclaimPolicy, phase corev1.PersistentVolumePhase, claimRef *corev1.ObjectReference) corev1.PersistentVolume {
+	return corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc-0a1b2c3d"},
+		Spec: corev1.PersistentVolumeSpec{
+			Capacity:                      corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+			StorageClassName:              "do-block-storage",
+			PersistentVolumeReclaimPolicy: policy,
+			ClaimRef:                      claimRef,
+			PersistentVolumeSource: corev1.PersistentVolumeSource{CSI: &corev1.CSIPersistentVolumeSource{
+				Driver:       "dobs.csi.digitalocean.com",
+				VolumeHandle: "0a1b2c3d",
+			}},
+		},
+		Status: corev1.PersistentVolumeStatus{Phase: phase},
+	}
+}
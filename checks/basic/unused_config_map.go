@@ -17,12 +17,9 @@ limitations under the License.
 package basic
 
 import (
-	"sync"
-
 	"github.com/digitalocean/clusterlint/checks"
 	"github.com/digitalocean/clusterlint/kube"
-	"golang.org/x/sync/errgroup"
-	corev1 "k8s.io/api/core/v1"
+	"github.com/digitalocean/clusterlint/kube/references"
 )
 
 func init() {
@@ -47,28 +44,26 @@ func (c *unusedCMCheck) Description() string {
 	return "Checks if there are unused config maps in the cluster"
 }
 
+// Resources returns the resources whose objects this check reads.
+func (c *unusedCMCheck) Resources() []kube.Resource {
+	return append([]kube.Resource{kube.ConfigMaps}, references.ResourcesFor(references.ConfigMap)...)
+}
+
+// MetadataOnly returns the resources whose objects this check only reads the
+// metadata of.
+func (c *unusedCMCheck) MetadataOnly() []kube.Resource {
+	return []kube.Resource{kube.ConfigMaps}
+}
+
 // Run runs this check on a set of Kubernetes objects. It can return warnings
 // (low-priority problems) and errors (high-priority problems) as well as an
 // error value indicating that the check failed to run.
 func (c *unusedCMCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
 	var diagnostics []checks.Diagnostic
-
-	used, err := checkPodReferences(objects)
-	if err != nil {
-		return nil, err
-	}
-
-	nodeRefs, err := checkNodeReferences(objects)
-	if err != nil {
-		return nil, err
-	}
-
-	for k, v := range nodeRefs {
-		used[k] = v
-	}
+	graph := references.NewGraph(objects)
 
 	for _, cm := range objects.ConfigMaps.Items {
-		if _, ok := used[kube.Identifier{Name: cm.GetName(), Namespace: cm.GetNamespace()}]; !ok {
+		if !graph.IsReferenced(references.Ref{Kind: references.ConfigMap, Namespace: cm.GetNamespace(), Name: cm.GetName()}) {
 			cm := cm
 			d := checks.Diagnostic{
 				Severity: checks.Warning,
@@ -82,84 +77,3 @@ func (c *unusedCMCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error)
 	}
 	return diagnostics, nil
 }
-
-func checkNodeReferences(objects *kube.Objects) (map[kube.Identifier]struct{}, error) {
-	used := make(map[kube.Identifier]struct{})
-	var empty struct{}
-	var mu sync.Mutex
-	var g errgroup.Group
-	for _, node := range objects.Nodes.Items {
-		node := node
-		g.Go(func() error {
-			source := node.Spec.ConfigSource
-			if source != nil {
-				mu.Lock()
-				used[kube.Identifier{Name: source.ConfigMap.Name, Namespace: source.ConfigMap.Namespace}] = empty
-				mu.Unlock()
-			}
-			return nil
-		})
-	}
-	return used, g.Wait()
-}
-
-//checkPodReferences checks each pod for config map references in volumes and environment variables
-func checkPodReferences(objects *kube.Objects) (map[kube.Identifier]struct{}, error) {
-	used := make(map[kube.Identifier]struct{})
-	var empty struct{}
-	var mu sync.Mutex
-	var g errgroup.Group
-	for _, pod := range objects.Pods.Items {
-		pod := pod
-		namespace := pod.GetNamespace()
-		g.Go(func() error {
-			for _, volume := range pod.Spec.Volumes {
-				cm := volume.VolumeSource.ConfigMap
-				if cm != nil {
-					mu.Lock()
-					used[kube.Identifier{Name: cm.LocalObjectReference.Name, Namespace: namespace}] = empty
-					mu.Unlock()
-				}
-				if volume.VolumeSource.Projected != nil {
-					for _, source := range volume.VolumeSource.Projected.Sources {
-						cm := source.ConfigMap
-						if cm != nil {
-							mu.Lock()
-							used[kube.Identifier{Name: cm.LocalObjectReference.Name, Namespace: namespace}] = empty
-							mu.Unlock()
-						}
-					}
-				}
-			}
-			identifiers := checkEnvVars(pod.Spec.Containers, namespace)
-			identifiers = append(identifiers, checkEnvVars(pod.Spec.InitContainers, namespace)...)
-			mu.Lock()
-			for _, i := range identifiers {
-				used[i] = empty
-			}
-			mu.Unlock()
-
-			return nil
-		})
-	}
-
-	return used, g.Wait()
-}
-
-// checkEnvVars checks for config map references in container environment variables
-func checkEnvVars(containers []corev1.Container, namespace string) []kube.Identifier {
-	var refs []kube.Identifier
-	for _, container := range containers {
-		for _, env := range container.EnvFrom {
-			if env.ConfigMapRef != nil {
-				refs = append(refs, kube.Identifier{Name: env.ConfigMapRef.LocalObjectReference.Name, Namespace: namespace})
-			}
-		}
-		for _, env := range container.Env {
-			if env.ValueFrom != nil && env.ValueFrom.ConfigMapKeyRef != nil {
-				refs = append(refs, kube.Identifier{Name: env.ValueFrom.ConfigMapKeyRef.LocalObjectReference.Name, Namespace: namespace})
-			}
-		}
-	}
-	return refs
-}
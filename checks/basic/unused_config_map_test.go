@@ -22,6 +22,7 @@ import (
 	"github.com/digitalocean/clusterlint/checks"
 	"github.com/digitalocean/clusterlint/kube"
 	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -80,6 +81,16 @@ func TestUnusedConfigMapWarning(t *testing.T) {
 			objs:     nodeConfigSource(),
 			expected: nil,
 		},
+		{
+			name:     "stateful set references config map",
+			objs:     statefulSetConfigMap(),
+			expected: nil,
+		},
+		{
+			name:     "ephemeral container references config map",
+			objs:     ephemeralContainerConfigMap(),
+			expected: nil,
+		},
 		{
 			name: "unused config map",
 			objs: initConfigMap(),
@@ -226,3 +237,52 @@ func configMapEnvVarValueFromSource() *kube.Objects {
 	}
 	return objs
 }
+
+func statefulSetConfigMap() *kube.Objects {
+	objs := initConfigMap()
+	objs.StatefulSets = &appsv1.StatefulSetList{
+		Items: []appsv1.StatefulSet{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "statefulset_foo", Namespace: cmNamespace},
+				Spec: appsv1.StatefulSetSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Volumes: []corev1.Volume{
+								{
+									Name: "bar",
+									VolumeSource: corev1.VolumeSource{
+										ConfigMap: &corev1.ConfigMapVolumeSource{
+											LocalObjectReference: corev1.LocalObjectReference{Name: "cm_foo"},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	return objs
+}
+
+func ephemeralContainerConfigMap() *kube.Objects {
+	objs := initConfigMap()
+	objs.Pods.Items[0].Spec = corev1.PodSpec{
+		EphemeralContainers: []corev1.EphemeralContainer{
+			{
+				EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+					Name: "debugger",
+					EnvFrom: []corev1.EnvFromSource{
+						{
+							ConfigMapRef: &corev1.ConfigMapEnvSource{
+								LocalObjectReference: corev1.LocalObjectReference{Name: "cm_foo"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	return objs
+}
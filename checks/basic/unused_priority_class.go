@@ -0,0 +1,88 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package basic
+
+import (
+	"strings"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	"github.com/digitalocean/clusterlint/kube/references"
+)
+
+// systemPriorityClassPrefix is reserved for the priority classes created by
+// Kubernetes, such as system-cluster-critical.
+const systemPriorityClassPrefix = "system-"
+
+func init() {
+	checks.Register(&unusedPriorityClassCheck{})
+}
+
+type unusedPriorityClassCheck struct{}
+
+// Name returns a unique name for this check.
+func (u *unusedPriorityClassCheck) Name() string {
+	return "unused-priority-class"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (u *unusedPriorityClassCheck) Groups() []string {
+	return []string{"basic"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (u *unusedPriorityClassCheck) Description() string {
+	return "Checks if there are priority classes that are not used by any workload. Ignores the global default and built-in system priority classes"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (u *unusedPriorityClassCheck) Resources() []kube.Resource {
+	return append([]kube.Resource{kube.PriorityClasses}, references.ResourcesFor(references.PriorityClass)...)
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (u *unusedPriorityClassCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	// Priority classes may be used by workloads in namespaces that were
+	// filtered out, so they are only reported when all namespaces are checked.
+	if !objects.Filter.AllNamespaces() {
+		return nil, nil
+	}
+	graph := references.NewGraph(objects)
+
+	for _, pc := range objects.PriorityClasses.Items {
+		if pc.GlobalDefault || strings.HasPrefix(pc.Name, systemPriorityClassPrefix) {
+			continue
+		}
+		if graph.IsReferenced(references.Ref{Kind: references.PriorityClass, Name: pc.Name}) {
+			continue
+		}
+		pc := pc
+		d := checks.Diagnostic{
+			Severity: checks.Warning,
+			Message:  "Unused priority class",
+			Kind:     checks.PriorityClass,
+			Object:   &pc.ObjectMeta,
+			Owners:   pc.ObjectMeta.GetOwnerReferences(),
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics, nil
+}
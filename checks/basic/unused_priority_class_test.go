@@ -0,0 +1,123 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package basic
+
+import (
+	"testing"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUnusedPriorityClassCheckMeta(t *testing.T) {
+	unusedPriorityClassCheck := unusedPriorityClassCheck{}
+	assert.Equal(t, "unused-priority-class", unusedPriorityClassCheck.Name())
+	assert.Equal(t, []string{"basic"}, unusedPriorityClassCheck.Groups())
+	assert.NotEmpty(t, unusedPriorityClassCheck.Description())
+}
+
+func TestUnusedPriorityClassCheckRegistration(t *testing.T) {
+	unusedPriorityClassCheck := &unusedPriorityClassCheck{}
+	check, err := checks.Get("unused-priority-class")
+	assert.NoError(t, err)
+	assert.Equal(t, check, unusedPriorityClassCheck)
+}
+
+func TestUnusedPriorityClassWarning(t *testing.T) {
+	tests := []struct {
+		name          string
+		priorityClass schedulingv1.PriorityClass
+		objs          func(*kube.Objects)
+		expected      bool
+	}{
+		{
+			name:          "used by pod",
+			priorityClass: schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: "high"}},
+			objs: func(objs *kube.Objects) {
+				objs.Pods.Items[0].Spec.PriorityClassName = "high"
+			},
+			expected: false,
+		},
+		{
+			name:          "used by daemon set",
+			priorityClass: schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: "high"}},
+			objs: func(objs *kube.Objects) {
+				objs.DaemonSets = &appsv1.DaemonSetList{
+					Items: []appsv1.DaemonSet{{
+						ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "k8s"},
+						Spec: appsv1.DaemonSetSpec{
+							Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{PriorityClassName: "high"}},
+						},
+					}},
+				}
+			},
+			expected: false,
+		},
+		{
+			name:          "global default",
+			priorityClass: schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: "high"}, GlobalDefault: true},
+			expected:      false,
+		},
+		{
+			name:          "system priority class",
+			priorityClass: schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: "system-node-critical"}},
+			expected:      false,
+		},
+		{
+			name:          "filtered namespaces",
+			priorityClass: schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: "high"}},
+			objs: func(objs *kube.Objects) {
+				objs.Filter = kube.ObjectFilter{ExcludeNamespace: "app"}
+			},
+			expected: false,
+		},
+		{
+			name:          "unused",
+			priorityClass: schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: "high"}},
+			expected:      true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := initPod()
+			objs.PriorityClasses = &schedulingv1.PriorityClassList{Items: []schedulingv1.PriorityClass{test.priorityClass}}
+			if test.objs != nil {
+				test.objs(objs)
+			}
+
+			var expected []checks.Diagnostic
+			if test.expected {
+				expected = append(expected, checks.Diagnostic{
+					Severity: checks.Warning,
+					Message:  "Unused priority class",
+					Kind:     checks.PriorityClass,
+					Object:   &objs.PriorityClasses.Items[0].ObjectMeta,
+				})
+			}
+
+			d, err := (&unusedPriorityClassCheck{}).Run(objs)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, d)
+		})
+	}
+}
@@ -45,6 +45,11 @@ func (p *unusedPVCheck) Description() string {
 	return "Check if there are unused persistent volumes in the cluster"
 }
 
+// Resources returns the resources whose objects this check reads.
+func (p *unusedPVCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.PersistentVolumes}
+}
+
 // Run runs this check on a set of Kubernetes objects. It can return warnings
 // (low-priority problems) and errors (high-priority problems) as well as an
 // error value indicating that the check failed to run.
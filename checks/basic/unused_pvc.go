@@ -43,6 +43,11 @@ func (c *unusedClaimCheck) Description() string {
 	return "Check if there are unused persistent volume claims in the cluster"
 }
 
+// Resources returns the resources whose objects this check reads.
+func (c *unusedClaimCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.PersistentVolumeClaims, kube.Pods}
+}
+
 // Run runs this check on a set of Kubernetes objects. It can return warnings
 // (low-priority problems) and errors (high-priority problems) as well as an
 // error value indicating that the check failed to run.
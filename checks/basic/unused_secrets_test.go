@@ -22,7 +22,10 @@ import (
 	"github.com/digitalocean/clusterlint/checks"
 	"github.com/digitalocean/clusterlint/kube"
 	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -50,7 +53,7 @@ func TestUnusedSecretWarning(t *testing.T) {
 	}{
 		{
 			name:     "no secrets",
-			objs:     &kube.Objects{Pods: &corev1.PodList{}, Secrets: &corev1.SecretList{}, ServiceAccounts: &corev1.ServiceAccountList{}},
+			objs:     &kube.Objects{Pods: &corev1.PodList{}, Secrets: &corev1.SecretList{}, ServiceAccounts: &corev1.ServiceAccountList{}, Ingresses: &networkingv1.IngressList{}},
 			expected: nil,
 		},
 		{
@@ -98,6 +101,36 @@ func TestUnusedSecretWarning(t *testing.T) {
 			objs:     secretProjection(),
 			expected: nil,
 		},
+		{
+			name:     "ingress tls references secret",
+			objs:     ingressTLS(),
+			expected: nil,
+		},
+		{
+			name:     "csi volume references node publish secret",
+			objs:     csiNodePublishSecret(),
+			expected: nil,
+		},
+		{
+			name:     "persistent volume references csi secret",
+			objs:     persistentVolumeCSISecret(),
+			expected: nil,
+		},
+		{
+			name:     "pod template references secret",
+			objs:     podTemplateSecret(),
+			expected: nil,
+		},
+		{
+			name:     "cron job references secret",
+			objs:     cronJobSecret(),
+			expected: nil,
+		},
+		{
+			name:     "deployment references secret",
+			objs:     deploymentSecret(),
+			expected: nil,
+		},
 		{
 			name: "unused secret",
 			objs: initSecret(),
@@ -148,6 +181,7 @@ func initSecret() *kube.Objects {
 				},
 			},
 		},
+		Ingresses: &networkingv1.IngressList{},
 	}
 	return objs
 }
@@ -309,3 +343,119 @@ func saSecretRefs() *kube.Objects {
 	}
 	return objs
 }
+
+func ingressTLS() *kube.Objects {
+	objs := initSecret()
+	objs.Ingresses.Items = []networkingv1.Ingress{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "ingress_foo", Namespace: "k8s"},
+			Spec: networkingv1.IngressSpec{
+				TLS: []networkingv1.IngressTLS{
+					{
+						Hosts:      []string{"example.com"},
+						SecretName: "secret_foo",
+					},
+				},
+			},
+		},
+	}
+	return objs
+}
+
+func csiNodePublishSecret() *kube.Objects {
+	objs := initSecret()
+	objs.Pods.Items[0].Spec = corev1.PodSpec{
+		Volumes: []corev1.Volume{
+			{
+				Name: "bar",
+				VolumeSource: corev1.VolumeSource{
+					CSI: &corev1.CSIVolumeSource{
+						Driver:               "secrets-store.csi.k8s.io",
+						NodePublishSecretRef: &corev1.LocalObjectReference{Name: "secret_foo"},
+					},
+				},
+			},
+		},
+	}
+	return objs
+}
+
+func persistentVolumeCSISecret() *kube.Objects {
+	objs := initSecret()
+	objs.PersistentVolumes = &corev1.PersistentVolumeList{
+		Items: []corev1.PersistentVolume{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "pv_foo"},
+				Spec: corev1.PersistentVolumeSpec{
+					PersistentVolumeSource: corev1.PersistentVolumeSource{
+						CSI: &corev1.CSIPersistentVolumeSource{
+							Driver:             "dobs.csi.digitalocean.com",
+							NodeStageSecretRef: &corev1.SecretReference{Name: "secret_foo", Namespace: "k8s"},
+						},
+					},
+				},
+			},
+		},
+	}
+	return objs
+}
+
+func podTemplateSecret() *kube.Objects {
+	objs := initSecret()
+	objs.PodTemplates = &corev1.PodTemplateList{
+		Items: []corev1.PodTemplate{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "template_foo", Namespace: "k8s"},
+				Template:   corev1.PodTemplateSpec{Spec: secretEnvPodSpec()},
+			},
+		},
+	}
+	return objs
+}
+
+func cronJobSecret() *kube.Objects {
+	objs := initSecret()
+	objs.CronJobs = &batchv1.CronJobList{
+		Items: []batchv1.CronJob{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "cronjob_foo", Namespace: "k8s"},
+				Spec: batchv1.CronJobSpec{
+					JobTemplate: batchv1.JobTemplateSpec{
+						Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{Spec: secretEnvPodSpec()}},
+					},
+				},
+			},
+		},
+	}
+	return objs
+}
+
+func deploymentSecret() *kube.Objects {
+	objs := initSecret()
+	objs.Deployments = &appsv1.DeploymentList{
+		Items: []appsv1.Deployment{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "deployment_foo", Namespace: "k8s"},
+				Spec:       appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: secretEnvPodSpec()}},
+			},
+		},
+	}
+	return objs
+}
+
+func secretEnvPodSpec() corev1.PodSpec {
+	return corev1.PodSpec{
+		Containers: []corev1.Container{
+			{
+				Name: "test-container",
+				EnvFrom: []corev1.EnvFromSource{
+					{
+						SecretRef: &corev1.SecretEnvSource{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "secret_foo"},
+						},
+					},
+				},
+			},
+		},
+	}
+}
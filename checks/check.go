@@ -17,10 +17,13 @@ limitations under the License.
 package checks
 
 import (
+	"context"
+	"runtime/debug"
 	"strings"
 
 	"github.com/digitalocean/clusterlint/kube"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 const checkAnnotation = "clusterlint.digitalocean.com/disabled-checks"
@@ -43,6 +46,152 @@ type Check interface {
 	Run(*kube.Objects) ([]Diagnostic, error)
 }
 
+// ResourceDeclarer is implemented by checks that declare the resources they
+// read. Checks that don't implement it are assumed to read all resources.
+type ResourceDeclarer interface {
+	// Resources returns the resources whose objects the check reads.
+	Resources() []kube.Resource
+}
+
+// UnstructuredDeclarer is implemented by checks that read objects of
+// resources that have no field in kube.Objects, such as custom resources.
+// These objects are fetched into kube.Objects.Unstructured.
+type UnstructuredDeclarer interface {
+	// UnstructuredResources returns the resources whose objects the check
+	// reads from kube.Objects.Unstructured.
+	UnstructuredResources() []schema.GroupVersionResource
+}
+
+// Resources returns the resources a check reads, including the resources it
+// reads as unstructured objects.
+func Resources(check Check) []kube.Resource {
+	resources := kube.AllResources()
+	if d, ok := check.(ResourceDeclarer); ok {
+		resources = d.Resources()
+	}
+	for _, gvr := range unstructuredResources(check) {
+		resources = append(resources, kube.Resource{Group: gvr.Group, Resource: gvr.Resource})
+	}
+	return resources
+}
+
+// unstructuredResources returns the resources a check reads as unstructured
+// objects.
+func unstructuredResources(check Check) []schema.GroupVersionResource {
+	if d, ok := check.(UnstructuredDeclarer); ok {
+		return d.UnstructuredResources()
+	}
+	return nil
+}
+
+// MetadataDeclarer is implemented by checks that only read the metadata of
+// the objects of some resources. Unless other checks read these objects in
+// full, they may be fetched with only their metadata set.
+type MetadataDeclarer interface {
+	// MetadataOnly returns the resources whose objects the check only reads
+	// the metadata of.
+	MetadataOnly() []kube.Resource
+}
+
+// metadataOnly returns the resources that none of the checks read more than
+// the metadata of.
+func metadataOnly(all []Check) []kube.Resource {
+	counts := make(map[kube.Resource]int)
+	for _, check := range all {
+		if d, ok := check.(MetadataDeclarer); ok {
+			for _, resource := range d.MetadataOnly() {
+				counts[resource]++
+			}
+		}
+	}
+	var resources []kube.Resource
+	for resource, count := range counts {
+		readers := 0
+		for _, check := range all {
+			if containsResource(Resources(check), resource) {
+				readers++
+			}
+		}
+		if count == readers {
+			resources = append(resources, resource)
+		}
+	}
+	return resources
+}
+
+func containsResource(resources []kube.Resource, resource kube.Resource) bool {
+	for _, r := range resources {
+		if r == resource {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckWithContext is a check that stops running when its context is
+// cancelled, for example because the check timed out. Checks implementing it
+// are run with RunWithContext instead of Run.
+type CheckWithContext interface {
+	Check
+	// RunWithContext runs this check on a set of Kubernetes objects like Run.
+	// It should return promptly with the context's error once the context is
+	// done.
+	RunWithContext(context.Context, *kube.Objects) ([]Diagnostic, error)
+}
+
+// WithContext returns a context-aware version of a check. Checks that only
+// implement Run keep running in the background after the context is done,
+// but their result is discarded.
+func WithContext(check Check) CheckWithContext {
+	if c, ok := check.(CheckWithContext); ok {
+		return c
+	}
+	return contextAdapter{check}
+}
+
+type contextAdapter struct {
+	Check
+}
+
+type checkOutput struct {
+	diagnostics []Diagnostic
+	err         error
+}
+
+// checkPanic carries a panic of a check, along with the stack of the goroutine
+// that panicked, to the goroutine running the check.
+type checkPanic struct {
+	value interface{}
+	stack []byte
+}
+
+// RunWithContext runs the check's Run in a goroutine and returns early if the
+// context is done. Panics are propagated to the caller.
+func (c contextAdapter) RunWithContext(ctx context.Context, objects *kube.Objects) ([]Diagnostic, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	done := make(chan checkOutput, 1)
+	panicked := make(chan checkPanic, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				panicked <- checkPanic{value: r, stack: debug.Stack()}
+			}
+		}()
+		d, err := c.Run(objects)
+		done <- checkOutput{diagnostics: d, err: err}
+	}()
+	select {
+	case output := <-done:
+		return output.diagnostics, output.err
+	case r := <-panicked:
+		panic(r)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
 // IsEnabled inspects the object annotations to see if a check is disabled
 func IsEnabled(name string, item *metav1.ObjectMeta) bool {
 	annotations := item.GetAnnotations()
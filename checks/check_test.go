@@ -17,13 +17,16 @@ limitations under the License.
 package checks
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
+	"github.com/digitalocean/clusterlint/kube"
 	"github.com/stretchr/testify/assert"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 func TestCheckIsDisabled(t *testing.T) {
@@ -47,6 +50,25 @@ func TestNoClusterlintAnnotation(t *testing.T) {
 	assert.True(t, IsEnabled("pod_foo", &pod.ObjectMeta))
 }
 
+func TestWithContext(t *testing.T) {
+	check := &contextCheck{}
+	assert.Same(t, check, WithContext(check))
+
+	d, err := WithContext(&alwaysFail{}).RunWithContext(context.Background(), &kube.Objects{})
+	assert.NoError(t, err)
+	assert.Len(t, d, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d, err = WithContext(&alwaysFail{}).RunWithContext(ctx, &kube.Objects{})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, d)
+
+	assert.Panics(t, func() {
+		WithContext(&panicCheck{}).RunWithContext(context.Background(), &kube.Objects{})
+	})
+}
+
 func initPod(name string) *corev1.Pod {
 	pod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -55,3 +77,49 @@ func initPod(name string) *corev1.Pod {
 	}
 	return pod
 }
+
+func TestResources(t *testing.T) {
+	assert.Equal(t, []kube.Resource{kube.Pods}, Resources(&alwaysFail{}))
+	assert.Equal(t, kube.AllResources(), Resources(&errorCheck{}))
+}
+
+type metadataCheck struct {
+	alwaysFail
+	resources []kube.Resource
+	metadata  []kube.Resource
+}
+
+func (m *metadataCheck) Resources() []kube.Resource {
+	return m.resources
+}
+
+func (m *metadataCheck) MetadataOnly() []kube.Resource {
+	return m.metadata
+}
+
+func TestMetadataOnly(t *testing.T) {
+	configMaps := &metadataCheck{
+		resources: []kube.Resource{kube.ConfigMaps, kube.Pods},
+		metadata:  []kube.Resource{kube.ConfigMaps, kube.Pods},
+	}
+	secrets := &metadataCheck{
+		resources: []kube.Resource{kube.Secrets},
+		metadata:  []kube.Resource{kube.Secrets},
+	}
+
+	assert.ElementsMatch(t, []kube.Resource{kube.ConfigMaps, kube.Pods, kube.Secrets}, metadataOnly([]Check{configMaps, secrets}))
+	assert.ElementsMatch(t, []kube.Resource{kube.ConfigMaps, kube.Secrets}, metadataOnly([]Check{configMaps, secrets, &alwaysFail{}}))
+	assert.Empty(t, metadataOnly([]Check{configMaps, &errorCheck{}}))
+}
+
+type unstructuredCheck struct {
+	alwaysFail
+}
+
+func (u *unstructuredCheck) UnstructuredResources() []schema.GroupVersionResource {
+	return []schema.GroupVersionResource{{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}}
+}
+
+func TestResourcesUnstructured(t *testing.T) {
+	assert.Equal(t, []kube.Resource{kube.Pods, {Group: "cert-manager.io", Resource: "certificates"}}, Resources(&unstructuredCheck{}))
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checks
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Configurable is implemented by checks whose behavior can be tuned, for
+// example by changing thresholds.
+type Configurable interface {
+	// Configure applies settings to the check. It returns an error if a
+	// setting is unknown or has an invalid value.
+	Configure(settings map[string]string) error
+}
+
+// Configure parses settings of the form `check-name.key=value` and applies
+// them to the registered checks.
+func Configure(settings []string) error {
+	byCheck := make(map[string]map[string]string)
+	for _, setting := range settings {
+		kv := strings.SplitN(setting, "=", 2)
+		name := strings.SplitN(kv[0], ".", 2)
+		if len(kv) != 2 || len(name) != 2 {
+			return fmt.Errorf("invalid setting %q: expected check-name.key=value", setting)
+		}
+		if byCheck[name[0]] == nil {
+			byCheck[name[0]] = make(map[string]string)
+		}
+		byCheck[name[0]][name[1]] = kv[1]
+	}
+
+	for name, s := range byCheck {
+		check, err := Get(name)
+		if err != nil {
+			return err
+		}
+		c, ok := check.(Configurable)
+		if !ok {
+			return fmt.Errorf("check %q has no settings", name)
+		}
+		if err := c.Configure(s); err != nil {
+			return fmt.Errorf("failed to configure check %q: %s", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checks
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/digitalocean/clusterlint/kube"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigure(t *testing.T) {
+	check := &configurableCheck{}
+	Register(check)
+	Register(&alwaysFail{})
+
+	tests := []struct {
+		name        string
+		settings    []string
+		expectedErr string
+		expected    map[string]string
+	}{
+		{
+			name:     "no settings",
+			settings: nil,
+		},
+		{
+			name:     "valid settings",
+			settings: []string{"configurable-check.foo=1", "configurable-check.bar=a=b"},
+			expected: map[string]string{"foo": "1", "bar": "a=b"},
+		},
+		{
+			name:        "missing value",
+			settings:    []string{"configurable-check.foo"},
+			expectedErr: `invalid setting "configurable-check.foo": expected check-name.key=value`,
+		},
+		{
+			name:        "missing key",
+			settings:    []string{"configurable-check=1"},
+			expectedErr: `invalid setting "configurable-check=1": expected check-name.key=value`,
+		},
+		{
+			name:        "unknown check",
+			settings:    []string{"no-such-check.foo=1"},
+			expectedErr: "Check not found: no-such-check",
+		},
+		{
+			name:        "check without settings",
+			settings:    []string{"always-fail.foo=1"},
+			expectedErr: `check "always-fail" has no settings`,
+		},
+		{
+			name:        "rejected setting",
+			settings:    []string{"configurable-check.invalid=1"},
+			expectedErr: `failed to configure check "configurable-check": invalid setting`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			check.settings = nil
+			err := Configure(test.settings)
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, check.settings)
+		})
+	}
+}
+
+type configurableCheck struct {
+	settings map[string]string
+}
+
+func (c *configurableCheck) Name() string {
+	return "configurable-check"
+}
+
+func (c *configurableCheck) Groups() []string {
+	return nil
+}
+
+func (c *configurableCheck) Description() string {
+	return "Does not check anything. Records its settings."
+}
+
+func (c *configurableCheck) Run(*kube.Objects) ([]Diagnostic, error) {
+	return nil, nil
+}
+
+func (c *configurableCheck) Configure(settings map[string]string) error {
+	if _, ok := settings["invalid"]; ok {
+		return errors.New("invalid setting")
+	}
+	c.settings = settings
+	return nil
+}
@@ -47,6 +47,11 @@ func (l *domainNameCheck) Description() string {
 	return "Checks if there are pods with container images that are hosted at the docker.pkg.github.com registry"
 }
 
+// Resources returns the resources whose objects this check reads.
+func (l *domainNameCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Pods}
+}
+
 // Run runs this check on a set of Kubernetes objects. It can return errors
 // (low-priority problems) and errors (high-priority problems) as well as an
 // error value indicating that the check failed to run.
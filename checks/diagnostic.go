@@ -34,6 +34,9 @@ type Diagnostic struct {
 }
 
 func (d Diagnostic) String() string {
+	if d.Kind == Cluster {
+		return fmt.Sprintf("[%s] %s: %s", d.Severity, d.Kind, d.Message)
+	}
 	return fmt.Sprintf("[%s] %s/%s/%s: %s", d.Severity, d.Object.Namespace,
 		d.Kind, d.Object.Name, d.Message)
 }
@@ -56,6 +59,9 @@ const (
 	Warning Severity = "warning"
 	// Suggestion means that a user need not implement it, but is in line with the recommended best practices
 	Suggestion Severity = "suggestion"
+	// Cluster identifies diagnostics about the cluster as a whole rather than
+	// a single object. Their Object has no name or namespace.
+	Cluster Kind = "cluster"
 	// Pod identifies Kubernetes objects of kind `pod`
 	Pod Kind = "pod"
 	// PodTemplate identifies Kubernetes objects of kind `pod template`
@@ -72,6 +78,10 @@ const (
 	ServiceAccount Kind = "service account"
 	// PersistentVolume identifies Kubernetes objects of kind `persistent volume`
 	PersistentVolume Kind = "persistent volume"
+	// StorageClass identifies Kubernetes objects of kind `storage class`
+	StorageClass Kind = "storage class"
+	// VolumeAttachment identifies Kubernetes objects of kind `volume attachment`
+	VolumeAttachment Kind = "volume attachment"
 	// ValidatingWebhookConfiguration identifies Kubernetes objects of kind `validating webhook configuration`
 	ValidatingWebhookConfiguration Kind = "validating webhook configuration"
 	// MutatingWebhookConfiguration identifies Kubernetes objects of kind `mutating webhook configuration`
@@ -84,4 +94,40 @@ const (
 	VolumeSnapshotContent Kind = "volume snapshot content"
 	// CronJob identifies Kubernetes objects of kind `cron job`
 	CronJob Kind = "cron job"
+	// ResourceQuota identifies Kubernetes objects of kind `resource quota`
+	ResourceQuota Kind = "resource quota"
+	// LimitRange identifies Kubernetes objects of kind `limit range`
+	LimitRange Kind = "limit range"
+	// Deployment identifies Kubernetes objects of kind `deployment`
+	Deployment Kind = "deployment"
+	// ReplicaSet identifies Kubernetes objects of kind `replica set`
+	ReplicaSet Kind = "replica set"
+	// StatefulSet identifies Kubernetes objects of kind `stateful set`
+	StatefulSet Kind = "stateful set"
+	// DaemonSet identifies Kubernetes objects of kind `daemon set`
+	DaemonSet Kind = "daemon set"
+	// Job identifies Kubernetes objects of kind `job`
+	Job Kind = "job"
+	// ReplicationController identifies Kubernetes objects of kind `replication controller`
+	ReplicationController Kind = "replication controller"
+	// Role identifies Kubernetes objects of kind `role`
+	Role Kind = "role"
+	// ClusterRole identifies Kubernetes objects of kind `cluster role`
+	ClusterRole Kind = "cluster role"
+	// RoleBinding identifies Kubernetes objects of kind `role binding`
+	RoleBinding Kind = "role binding"
+	// ClusterRoleBinding identifies Kubernetes objects of kind `cluster role binding`
+	ClusterRoleBinding Kind = "cluster role binding"
+	// Namespace identifies Kubernetes objects of kind `namespace`
+	Namespace Kind = "namespace"
+	// NetworkPolicy identifies Kubernetes objects of kind `network policy`
+	NetworkPolicy Kind = "network policy"
+	// Ingress identifies Kubernetes objects of kind `ingress`
+	Ingress Kind = "ingress"
+	// HTTPRoute identifies Gateway API objects of kind `http route`
+	HTTPRoute Kind = "http route"
+	// PriorityClass identifies Kubernetes objects of kind `priority class`
+	PriorityClass Kind = "priority class"
+	// HorizontalPodAutoscaler identifies Kubernetes objects of kind `horizontal pod autoscaler`
+	HorizontalPodAutoscaler Kind = "horizontal pod autoscaler"
 )
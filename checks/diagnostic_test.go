@@ -0,0 +1,42 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDiagnosticString(t *testing.T) {
+	pod := Diagnostic{
+		Severity: Warning,
+		Message:  "Unhealthy pod",
+		Kind:     Pod,
+		Object:   &metav1.ObjectMeta{Name: "web", Namespace: "k8s"},
+	}
+	assert.Equal(t, "[warning] k8s/pod/web: Unhealthy pod", pod.String())
+
+	cluster := Diagnostic{
+		Severity: Warning,
+		Message:  "No storage class is marked as the default",
+		Kind:     Cluster,
+		Object:   &metav1.ObjectMeta{},
+	}
+	assert.Equal(t, "[warning] cluster: No storage class is marked as the default", cluster.String())
+}
@@ -47,6 +47,11 @@ func (w *webhookReplacementCheck) Description() string {
 	return "Check for admission control webhooks that could cause problems during upgrades or node replacement"
 }
 
+// Resources returns the resources whose objects this check reads.
+func (w *webhookReplacementCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.MutatingWebhookConfigurations, kube.Namespaces, kube.Nodes, kube.ValidatingWebhookConfigurations}
+}
+
 // Run runs this check on a set of Kubernetes objects.
 func (w *webhookReplacementCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
 	const apiserverServiceName = "kubernetes"
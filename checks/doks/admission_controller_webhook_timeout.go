@@ -43,6 +43,11 @@ func (w *webhookTimeoutCheck) Description() string {
 	return "Check for admission control webhooks that have exceeded a timeout of 30 seconds."
 }
 
+// Resources returns the resources whose objects this check reads.
+func (w *webhookTimeoutCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.MutatingWebhookConfigurations, kube.ValidatingWebhookConfigurations}
+}
+
 // Run runs this check on a set of Kubernetes objects.
 func (w *webhookTimeoutCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
 	var diagnostics []checks.Diagnostic
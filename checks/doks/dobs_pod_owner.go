@@ -52,6 +52,11 @@ func (p *dobsPodOwner) Description() string {
 	return "Checks if pods referencing dobs volumes are owned by a stateful set."
 }
 
+// Resources returns the resources whose objects this check reads.
+func (p *dobsPodOwner) Resources() []kube.Resource {
+	return []kube.Resource{kube.PersistentVolumeClaims, kube.Pods, kube.StorageClasses}
+}
+
 // Run runs this check on a set of Kubernetes objects. It can return warnings
 // (low-priority problems) and errors (high-priority problems) as well as an
 // error value indicating that the check failed to run.
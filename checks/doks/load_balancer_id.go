@@ -0,0 +1,87 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package doks
+
+import (
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// loadBalancerIDAnnotation is set by the DigitalOcean cloud controller
+// manager to tie a service to the load balancer it created.
+const loadBalancerIDAnnotation = "kubernetes.digitalocean.com/load-balancer-id"
+
+func init() {
+	checks.Register(&loadBalancerIDCheck{})
+}
+
+type loadBalancerIDCheck struct{}
+
+// Name returns the name of the check.
+func (*loadBalancerIDCheck) Name() string {
+	return "load-balancer-id"
+}
+
+// Groups returns groups for this check.
+func (*loadBalancerIDCheck) Groups() []string {
+	return []string{"doks"}
+}
+
+// Description returns a description of the check.
+func (*loadBalancerIDCheck) Description() string {
+	return "Checks that provisioned LoadBalancer services carry the DigitalOcean load balancer ID annotation."
+}
+
+// Resources returns the resources the check reads.
+func (*loadBalancerIDCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Services}
+}
+
+// Run runs the check.
+func (c *loadBalancerIDCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	for _, service := range objects.Services.Items {
+		if service.Spec.Type != corev1.ServiceTypeLoadBalancer {
+			continue
+		}
+		// Services with a load balancer class are handled by another
+		// controller.
+		if service.Spec.LoadBalancerClass != nil {
+			continue
+		}
+		// The annotation is added once the load balancer is provisioned.
+		if len(service.Status.LoadBalancer.Ingress) == 0 {
+			continue
+		}
+		if service.Annotations[loadBalancerIDAnnotation] != "" {
+			continue
+		}
+		service := service
+		d := checks.Diagnostic{
+			Severity: checks.Warning,
+			Message:  "Load balancer ID annotation is missing. The service may be assigned a new load balancer and IP address when the cluster is upgraded.",
+			Kind:     checks.Service,
+			Object:   &service.ObjectMeta,
+			Owners:   service.ObjectMeta.GetOwnerReferences(),
+			Details:  "Missing annotation: " + loadBalancerIDAnnotation,
+		}
+		diagnostics = append(diagnostics, d)
+	}
+
+	return diagnostics, nil
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package doks
+
+import (
+	"testing"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLoadBalancerID(t *testing.T) {
+	provisioned := corev1.ServiceStatus{
+		LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{IP: "203.0.113.10"}}},
+	}
+	otherClass := "example.com/lb"
+
+	tests := []struct {
+		name        string
+		serviceType corev1.ServiceType
+		annotations map[string]string
+		class       *string
+		status      corev1.ServiceStatus
+		expected    bool
+	}{
+		{
+			name:        "cluster IP service",
+			serviceType: corev1.ServiceTypeClusterIP,
+			status:      provisioned,
+			expected:    false,
+		},
+		{
+			name:        "annotated load balancer",
+			serviceType: corev1.ServiceTypeLoadBalancer,
+			annotations: map[string]string{loadBalancerIDAnnotation: "9a3f2b4c-0000-0000-0000-000000000000"},
+			status:      provisioned,
+			expected:    false,
+		},
+		{
+			name:        "load balancer being provisioned",
+			serviceType: corev1.ServiceTypeLoadBalancer,
+			expected:    false,
+		},
+		{
+			name:        "load balancer of another class",
+			serviceType: corev1.ServiceTypeLoadBalancer,
+			class:       &otherClass,
+			status:      provisioned,
+			expected:    false,
+		},
+		{
+			name:        "missing annotation",
+			serviceType: corev1.ServiceTypeLoadBalancer,
+			status:      provisioned,
+			expected:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objects := &kube.Objects{
+				Services: &corev1.ServiceList{
+					Items: []corev1.Service{{
+						ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "k8s", Annotations: test.annotations},
+						Spec:       corev1.ServiceSpec{Type: test.serviceType, LoadBalancerClass: test.class},
+						Status:     test.status,
+					}},
+				},
+			}
+
+			var expected []checks.Diagnostic
+			if test.expected {
+				expected = append(expected, checks.Diagnostic{
+					Severity: checks.Warning,
+					Message:  "Load balancer ID annotation is missing. The service may be assigned a new load balancer and IP address when the cluster is upgraded.",
+					Kind:     checks.Service,
+					Object:   &objects.Services.Items[0].ObjectMeta,
+					Details:  "Missing annotation: kubernetes.digitalocean.com/load-balancer-id",
+				})
+			}
+
+			check := &loadBalancerIDCheck{}
+			d, err := check.Run(objects)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, d)
+		})
+	}
+}
@@ -47,6 +47,11 @@ func (*nodeLabelsTaintsCheck) Description() string {
 	return "Checks that nodes do not have custom labels or taints configured."
 }
 
+// Resources returns the resources the check reads.
+func (*nodeLabelsTaintsCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Nodes}
+}
+
 // Run runs the check.
 func (c *nodeLabelsTaintsCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
 	var diagnostics []checks.Diagnostic
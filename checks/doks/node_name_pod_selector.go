@@ -44,6 +44,11 @@ func (p *podSelectorCheck) Description() string {
 	return "Checks if there are pods which use kubernetes.io/hostname label in the node selector."
 }
 
+// Resources returns the resources whose objects this check reads.
+func (p *podSelectorCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Pods}
+}
+
 // Run runs this check on a set of Kubernetes objects. It can return warnings
 // (low-priority problems) and errors (high-priority problems) as well as an
 // error value indicating that the check failed to run.
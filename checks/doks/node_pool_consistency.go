@@ -0,0 +1,133 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package doks
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// nodePoolLabel is the label DOKS sets to the name of a node's node pool.
+const nodePoolLabel = "doks.digitalocean.com/node-pool"
+
+func init() {
+	checks.Register(&nodePoolConsistencyCheck{})
+}
+
+type nodePoolConsistencyCheck struct{}
+
+// Name returns the name of the check.
+func (*nodePoolConsistencyCheck) Name() string {
+	return "node-pool-consistency"
+}
+
+// Groups returns groups for this check.
+func (*nodePoolConsistencyCheck) Groups() []string {
+	return []string{"doks", "node-health"}
+}
+
+// Description returns a description of the check.
+func (*nodePoolConsistencyCheck) Description() string {
+	return "Checks that all nodes of a node pool run the same container runtime and OS image."
+}
+
+// Resources returns the resources the check reads.
+func (*nodePoolConsistencyCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Nodes}
+}
+
+// Run runs the check.
+func (c *nodePoolConsistencyCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var pools []string
+	nodes := make(map[string][]*corev1.Node)
+	for i := range objects.Nodes.Items {
+		node := &objects.Nodes.Items[i]
+		pool, ok := node.Labels[nodePoolLabel]
+		if !ok {
+			continue
+		}
+		if _, ok := nodes[pool]; !ok {
+			pools = append(pools, pool)
+		}
+		nodes[pool] = append(nodes[pool], node)
+	}
+
+	var diagnostics []checks.Diagnostic
+	for _, pool := range pools {
+		diagnostics = append(diagnostics, inconsistentNodes(pool, nodes[pool], "container runtime", func(node *corev1.Node) string {
+			return node.Status.NodeInfo.ContainerRuntimeVersion
+		})...)
+		diagnostics = append(diagnostics, inconsistentNodes(pool, nodes[pool], "OS image", func(node *corev1.Node) string {
+			return node.Status.NodeInfo.OSImage
+		})...)
+	}
+	return diagnostics, nil
+}
+
+// inconsistentNodes reports the nodes of a node pool whose property differs
+// from the one most nodes of the pool have.
+func inconsistentNodes(pool string, nodes []*corev1.Node, property string, value func(*corev1.Node) string) []checks.Diagnostic {
+	counts := make(map[string]int)
+	for _, node := range nodes {
+		if v := value(node); v != "" {
+			counts[v]++
+		}
+	}
+	if len(counts) < 2 {
+		return nil
+	}
+
+	values := make([]string, 0, len(counts))
+	for v := range counts {
+		values = append(values, v)
+	}
+	// Sort by the number of nodes, so that the most common value comes
+	// first, and by value for stable output.
+	sort.Slice(values, func(i, j int) bool {
+		if counts[values[i]] != counts[values[j]] {
+			return counts[values[i]] > counts[values[j]]
+		}
+		return values[i] < values[j]
+	})
+	common := values[0]
+	var summary []string
+	for _, v := range values {
+		summary = append(summary, fmt.Sprintf("%s (%d nodes)", v, counts[v]))
+	}
+
+	var diagnostics []checks.Diagnostic
+	for _, node := range nodes {
+		v := value(node)
+		if v == "" || v == common {
+			continue
+		}
+		d := checks.Diagnostic{
+			Severity: checks.Warning,
+			Message:  fmt.Sprintf("Node runs %s `%s`, while most nodes of node pool `%s` run `%s`. Nodes of a node pool should be identical.", property, v, pool, common),
+			Kind:     checks.Node,
+			Object:   &node.ObjectMeta,
+			Details:  fmt.Sprintf("%ss in node pool: %s", strings.ToUpper(property[:1])+property[1:], strings.Join(summary, ", ")),
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics
+}
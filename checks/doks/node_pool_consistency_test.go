@@ -0,0 +1,130 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package doks
+
+import (
+	"testing"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNodePoolConsistencyCheckMeta(t *testing.T) {
+	nodePoolConsistencyCheck := nodePoolConsistencyCheck{}
+	assert.Equal(t, "node-pool-consistency", nodePoolConsistencyCheck.Name())
+	assert.Equal(t, []string{"doks", "node-health"}, nodePoolConsistencyCheck.Groups())
+	assert.NotEmpty(t, nodePoolConsistencyCheck.Description())
+}
+
+func TestNodePoolConsistencyCheckRegistration(t *testing.T) {
+	nodePoolConsistencyCheck := &nodePoolConsistencyCheck{}
+	check, err := checks.Get("node-pool-consistency")
+	assert.NoError(t, err)
+	assert.Equal(t, check, nodePoolConsistencyCheck)
+}
+
+func TestNodePoolConsistency(t *testing.T) {
+	const (
+		containerd16 = "containerd://1.6.31"
+		containerd17 = "containerd://1.7.15"
+		debian11     = "Debian GNU/Linux 11 (bullseye)"
+		debian12     = "Debian GNU/Linux 12 (bookworm)"
+	)
+
+	tests := []struct {
+		name     string
+		nodes    []corev1.Node
+		expected []checks.Diagnostic
+	}{
+		{
+			name: "consistent pool",
+			nodes: []corev1.Node{
+				poolNode("pool-a-1", "pool-a", containerd17, debian12),
+				poolNode("pool-a-2", "pool-a", containerd17, debian12),
+			},
+			expected: nil,
+		},
+		{
+			name: "different pools",
+			nodes: []corev1.Node{
+				poolNode("pool-a-1", "pool-a", containerd17, debian12),
+				poolNode("pool-b-1", "pool-b", containerd16, debian11),
+			},
+			expected: nil,
+		},
+		{
+			name: "nodes without pool",
+			nodes: []corev1.Node{
+				poolNode("node-1", "", containerd17, debian12),
+				poolNode("node-2", "", containerd16, debian11),
+			},
+			expected: nil,
+		},
+		{
+			name: "mixed runtimes and OS images",
+			nodes: []corev1.Node{
+				poolNode("pool-a-1", "pool-a", containerd17, debian12),
+				poolNode("pool-a-2", "pool-a", containerd16, debian11),
+				poolNode("pool-a-3", "pool-a", containerd17, debian12),
+			},
+			expected: []checks.Diagnostic{
+				{
+					Severity: checks.Warning,
+					Message:  "Node runs container runtime `containerd://1.6.31`, while most nodes of node pool `pool-a` run `containerd://1.7.15`. Nodes of a node pool should be identical.",
+					Kind:     checks.Node,
+					Details:  "Container runtimes in node pool: containerd://1.7.15 (2 nodes), containerd://1.6.31 (1 nodes)",
+				},
+				{
+					Severity: checks.Warning,
+					Message:  "Node runs OS image `Debian GNU/Linux 11 (bullseye)`, while most nodes of node pool `pool-a` run `Debian GNU/Linux 12 (bookworm)`. Nodes of a node pool should be identical.",
+					Kind:     checks.Node,
+					Details:  "OS images in node pool: Debian GNU/Linux 12 (bookworm) (2 nodes), Debian GNU/Linux 11 (bullseye) (1 nodes)",
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := &kube.Objects{Nodes: &corev1.NodeList{Items: test.nodes}}
+			for i := range test.expected {
+				test.expected[i].Object = &objs.Nodes.Items[1].ObjectMeta
+			}
+
+			d, err := (&nodePoolConsistencyCheck{}).Run(objs)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, d)
+		})
+	}
+}
+
+func poolNode(name, pool, runtime, osImage string) corev1.Node {
+	node := corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{}},
+		Status: corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{
+			ContainerRuntimeVersion: runtime,
+			OSImage:                 osImage,
+		}},
+	}
+	if pool != "" {
+		node.Labels[nodePoolLabel] = pool
+	}
+	return node
+}
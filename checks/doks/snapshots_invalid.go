@@ -43,6 +43,11 @@ func (i *invalidSnapshotCheck) Description() string {
 	return "Checks if there are invalid volume snapshots that would fail webhook validation"
 }
 
+// Resources returns the resources whose objects this check reads.
+func (i *invalidSnapshotCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.VolumeSnapshots}
+}
+
 // Run runs this check on a set of Kubernetes objects. It can return warnings
 // (low-priority problems) and errors (high-priority problems) as well as an
 // error value indicating that the check failed to run.
@@ -0,0 +1,71 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package doks
+
+import (
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+)
+
+func init() {
+	checks.Register(&storageClassExpansionCheck{})
+}
+
+type storageClassExpansionCheck struct{}
+
+// Name returns a unique name for this check.
+func (s *storageClassExpansionCheck) Name() string {
+	return "storage-class-expansion"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (s *storageClassExpansionCheck) Groups() []string {
+	return []string{"doks"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (s *storageClassExpansionCheck) Description() string {
+	return "Checks if storage classes provisioning DigitalOcean block storage volumes allow volume expansion."
+}
+
+// Resources returns the resources whose objects this check reads.
+func (s *storageClassExpansionCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.StorageClasses}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (s *storageClassExpansionCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	for i := range objects.StorageClasses.Items {
+		class := &objects.StorageClasses.Items[i]
+		if !isDOCSI(class.Provisioner) || (class.AllowVolumeExpansion != nil && *class.AllowVolumeExpansion) {
+			continue
+		}
+		d := checks.Diagnostic{
+			Severity: checks.Warning,
+			Message:  "Storage class does not allow volume expansion. DOBS volumes can be resized, but persistent volume claims of this storage class cannot.",
+			Kind:     checks.StorageClass,
+			Object:   &class.ObjectMeta,
+			Owners:   class.ObjectMeta.GetOwnerReferences(),
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics, nil
+}
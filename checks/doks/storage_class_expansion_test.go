@@ -0,0 +1,99 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package doks
+
+import (
+	"testing"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	"github.com/stretchr/testify/assert"
+	st "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStorageClassExpansionCheckMeta(t *testing.T) {
+	storageClassExpansionCheck := storageClassExpansionCheck{}
+	assert.Equal(t, "storage-class-expansion", storageClassExpansionCheck.Name())
+	assert.Equal(t, []string{"doks"}, storageClassExpansionCheck.Groups())
+	assert.NotEmpty(t, storageClassExpansionCheck.Description())
+}
+
+func TestStorageClassExpansionCheckRegistration(t *testing.T) {
+	storageClassExpansionCheck := &storageClassExpansionCheck{}
+	check, err := checks.Get("storage-class-expansion")
+	assert.NoError(t, err)
+	assert.Equal(t, check, storageClassExpansionCheck)
+}
+
+func TestStorageClassExpansionWarning(t *testing.T) {
+	allow := func(b bool) *bool { return &b }
+	tests := []struct {
+		name        string
+		provisioner string
+		allow       *bool
+		expected    bool
+	}{
+		{
+			name:        "expansion allowed",
+			provisioner: DOCSIDriver,
+			allow:       allow(true),
+			expected:    false,
+		},
+		{
+			name:        "expansion disallowed",
+			provisioner: DOCSIDriver,
+			allow:       allow(false),
+			expected:    true,
+		},
+		{
+			name:        "expansion not set on legacy driver",
+			provisioner: LegacyCSIDriver,
+			expected:    true,
+		},
+		{
+			name:        "other provisioner",
+			provisioner: "nfs.csi.k8s.io",
+			allow:       allow(false),
+			expected:    false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := &kube.Objects{StorageClasses: &st.StorageClassList{Items: []st.StorageClass{{
+				ObjectMeta:           metav1.ObjectMeta{Name: DOBlockStorageName},
+				Provisioner:          test.provisioner,
+				AllowVolumeExpansion: test.allow,
+			}}}}
+
+			var expected []checks.Diagnostic
+			if test.expected {
+				expected = append(expected, checks.Diagnostic{
+					Severity: checks.Warning,
+					Message:  "Storage class does not allow volume expansion. DOBS volumes can be resized, but persistent volume claims of this storage class cannot.",
+					Kind:     checks.StorageClass,
+					Object:   &objs.StorageClasses.Items[0].ObjectMeta,
+				})
+			}
+
+			d, err := (&storageClassExpansionCheck{}).Run(objs)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, d)
+		})
+	}
+}
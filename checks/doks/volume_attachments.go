@@ -0,0 +1,254 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package doks
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	st "k8s.io/api/storage/v1"
+)
+
+const (
+	// defaultMinAttachPercent is the share of a node's volume attach limit
+	// from which the node is reported.
+	defaultMinAttachPercent = 80
+
+	// staleDetachAge is how long a volume may take to detach before its
+	// attachment is reported.
+	staleDetachAge = 10 * time.Minute
+)
+
+// now returns the current time. It is replaced in tests.
+var now = time.Now
+
+func init() {
+	checks.Register(&attachLimitCheck{minPercent: defaultMinAttachPercent})
+	checks.Register(&volumeAttachmentNodeCheck{})
+	checks.Register(&staleVolumeAttachmentCheck{})
+}
+
+type attachLimitCheck struct {
+	minPercent int64
+}
+
+// Name returns a unique name for this check.
+func (a *attachLimitCheck) Name() string {
+	return "csi-attach-limit"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (a *attachLimitCheck) Groups() []string {
+	return []string{"doks"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (a *attachLimitCheck) Description() string {
+	return "Checks if nodes are close to the number of DOBS volumes they can attach."
+}
+
+// Resources returns the resources whose objects this check reads.
+func (a *attachLimitCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.CSINodes, kube.Nodes, kube.VolumeAttachments}
+}
+
+// Configure sets the thresholds used by the check. The only supported setting
+// is `min-percent`.
+func (a *attachLimitCheck) Configure(settings map[string]string) error {
+	for key, value := range settings {
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || v < 0 || v > 100 {
+			return fmt.Errorf("invalid value %q for %s", value, key)
+		}
+		switch key {
+		case "min-percent":
+			a.minPercent = v
+		default:
+			return fmt.Errorf("unknown setting %q", key)
+		}
+	}
+	return nil
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (a *attachLimitCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	attached := make(map[string][]string)
+	for _, attachment := range objects.VolumeAttachments.Items {
+		if !isDOCSI(attachment.Spec.Attacher) || attachment.DeletionTimestamp != nil {
+			continue
+		}
+		attached[attachment.Spec.NodeName] = append(attached[attachment.Spec.NodeName], attachedVolume(attachment))
+	}
+
+	limits := make(map[string]int32)
+	for _, csiNode := range objects.CSINodes.Items {
+		for _, driver := range csiNode.Spec.Drivers {
+			if isDOCSI(driver.Name) && driver.Allocatable != nil && driver.Allocatable.Count != nil {
+				limits[csiNode.Name] = *driver.Allocatable.Count
+			}
+		}
+	}
+
+	var diagnostics []checks.Diagnostic
+	for i := range objects.Nodes.Items {
+		node := &objects.Nodes.Items[i]
+		limit, ok := limits[node.Name]
+		if !ok || limit == 0 {
+			continue
+		}
+		volumes := attached[node.Name]
+		if int64(len(volumes))*100 < int64(limit)*a.minPercent {
+			continue
+		}
+		d := checks.Diagnostic{
+			Severity: checks.Warning,
+			Message:  fmt.Sprintf("Node has %d of at most %d DOBS volumes attached. Pods that need more volumes cannot be scheduled onto the node.", len(volumes), limit),
+			Kind:     checks.Node,
+			Object:   &node.ObjectMeta,
+			Details:  fmt.Sprintf("Attached volumes: %s", strings.Join(volumes, ", ")),
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics, nil
+}
+
+type volumeAttachmentNodeCheck struct{}
+
+// Name returns a unique name for this check.
+func (v *volumeAttachmentNodeCheck) Name() string {
+	return "volume-attachment-node"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (v *volumeAttachmentNodeCheck) Groups() []string {
+	return []string{"doks"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (v *volumeAttachmentNodeCheck) Description() string {
+	return "Checks if there are DOBS volume attachments to nodes that no longer exist."
+}
+
+// Resources returns the resources whose objects this check reads.
+func (v *volumeAttachmentNodeCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Nodes, kube.VolumeAttachments}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (v *volumeAttachmentNodeCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	nodes := make(map[string]struct{})
+	for _, node := range objects.Nodes.Items {
+		nodes[node.Name] = struct{}{}
+	}
+
+	var diagnostics []checks.Diagnostic
+	for i := range objects.VolumeAttachments.Items {
+		attachment := &objects.VolumeAttachments.Items[i]
+		if !isDOCSI(attachment.Spec.Attacher) {
+			continue
+		}
+		if _, ok := nodes[attachment.Spec.NodeName]; ok {
+			continue
+		}
+		d := checks.Diagnostic{
+			Severity: checks.Warning,
+			Message:  fmt.Sprintf("Volume is attached to node `%s`, which no longer exists. Pods using the volume cannot start on other nodes until it is detached.", attachment.Spec.NodeName),
+			Kind:     checks.VolumeAttachment,
+			Object:   &attachment.ObjectMeta,
+			Owners:   attachment.ObjectMeta.GetOwnerReferences(),
+			Details:  fmt.Sprintf("Volume: %s", attachedVolume(*attachment)),
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics, nil
+}
+
+type staleVolumeAttachmentCheck struct{}
+
+// Name returns a unique name for this check.
+func (s *staleVolumeAttachmentCheck) Name() string {
+	return "stale-volume-attachment"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (s *staleVolumeAttachmentCheck) Groups() []string {
+	return []string{"doks"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (s *staleVolumeAttachmentCheck) Description() string {
+	return "Checks if there are DOBS volume attachments that are stuck detaching."
+}
+
+// Resources returns the resources whose objects this check reads.
+func (s *staleVolumeAttachmentCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.VolumeAttachments}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (s *staleVolumeAttachmentCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	for i := range objects.VolumeAttachments.Items {
+		attachment := &objects.VolumeAttachments.Items[i]
+		if !isDOCSI(attachment.Spec.Attacher) || attachment.DeletionTimestamp == nil {
+			continue
+		}
+		age := now().Sub(attachment.DeletionTimestamp.Time)
+		if age < staleDetachAge {
+			continue
+		}
+		details := []string{
+			fmt.Sprintf("Volume: %s", attachedVolume(*attachment)),
+			fmt.Sprintf("Node: %s", attachment.Spec.NodeName),
+		}
+		if detachError := attachment.Status.DetachError; detachError != nil && detachError.Message != "" {
+			details = append(details, fmt.Sprintf("Detach error: %s", detachError.Message))
+		}
+		d := checks.Diagnostic{
+			Severity: checks.Warning,
+			Message:  fmt.Sprintf("Volume has been detaching for %d minutes. Pods using the volume cannot start on other nodes until it is detached.", int64(age.Minutes())),
+			Kind:     checks.VolumeAttachment,
+			Object:   &attachment.ObjectMeta,
+			Owners:   attachment.ObjectMeta.GetOwnerReferences(),
+			Details:  strings.Join(details, ". "),
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics, nil
+}
+
+// attachedVolume returns the name of the persistent volume an attachment
+// attaches, or the name of the attachment for inline volumes.
+func attachedVolume(attachment st.VolumeAttachment) string {
+	if name := attachment.Spec.Source.PersistentVolumeName; name != nil {
+		return *name
+	}
+	return attachment.Name
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package doks
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	st "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestVolumeAttachmentChecksMeta(t *testing.T) {
+	for name, check := range map[string]checks.Check{
+		"csi-attach-limit":        &attachLimitCheck{},
+		"volume-attachment-node":  &volumeAttachmentNodeCheck{},
+		"stale-volume-attachment": &staleVolumeAttachmentCheck{},
+	} {
+		assert.Equal(t, name, check.Name())
+		assert.Equal(t, []string{"doks"}, check.Groups())
+		assert.NotEmpty(t, check.Description())
+
+		registered, err := checks.Get(name)
+		assert.NoError(t, err)
+		assert.IsType(t, check, registered)
+	}
+}
+
+func TestAttachLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		attached int
+		detached int
+		driver   string
+		expected []string
+	}{
+		{
+			name:     "few volumes",
+			attached: 3,
+			driver:   DOCSIDriver,
+			expected: nil,
+		},
+		{
+			name:     "near the limit",
+			attached: 6,
+			driver:   DOCSIDriver,
+			expected: []string{"Node has 6 of at most 7 DOBS volumes attached. Pods that need more volumes cannot be scheduled onto the node."},
+		},
+		{
+			name:     "detaching volumes",
+			attached: 5,
+			detached: 2,
+			driver:   DOCSIDriver,
+			expected: nil,
+		},
+		{
+			name:     "other driver",
+			attached: 7,
+			driver:   "nfs.csi.k8s.io",
+			expected: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := initAttachments()
+			for i := 0; i < test.attached+test.detached; i++ {
+				attachment := attachment(fmt.Sprintf("pvc-%d", i), "node-1", test.driver)
+				if i >= test.attached {
+					deleted := metav1.Now()
+					attachment.DeletionTimestamp = &deleted
+				}
+				objs.VolumeAttachments.Items = append(objs.VolumeAttachments.Items, attachment)
+			}
+
+			d, err := (&attachLimitCheck{minPercent: defaultMinAttachPercent}).Run(objs)
+			assert.NoError(t, err)
+			var messages []string
+			for _, diagnostic := range d {
+				assert.Equal(t, checks.Node, diagnostic.Kind)
+				assert.Equal(t, "node-1", diagnostic.Object.Name)
+				messages = append(messages, diagnostic.Message)
+			}
+			assert.Equal(t, test.expected, messages)
+		})
+	}
+}
+
+func TestAttachLimitConfigure(t *testing.T) {
+	check := &attachLimitCheck{minPercent: defaultMinAttachPercent}
+	assert.NoError(t, check.Configure(map[string]string{"min-percent": "50"}))
+	assert.Equal(t, int64(50), check.minPercent)
+	assert.Error(t, check.Configure(map[string]string{"min-percent": "150"}))
+	assert.Error(t, check.Configure(map[string]string{"max-percent": "50"}))
+}
+
+func TestVolumeAttachmentNode(t *testing.T) {
+	objs := initAttachments()
+	objs.VolumeAttachments.Items = []st.VolumeAttachment{
+		attachment("pvc-1", "node-1", DOCSIDriver),
+		attachment("pvc-2", "node-2", DOCSIDriver),
+		attachment("pvc-3", "node-2", "nfs.csi.k8s.io"),
+	}
+
+	d, err := (&volumeAttachmentNodeCheck{}).Run(objs)
+	assert.NoError(t, err)
+	assert.Equal(t, []checks.Diagnostic{{
+		Severity: checks.Warning,
+		Message:  "Volume is attached to node `node-2`, which no longer exists. Pods using the volume cannot start on other nodes until it is detached.",
+		Kind:     checks.VolumeAttachment,
+		Object:   &objs.VolumeAttachments.Items[1].ObjectMeta,
+		Details:  "Volume: pvc-2",
+	}}, d)
+}
+
+func TestStaleVolumeAttachment(t *testing.T) {
+	current := time.Date(2022, 3, 3, 12, 0, 0, 0, time.UTC)
+	defer func() { now = time.Now }()
+	now = func() time.Time { return current }
+
+	detaching := func(name string, since time.Duration, detachError string) st.VolumeAttachment {
+		a := attachment(name, "node-1", DOCSIDriver)
+		deleted := metav1.NewTime(current.Add(-since))
+		a.DeletionTimestamp = &deleted
+		if detachError != "" {
+			a.Status.DetachError = &st.VolumeError{Message: detachError}
+		}
+		return a
+	}
+
+	objs := initAttachments()
+	objs.VolumeAttachments.Items = []st.VolumeAttachment{
+		attachment("pvc-1", "node-1", DOCSIDriver),
+		detaching("pvc-2", time.Minute, ""),
+		detaching("pvc-3", 45*time.Minute, "rpc error: code = Unavailable"),
+	}
+
+	d, err := (&staleVolumeAttachmentCheck{}).Run(objs)
+	assert.NoError(t, err)
+	assert.Equal(t, []checks.Diagnostic{{
+		Severity: checks.Warning,
+		Message:  "Volume has been detaching for 45 minutes. Pods using the volume cannot start on other nodes until it is detached.",
+		Kind:     checks.VolumeAttachment,
+		Object:   &objs.VolumeAttachments.Items[2].ObjectMeta,
+		Details:  "Volume: pvc-3. Node: node-1. Detach error: rpc error: code = Unavailable",
+	}}, d)
+}
+
+func initAttachments() *kube.Objects {
+	limit := int32(7)
+	return &kube.Objects{
+		Nodes: &corev1.NodeList{Items: []corev1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}}},
+		CSINodes: &st.CSINodeList{Items: []st.CSINode{{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Spec: st.CSINodeSpec{Drivers: []st.CSINodeDriver{{
+				Name:        DOCSIDriver,
+				Allocatable: &st.VolumeNodeResources{Count: &limit},
+			}}},
+		}}},
+		VolumeAttachments: &st.VolumeAttachmentList{},
+	}
+}
+
+func attachment(pv, node, attacher string) st.VolumeAttachment {
+	return st.VolumeAttachment{
+		ObjectMeta: metav1.ObjectMeta{Name: "csi-" + pv},
+		Spec: st.VolumeAttachmentSpec{
+			Attacher: attacher,
+			NodeName: node,
+			Source:   st.VolumeAttachmentSource{PersistentVolumeName: &pv},
+		},
+		Status: st.VolumeAttachmentStatus{Attached: true},
+	}
+}
@@ -0,0 +1,243 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package external loads checks served by plugin executables, which
+// clusterlint runs as separate processes. See package sdk for the protocol
+// and for writing such plugins in Go.
+//
+// Plugins may also be WebAssembly modules built for WASI, such as Go programs
+// built with GOOS=wasip1 GOARCH=wasm. They speak the same protocol, and are
+// run in an embedded runtime that limits their memory and stops them when
+// their check times out.
+package external
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/checks/external/sdk"
+	"github.com/digitalocean/clusterlint/kube"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Prefix is the prefix of the names of plugin executables.
+const Prefix = "clusterlint-check-"
+
+// metadataTimeout bounds the time a plugin may take to describe its checks.
+const metadataTimeout = 10 * time.Second
+
+// Discover returns the paths of the plugin executables and WebAssembly
+// modules in the given directories. As with the PATH, a plugin is skipped if
+// one with the same name was found in an earlier directory.
+func Discover(dirs []string) []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			// Like the shell, ignore directories that can't be read.
+			continue
+		}
+		names := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if !strings.HasPrefix(name, Prefix) || seen[name] {
+				continue
+			}
+			path := filepath.Join(dir, name)
+			if filepath.Ext(name) != wasmExt && !executable(path) {
+				continue
+			}
+			seen[name] = true
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// executable reports whether path is an executable file. Windows has no
+// executable bits, so executables are recognised by their extension there.
+func executable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(filepath.Ext(path), ".exe")
+	}
+	return info.Mode()&0o111 != 0
+}
+
+// Option configures how plugins are run.
+type Option func(*options)
+
+type options struct {
+	wasmMemoryLimit uint32
+}
+
+// WithWASMMemoryLimit returns an Option that limits the memory of WebAssembly
+// plugins to the given number of 64 KiB pages. It defaults to
+// DefaultWASMMemoryLimit.
+func WithWASMMemoryLimit(pages uint32) Option {
+	return func(o *options) {
+		o.wasmMemoryLimit = pages
+	}
+}
+
+// runner runs a plugin with the given arguments and input, and returns its
+// output.
+type runner interface {
+	run(ctx context.Context, stdin []byte, args ...string) ([]byte, error)
+}
+
+// Load asks the plugin at path for the checks it serves.
+func Load(path string, opts ...Option) ([]checks.Check, error) {
+	o := &options{wasmMemoryLimit: DefaultWASMMemoryLimit}
+	for _, opt := range opts {
+		opt(o)
+	}
+	var plugin runner = process(path)
+	if filepath.Ext(path) == wasmExt {
+		m, err := compile(path, o.wasmMemoryLimit)
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s: %w", path, err)
+		}
+		plugin = m
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), metadataTimeout)
+	defer cancel()
+
+	out, err := plugin.run(ctx, nil, "metadata")
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", path, err)
+	}
+	var metadata sdk.Metadata
+	if err := json.Unmarshal(out, &metadata); err != nil {
+		return nil, fmt.Errorf("plugin %s: decoding metadata: %w", path, err)
+	}
+	if metadata.ProtocolVersion != sdk.ProtocolVersion {
+		return nil, fmt.Errorf("plugin %s: unsupported protocol version %d, expected %d", path, metadata.ProtocolVersion, sdk.ProtocolVersion)
+	}
+
+	ret := make([]checks.Check, 0, len(metadata.Checks))
+	for _, m := range metadata.Checks {
+		ret = append(ret, &check{path: path, plugin: plugin, metadata: m})
+	}
+	return ret, nil
+}
+
+// process is a plugin executable, which is run as a separate process.
+type process string
+
+// run runs the executable with the given arguments and returns its output.
+// The plugin's stderr is included in the error if it fails.
+func (p process) run(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, string(p), args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// check is a check served by a plugin.
+type check struct {
+	path     string
+	plugin   runner
+	metadata sdk.CheckMetadata
+}
+
+// Name returns a unique name for this check.
+func (c *check) Name() string {
+	return c.metadata.Name
+}
+
+// Groups returns a list of group names this check should be part of.
+func (c *check) Groups() []string {
+	return c.metadata.Groups
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (c *check) Description() string {
+	return c.metadata.Description
+}
+
+// Resources returns the resources whose objects this check reads.
+func (c *check) Resources() []kube.Resource {
+	return c.metadata.Resources
+}
+
+// MetadataOnly returns the resources this check only reads the metadata of.
+func (c *check) MetadataOnly() []kube.Resource {
+	return c.metadata.MetadataOnly
+}
+
+// UnstructuredResources returns the resources this check reads from
+// kube.Objects.Unstructured.
+func (c *check) UnstructuredResources() []schema.GroupVersionResource {
+	return c.metadata.UnstructuredResources
+}
+
+// Run runs this check on a set of Kubernetes objects.
+func (c *check) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	return c.RunWithContext(context.Background(), objects)
+}
+
+// RunWithContext runs the plugin, which is stopped if the context is
+// cancelled.
+func (c *check) RunWithContext(ctx context.Context, objects *kube.Objects) ([]checks.Diagnostic, error) {
+	in, err := json.Marshal(objects)
+	if err != nil {
+		return nil, err
+	}
+	out, err := c.plugin.run(ctx, in, "run", c.metadata.Name)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", c.path, err)
+	}
+	var result sdk.Result
+	if err := json.Unmarshal(out, &result); err != nil {
+		return nil, fmt.Errorf("plugin %s: decoding result: %w", c.path, err)
+	}
+	if result.Error != "" {
+		return nil, errors.New(result.Error)
+	}
+	return result.Diagnostics, nil
+}
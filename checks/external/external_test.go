@@ -0,0 +1,224 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/checks/external/sdk"
+	"github.com/digitalocean/clusterlint/kube"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// pluginEnv makes the test binary act as a plugin, so tests can run it as
+// one.
+const pluginEnv = "CLUSTERLINT_TEST_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(pluginEnv) != "" {
+		sdk.Main(&podCheck{}, &failingCheck{}, &slowCheck{})
+	}
+	os.Exit(m.Run())
+}
+
+type podCheck struct{}
+
+func (c *podCheck) Name() string                  { return "pods" }
+func (c *podCheck) Groups() []string              { return []string{"plugins"} }
+func (c *podCheck) Description() string           { return "Reports every pod" }
+func (c *podCheck) Resources() []kube.Resource    { return []kube.Resource{kube.Pods} }
+func (c *podCheck) MetadataOnly() []kube.Resource { return []kube.Resource{kube.Pods} }
+func (c *podCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var d []checks.Diagnostic
+	for i := range objects.Pods.Items {
+		pod := &objects.Pods.Items[i]
+		d = append(d, checks.Diagnostic{
+			Severity: checks.Suggestion,
+			Message:  "found a pod",
+			Kind:     checks.Pod,
+			Object:   &pod.ObjectMeta,
+			Owners:   pod.GetOwnerReferences(),
+		})
+	}
+	return d, nil
+}
+
+type failingCheck struct{}
+
+func (c *failingCheck) Name() string        { return "failing" }
+func (c *failingCheck) Groups() []string    { return nil }
+func (c *failingCheck) Description() string { return "Always fails" }
+func (c *failingCheck) Run(*kube.Objects) ([]checks.Diagnostic, error) {
+	return nil, errors.New("something went wrong")
+}
+
+type slowCheck struct{}
+
+func (c *slowCheck) Name() string        { return "slow" }
+func (c *slowCheck) Groups() []string    { return nil }
+func (c *slowCheck) Description() string { return "Never finishes" }
+func (c *slowCheck) Run(*kube.Objects) ([]checks.Diagnostic, error) {
+	time.Sleep(time.Hour)
+	return nil, nil
+}
+
+// installPlugin links the test binary into a directory as a plugin
+// executable and returns its path.
+func installPlugin(t *testing.T, dir string) string {
+	t.Setenv(pluginEnv, "1")
+	self, err := os.Executable()
+	assert.NoError(t, err)
+	path := filepath.Join(dir, Prefix+"test")
+	assert.NoError(t, os.Symlink(self, path))
+	return path
+}
+
+func loadPlugin(t *testing.T) map[string]checks.Check {
+	cs, err := Load(installPlugin(t, t.TempDir()))
+	assert.NoError(t, err)
+	ret := make(map[string]checks.Check)
+	for _, check := range cs {
+		ret[check.Name()] = check
+	}
+	return ret
+}
+
+func TestDiscover(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	path := installPlugin(t, first)
+	installPlugin(t, second)
+	assert.NoError(t, os.WriteFile(filepath.Join(first, Prefix+"not-executable"), nil, 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(first, "kubectl-plugin"), nil, 0o755))
+	module := filepath.Join(second, Prefix+"module.wasm")
+	assert.NoError(t, os.WriteFile(module, nil, 0o644))
+
+	assert.Equal(t, []string{path, module}, Discover([]string{first, second, filepath.Join(first, "missing")}))
+}
+
+func TestLoad(t *testing.T) {
+	cs := loadPlugin(t)
+	assert.Len(t, cs, 3)
+
+	check := cs["pods"]
+	assert.Equal(t, []string{"plugins"}, check.Groups())
+	assert.Equal(t, "Reports every pod", check.Description())
+	assert.Equal(t, []kube.Resource{kube.Pods}, checks.Resources(check))
+	assert.Equal(t, []kube.Resource{kube.Pods}, check.(checks.MetadataDeclarer).MetadataOnly())
+	assert.Equal(t, kube.AllResources(), checks.Resources(cs["failing"]))
+}
+
+func TestLoadError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), Prefix+"broken")
+	assert.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\necho broken >&2\nexit 3\n"), 0o755))
+
+	_, err := Load(path)
+	assert.EqualError(t, err, "plugin "+path+": exit status 3: broken")
+}
+
+func TestRun(t *testing.T) {
+	cs := loadPlugin(t)
+	objects := &kube.Objects{
+		Pods: &corev1.PodList{
+			Items: []corev1.Pod{{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "k8s"}}},
+		},
+	}
+
+	diagnostics, err := cs["pods"].Run(objects)
+	assert.NoError(t, err)
+	assert.Equal(t, []checks.Diagnostic{
+		{
+			Severity: checks.Suggestion,
+			Message:  "found a pod",
+			Kind:     checks.Pod,
+			Object:   &metav1.ObjectMeta{Name: "web", Namespace: "k8s"},
+		},
+	}, diagnostics)
+
+	_, err = cs["failing"].Run(objects)
+	assert.EqualError(t, err, "something went wrong")
+}
+
+func TestRunWithContext(t *testing.T) {
+	cs := loadPlugin(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := cs["slow"].(checks.CheckWithContext).RunWithContext(ctx, &kube.Objects{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestLoadWASM(t *testing.T) {
+	cs, err := Load(filepath.Join("testdata", "plugin.wasm"))
+	assert.NoError(t, err)
+	assert.Len(t, cs, 4)
+	assert.Equal(t, "pods", cs[0].Name())
+	assert.Equal(t, []string{"wasm"}, cs[0].Groups())
+	assert.Equal(t, []kube.Resource{kube.Pods}, checks.Resources(cs[0]))
+
+	diagnostics, err := cs[0].Run(&kube.Objects{})
+	assert.NoError(t, err)
+	assert.Equal(t, []checks.Diagnostic{
+		{
+			Severity: checks.Suggestion,
+			Message:  "found a pod",
+			Kind:     checks.Pod,
+			Object:   &metav1.ObjectMeta{Name: "web", Namespace: "k8s"},
+		},
+	}, diagnostics)
+}
+
+func TestRunWASMOutputLimit(t *testing.T) {
+	cs, err := Load(filepath.Join("testdata", "plugin.wasm"), WithWASMMemoryLimit(4))
+	assert.NoError(t, err)
+
+	_, err = cs[3].Run(&kube.Objects{})
+	assert.EqualError(t, err, "plugin testdata/plugin.wasm: output to stdout exceeds 262144 bytes")
+}
+
+func TestLoadWASMError(t *testing.T) {
+	module := filepath.Join(t.TempDir(), Prefix+"broken.wasm")
+	assert.NoError(t, os.WriteFile(module, []byte("not a module"), 0o644))
+
+	_, err := Load(module)
+	assert.ErrorContains(t, err, "plugin "+module+": invalid magic number")
+}
+
+func TestRunWASMWithContext(t *testing.T) {
+	cs, err := Load(filepath.Join("testdata", "plugin.wasm"))
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err = cs[1].(checks.CheckWithContext).RunWithContext(ctx, &kube.Objects{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRunWASMMemoryLimit(t *testing.T) {
+	cs, err := Load(filepath.Join("testdata", "plugin.wasm"), WithWASMMemoryLimit(4))
+	assert.NoError(t, err)
+
+	_, err = cs[2].Run(&kube.Objects{})
+	assert.EqualError(t, err, "plugin testdata/plugin.wasm: exit status 1: out of memory")
+}
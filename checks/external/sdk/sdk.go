@@ -0,0 +1,150 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sdk implements the executable plugin protocol for checks, so that a
+// plugin can be written as a Go program serving ordinary checks.Check values:
+//
+//	func main() {
+//		sdk.Main(&myCheck{})
+//	}
+//
+// Build the program as an executable named clusterlint-check-<name> and put
+// it in a directory passed to clusterlint with --plugin-dir.
+//
+// The protocol is simple enough to implement in other languages. Clusterlint
+// runs the executable with a single "metadata" argument to learn about its
+// checks, and expects a Metadata value encoded as JSON on stdout. To run a
+// check, clusterlint runs the executable with the arguments "run" and the
+// check's name, writes the objects fetched from the cluster to its stdin as a
+// JSON encoded kube.Objects value, and expects a JSON encoded Result on
+// stdout. Messages written to stderr are shown if the executable exits with a
+// non-zero status.
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ProtocolVersion is the version of the protocol spoken by plugins built
+// with this package.
+const ProtocolVersion = 1
+
+// Metadata describes the checks served by a plugin.
+type Metadata struct {
+	ProtocolVersion int
+	Checks          []CheckMetadata
+}
+
+// CheckMetadata describes a check served by a plugin.
+type CheckMetadata struct {
+	Name        string
+	Groups      []string
+	Description string
+	// Resources lists the resources whose objects the check reads.
+	Resources []kube.Resource
+	// MetadataOnly lists the resources the check only reads the metadata of.
+	MetadataOnly []kube.Resource `json:",omitempty"`
+	// UnstructuredResources lists the resources the check reads from
+	// kube.Objects.Unstructured.
+	UnstructuredResources []schema.GroupVersionResource `json:",omitempty"`
+}
+
+// Result is the outcome of running a check.
+type Result struct {
+	Diagnostics []checks.Diagnostic
+	// Error is set if the check failed to run.
+	Error string `json:",omitempty"`
+}
+
+// Main serves the given checks, following the command line arguments, and
+// exits. It is meant to be called from a plugin's main function.
+func Main(cs ...checks.Check) {
+	if err := Serve(os.Args[1:], os.Stdin, os.Stdout, cs...); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// Serve handles a single request from clusterlint, given the arguments the
+// plugin was run with.
+func Serve(args []string, stdin io.Reader, stdout io.Writer, cs ...checks.Check) error {
+	switch {
+	case len(args) == 1 && args[0] == "metadata":
+		return json.NewEncoder(stdout).Encode(metadata(cs))
+	case len(args) == 2 && args[0] == "run":
+		check, err := find(cs, args[1])
+		if err != nil {
+			return err
+		}
+		objects := &kube.Objects{}
+		if err := json.NewDecoder(stdin).Decode(objects); err != nil {
+			return fmt.Errorf("decoding objects: %w", err)
+		}
+		if objects.Unstructured == nil {
+			objects.Unstructured = kube.NewUnstructuredObjects()
+		}
+		return json.NewEncoder(stdout).Encode(run(check, objects))
+	}
+	return fmt.Errorf("usage: %s metadata | run CHECK", os.Args[0])
+}
+
+func metadata(cs []checks.Check) Metadata {
+	m := Metadata{ProtocolVersion: ProtocolVersion}
+	for _, check := range cs {
+		c := CheckMetadata{
+			Name:        check.Name(),
+			Groups:      check.Groups(),
+			Description: check.Description(),
+			Resources:   kube.AllResources(),
+		}
+		if d, ok := check.(checks.ResourceDeclarer); ok {
+			c.Resources = d.Resources()
+		}
+		if d, ok := check.(checks.MetadataDeclarer); ok {
+			c.MetadataOnly = d.MetadataOnly()
+		}
+		if d, ok := check.(checks.UnstructuredDeclarer); ok {
+			c.UnstructuredResources = d.UnstructuredResources()
+		}
+		m.Checks = append(m.Checks, c)
+	}
+	return m
+}
+
+func find(cs []checks.Check, name string) (checks.Check, error) {
+	for _, check := range cs {
+		if check.Name() == name {
+			return check, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown check %q", name)
+}
+
+func run(check checks.Check, objects *kube.Objects) Result {
+	diagnostics, err := check.Run(objects)
+	if err != nil {
+		return Result{Error: err.Error()}
+	}
+	return Result{Diagnostics: diagnostics}
+}
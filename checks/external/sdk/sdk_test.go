@@ -0,0 +1,98 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var certificates = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+
+type certificateCheck struct{}
+
+func (c *certificateCheck) Name() string        { return "certificates" }
+func (c *certificateCheck) Groups() []string    { return []string{"cert-manager"} }
+func (c *certificateCheck) Description() string { return "Reports every certificate" }
+func (c *certificateCheck) Resources() []kube.Resource {
+	return nil
+}
+func (c *certificateCheck) UnstructuredResources() []schema.GroupVersionResource {
+	return []schema.GroupVersionResource{certificates}
+}
+func (c *certificateCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var d []checks.Diagnostic
+	for _, certificate := range objects.Unstructured.List(certificates) {
+		d = append(d, checks.Diagnostic{Severity: checks.Warning, Message: "found " + certificate.GetName()})
+	}
+	return d, nil
+}
+
+func TestServeMetadata(t *testing.T) {
+	var out bytes.Buffer
+	assert.NoError(t, Serve([]string{"metadata"}, nil, &out, &certificateCheck{}))
+
+	var metadata Metadata
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &metadata))
+	assert.Equal(t, Metadata{
+		ProtocolVersion: ProtocolVersion,
+		Checks: []CheckMetadata{{
+			Name:                  "certificates",
+			Groups:                []string{"cert-manager"},
+			Description:           "Reports every certificate",
+			UnstructuredResources: []schema.GroupVersionResource{certificates},
+		}},
+	}, metadata)
+}
+
+func TestServeRun(t *testing.T) {
+	objects := &kube.Objects{Unstructured: kube.NewUnstructuredObjects()}
+	objects.Unstructured.Add(certificates, "Certificate", unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "Certificate",
+		"metadata":   map[string]interface{}{"name": "tls"},
+	}})
+	in, err := json.Marshal(objects)
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+	assert.NoError(t, Serve([]string{"run", "certificates"}, bytes.NewReader(in), &out, &certificateCheck{}))
+	var result Result
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &result))
+	assert.Equal(t, Result{Diagnostics: []checks.Diagnostic{{Severity: checks.Warning, Message: "found tls"}}}, result)
+}
+
+func TestServeErrors(t *testing.T) {
+	var out bytes.Buffer
+	err := Serve([]string{"run", "missing"}, strings.NewReader("{}"), &out, &certificateCheck{})
+	assert.EqualError(t, err, `unknown check "missing"`)
+
+	err = Serve([]string{"run", "certificates"}, strings.NewReader("not json"), &out, &certificateCheck{})
+	assert.ErrorContains(t, err, "decoding objects")
+
+	err = Serve(nil, nil, &out, &certificateCheck{})
+	assert.ErrorContains(t, err, "usage:")
+	assert.Empty(t, out.String())
+}
@@ -0,0 +1,62 @@
+;; plugin.wat is a minimal WebAssembly plugin used to test the embedded WASI
+;; runtime. It serves four checks, and the first letter of the name of the
+;; check it is asked to run selects what it does:
+;;
+;;   pods   reports a pod
+;;   spin   never finishes
+;;   grow   tries to grow its memory by 16 pages and fails if it cannot
+;;   flood  writes to stdout without end
+;;
+;; plugin.wasm is built from this file with wat2wasm from WABT.
+(module
+  (import "wasi_snapshot_preview1" "args_sizes_get" (func $args_sizes_get (param i32 i32) (result i32)))
+  (import "wasi_snapshot_preview1" "args_get" (func $args_get (param i32 i32) (result i32)))
+  (import "wasi_snapshot_preview1" "fd_write" (func $fd_write (param i32 i32 i32 i32) (result i32)))
+  (import "wasi_snapshot_preview1" "proc_exit" (func $proc_exit (param i32)))
+
+  ;; 0: argc, 4: size of the arguments, 8: iovec, 16: bytes written,
+  ;; 32: argv, 64: arguments, 1024: responses
+  (memory (export "memory") 1)
+
+  (data (i32.const 1024) "{\"ProtocolVersion\":1,\"Checks\":[{\"Name\":\"pods\",\"Groups\":[\"wasm\"],\"Description\":\"Reports a pod\",\"Resources\":[{\"Group\":\"\",\"Resource\":\"pods\"}]},{\"Name\":\"spin\"},{\"Name\":\"grow\"},{\"Name\":\"flood\"}]}\n")
+  (data (i32.const 1536) "{\"Diagnostics\":[{\"Severity\":\"suggestion\",\"Message\":\"found a pod\",\"Kind\":\"pod\",\"Object\":{\"name\":\"web\",\"namespace\":\"k8s\"}}]}\n")
+  (data (i32.const 2048) "out of memory\n")
+
+  ;; write writes len bytes at ptr to a file descriptor.
+  (func $write (param $fd i32) (param $ptr i32) (param $len i32)
+    (i32.store (i32.const 8) (local.get $ptr))
+    (i32.store (i32.const 12) (local.get $len))
+    (drop (call $fd_write (local.get $fd) (i32.const 8) (i32.const 1) (i32.const 16))))
+
+  (func (export "_start")
+    (local $check i32)
+    (drop (call $args_sizes_get (i32.const 0) (i32.const 4)))
+    ;; Called with "metadata".
+    (if (i32.eq (i32.load (i32.const 0)) (i32.const 2))
+      (then
+        (call $write (i32.const 1) (i32.const 1024) (i32.const 191))
+        (return)))
+
+    ;; Called with "run" and the name of a check.
+    (drop (call $args_get (i32.const 32) (i32.const 64)))
+    (local.set $check (i32.load8_u (i32.load (i32.const 40))))
+    (if (i32.eq (local.get $check) (i32.const 112)) ;; 'p'
+      (then
+        (call $write (i32.const 1) (i32.const 1536) (i32.const 123))
+        (return)))
+    (if (i32.eq (local.get $check) (i32.const 115)) ;; 's'
+      (then
+        (loop $spin (br $spin))))
+    (if (i32.eq (local.get $check) (i32.const 103)) ;; 'g'
+      (then
+        (if (i32.eq (memory.grow (i32.const 16)) (i32.const -1))
+          (then
+            (call $write (i32.const 2) (i32.const 2048) (i32.const 14))
+            (call $proc_exit (i32.const 1))))
+        (return)))
+    (if (i32.eq (local.get $check) (i32.const 102)) ;; 'f'
+      (then
+        (loop $flood
+          (call $write (i32.const 1) (i32.const 1024) (i32.const 191))
+          (br $flood))))
+    (call $proc_exit (i32.const 2))))
@@ -0,0 +1,172 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// wasmExt is the extension of plugins that are WebAssembly modules.
+const wasmExt = ".wasm"
+
+// DefaultWASMMemoryLimit is the number of 64 KiB pages of memory a
+// WebAssembly plugin may use by default, which amounts to 512 MiB.
+const DefaultWASMMemoryLimit = 8192
+
+// wasmPageSize is the size of a page of WebAssembly memory.
+const wasmPageSize = 64 << 10
+
+// compilationCache returns the cache of compiled WebAssembly modules, kept in
+// the user's cache directory so that modules are only compiled once. It is nil
+// if there is no cache directory.
+var compilationCache = sync.OnceValue(func() wazero.CompilationCache {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil
+	}
+	cache, err := wazero.NewCompilationCacheWithDir(filepath.Join(dir, "clusterlint", "wasm"))
+	if err != nil {
+		return nil
+	}
+	return cache
+})
+
+// module is a compiled WebAssembly plugin. Each invocation instantiates it
+// anew in the module's runtime.
+type module struct {
+	name     string
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	// outputLimit is the number of bytes an instance may write to stdout
+	// and to stderr, which is as much as it may hold in memory.
+	outputLimit int64
+}
+
+// compile compiles the WebAssembly module at path in a runtime of its own,
+// which provides WASI and limits the memory of instances to the given number
+// of pages. Instances are closed when the context they run with is done.
+// Compiling a large module takes a while, so compiled modules are cached.
+func compile(path string, memoryLimit uint32) (*module, error) {
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	config := wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(memoryLimit).
+		WithCloseOnContextDone(true)
+	if cache := compilationCache(); cache != nil {
+		config = config.WithCompilationCache(cache)
+	}
+	r := wazero.NewRuntimeWithConfig(ctx, config)
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, r); err != nil {
+		r.Close(ctx)
+		return nil, err
+	}
+	compiled, err := r.CompileModule(ctx, code)
+	if err != nil {
+		r.Close(ctx)
+		return nil, err
+	}
+	return &module{
+		name:        filepath.Base(path),
+		runtime:     r,
+		compiled:    compiled,
+		outputLimit: int64(memoryLimit) * wasmPageSize,
+	}, nil
+}
+
+// run instantiates the module with the given arguments, which runs it to
+// completion, and returns its output. Modules have no access to the
+// filesystem or network, only to the clock and a source of randomness. A
+// module that writes more than its output limit is stopped.
+func (m *module) run(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	stdout := &limitedBuffer{name: "stdout", limit: m.outputLimit, exceeded: stop}
+	stderr := &limitedBuffer{name: "stderr", limit: m.outputLimit, exceeded: stop}
+	config := wazero.NewModuleConfig().
+		// Anonymous instances can run concurrently.
+		WithName("").
+		WithArgs(append([]string{m.name}, args...)...).
+		WithStdin(bytes.NewReader(stdin)).
+		WithStdout(stdout).
+		WithStderr(stderr).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithSysNanosleep().
+		WithRandSource(rand.Reader)
+	instance, err := m.runtime.InstantiateModule(runCtx, m.compiled, config)
+	if instance != nil {
+		instance.Close(ctx)
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	for _, out := range []*limitedBuffer{stdout, stderr} {
+		if out.overflow {
+			return nil, fmt.Errorf("output to %s exceeds %d bytes", out.name, out.limit)
+		}
+	}
+	var exitErr *sys.ExitError
+	if errors.As(err, &exitErr) {
+		if exitErr.ExitCode() == 0 {
+			return stdout.buf.Bytes(), nil
+		}
+		err = fmt.Errorf("exit status %d", exitErr.ExitCode())
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.buf.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return stdout.buf.Bytes(), nil
+}
+
+// limitedBuffer collects the output of a module up to a limit. Writing more
+// fails and calls exceeded, which stops the module.
+type limitedBuffer struct {
+	name     string
+	buf      bytes.Buffer
+	limit    int64
+	exceeded func()
+	overflow bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if int64(b.buf.Len())+int64(len(p)) > b.limit {
+		if !b.overflow {
+			b.overflow = true
+			b.exceeded()
+		}
+		return 0, fmt.Errorf("output to %s exceeds %d bytes", b.name, b.limit)
+	}
+	return b.buf.Write(p)
+}
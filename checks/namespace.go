@@ -0,0 +1,34 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checks
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var systemNamespaces = map[string]struct{}{
+	metav1.NamespaceSystem: {},
+	metav1.NamespacePublic: {},
+	"kube-node-lease":      {},
+}
+
+// IsSystemNamespace reports whether a namespace is managed by Kubernetes
+// rather than by the cluster's users.
+func IsSystemNamespace(namespace string) bool {
+	_, ok := systemNamespaces[namespace]
+	return ok
+}
@@ -0,0 +1,31 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSystemNamespace(t *testing.T) {
+	assert.True(t, IsSystemNamespace("kube-system"))
+	assert.True(t, IsSystemNamespace("kube-public"))
+	assert.True(t, IsSystemNamespace("kube-node-lease"))
+	assert.False(t, IsSystemNamespace("default"))
+	assert.False(t, IsSystemNamespace("kube-apps"))
+}
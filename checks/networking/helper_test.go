@@ -0,0 +1,65 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package networking
+
+import (
+	"github.com/digitalocean/clusterlint/kube"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func initObjects() *kube.Objects {
+	return &kube.Objects{
+		Pods: &corev1.PodList{},
+		Namespaces: &corev1.NamespaceList{
+			Items: []corev1.Namespace{
+				namespace("k8s", nil),
+				namespace("monitoring", map[string]string{"team": "observability"}),
+			},
+		},
+		NetworkPolicies: &networkingv1.NetworkPolicyList{},
+	}
+}
+
+func namespace(name string, labels map[string]string) corev1.Namespace {
+	return corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func withPod(objs *kube.Objects, namespace, name string, labels map[string]string, ports ...corev1.ContainerPort) {
+	objs.Pods.Items = append(objs.Pods.Items, corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "bar", Ports: ports}},
+		},
+	})
+}
+
+func withPolicy(objs *kube.Objects, namespace, name string, spec networkingv1.NetworkPolicySpec) {
+	objs.NetworkPolicies.Items = append(objs.NetworkPolicies.Items, networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       spec,
+	})
+}
+
+func denyAll() networkingv1.NetworkPolicySpec {
+	return networkingv1.NetworkPolicySpec{PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}}
+}
+
+func selector(labels map[string]string) metav1.LabelSelector {
+	return metav1.LabelSelector{MatchLabels: labels}
+}
@@ -0,0 +1,442 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package networking
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+const (
+	// legacyIngressClassAnnotation selected the ingress controller before
+	// IngressClass existed. Controllers still honor it.
+	legacyIngressClassAnnotation  = "kubernetes.io/ingress.class"
+	defaultIngressClassAnnotation = "ingressclass.kubernetes.io/is-default-class"
+)
+
+func init() {
+	checks.Register(&ingressBackendCheck{})
+	checks.Register(&ingressTLSCheck{})
+	checks.Register(&ingressDuplicateRuleCheck{})
+	checks.Register(&ingressClassCheck{})
+}
+
+type ingressBackendCheck struct{}
+
+// Name returns a unique name for this check.
+func (i *ingressBackendCheck) Name() string {
+	return "ingress-backend"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (i *ingressBackendCheck) Groups() []string {
+	return []string{"networking"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (i *ingressBackendCheck) Description() string {
+	return "Checks if there are ingresses and HTTP routes whose backends refer to services or ports that do not exist"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (i *ingressBackendCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.HTTPRoutes, kube.Ingresses, kube.Services}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (i *ingressBackendCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	services := make(map[kube.Identifier]*corev1.Service)
+	for j := range objects.Services.Items {
+		service := &objects.Services.Items[j]
+		services[kube.Identifier{Name: service.Name, Namespace: service.Namespace}] = service
+	}
+
+	for j := range objects.Ingresses.Items {
+		ingress := &objects.Ingresses.Items[j]
+		for _, backend := range ingressBackends(ingress) {
+			if backend.backend.Service == nil {
+				continue
+			}
+			ref := backend.backend.Service
+			service, ok := services[kube.Identifier{Name: ref.Name, Namespace: ingress.Namespace}]
+			var message string
+			switch {
+			case !ok:
+				message = fmt.Sprintf("Backend for %s refers to service `%s`, which does not exist", backend.describe(), ref.Name)
+			case !hasServicePort(service, ref.Port.Name, ref.Port.Number):
+				message = fmt.Sprintf("Backend for %s refers to port `%s` of service `%s`, which does not exist", backend.describe(), describeServiceBackendPort(ref.Port), ref.Name)
+			default:
+				continue
+			}
+			d := checks.Diagnostic{
+				Severity: checks.Error,
+				Message:  message,
+				Kind:     checks.Ingress,
+				Object:   &ingress.ObjectMeta,
+				Owners:   ingress.ObjectMeta.GetOwnerReferences(),
+			}
+			diagnostics = append(diagnostics, d)
+		}
+	}
+
+	for j := range objects.HTTPRoutes.Items {
+		route := &objects.HTTPRoutes.Items[j]
+		for k, rule := range route.Spec.Rules {
+			for _, ref := range rule.BackendRefs {
+				if !ref.IsService() {
+					continue
+				}
+				namespace := route.Namespace
+				if ref.Namespace != nil && *ref.Namespace != "" {
+					namespace = *ref.Namespace
+				}
+				// Services in namespaces left out by the object filter were
+				// not fetched.
+				if !objects.Filter.Includes(namespace) {
+					continue
+				}
+				service, ok := services[kube.Identifier{Name: ref.Name, Namespace: namespace}]
+				var message string
+				switch {
+				case !ok:
+					message = fmt.Sprintf("Backend of rule %d refers to service `%s/%s`, which does not exist", k+1, namespace, ref.Name)
+				case ref.Port != nil && !hasServicePort(service, "", *ref.Port):
+					message = fmt.Sprintf("Backend of rule %d refers to port `%d` of service `%s/%s`, which does not exist", k+1, *ref.Port, namespace, ref.Name)
+				default:
+					continue
+				}
+				d := checks.Diagnostic{
+					Severity: checks.Error,
+					Message:  message,
+					Kind:     checks.HTTPRoute,
+					Object:   &route.ObjectMeta,
+					Owners:   route.ObjectMeta.GetOwnerReferences(),
+				}
+				diagnostics = append(diagnostics, d)
+			}
+		}
+	}
+	return diagnostics, nil
+}
+
+type ingressTLSCheck struct{}
+
+// Name returns a unique name for this check.
+func (i *ingressTLSCheck) Name() string {
+	return "ingress-tls"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (i *ingressTLSCheck) Groups() []string {
+	return []string{"networking"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (i *ingressTLSCheck) Description() string {
+	return "Checks if there are ingresses whose TLS sections refer to secrets that do not exist or are not TLS secrets"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (i *ingressTLSCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Ingresses, kube.Secrets}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (i *ingressTLSCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	secrets := make(map[kube.Identifier]*corev1.Secret)
+	for j := range objects.Secrets.Items {
+		secret := &objects.Secrets.Items[j]
+		secrets[kube.Identifier{Name: secret.Name, Namespace: secret.Namespace}] = secret
+	}
+
+	for j := range objects.Ingresses.Items {
+		ingress := &objects.Ingresses.Items[j]
+		for _, tls := range ingress.Spec.TLS {
+			// An empty secret name selects the controller's default
+			// certificate.
+			if tls.SecretName == "" {
+				continue
+			}
+			secret, ok := secrets[kube.Identifier{Name: tls.SecretName, Namespace: ingress.Namespace}]
+			var message string
+			switch {
+			case !ok:
+				message = fmt.Sprintf("TLS secret `%s` does not exist", tls.SecretName)
+			case secret.Type != corev1.SecretTypeTLS:
+				message = fmt.Sprintf("TLS secret `%s` has type `%s` instead of `%s`", tls.SecretName, secret.Type, corev1.SecretTypeTLS)
+			default:
+				continue
+			}
+			d := checks.Diagnostic{
+				Severity: checks.Error,
+				Message:  message,
+				Kind:     checks.Ingress,
+				Object:   &ingress.ObjectMeta,
+				Owners:   ingress.ObjectMeta.GetOwnerReferences(),
+			}
+			if len(tls.Hosts) > 0 {
+				d.Details = fmt.Sprintf("Hosts: %s", strings.Join(tls.Hosts, ", "))
+			}
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	return diagnostics, nil
+}
+
+type ingressDuplicateRuleCheck struct{}
+
+// Name returns a unique name for this check.
+func (i *ingressDuplicateRuleCheck) Name() string {
+	return "ingress-duplicate-rule"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (i *ingressDuplicateRuleCheck) Groups() []string {
+	return []string{"networking"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (i *ingressDuplicateRuleCheck) Description() string {
+	return "Checks if there are host and path rules defined by more than one ingress of the same class"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (i *ingressDuplicateRuleCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.IngressClasses, kube.Ingresses}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (i *ingressDuplicateRuleCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	type rule struct {
+		class string
+		host  string
+		path  string
+	}
+	defaultClass := defaultIngressClass(objects)
+	owners := make(map[rule][]*networkingv1.Ingress)
+	var rules []rule
+	for j := range objects.Ingresses.Items {
+		ingress := &objects.Ingresses.Items[j]
+		class := ingressClassName(ingress, defaultClass)
+		seen := make(map[rule]struct{})
+		for _, r := range ingress.Spec.Rules {
+			if r.HTTP == nil {
+				continue
+			}
+			for _, path := range r.HTTP.Paths {
+				key := rule{class: class, host: r.Host, path: path.Path}
+				if _, ok := seen[key]; ok {
+					continue
+				}
+				seen[key] = struct{}{}
+				if _, ok := owners[key]; !ok {
+					rules = append(rules, key)
+				}
+				owners[key] = append(owners[key], ingress)
+			}
+		}
+	}
+
+	for _, key := range rules {
+		ingresses := owners[key]
+		if len(ingresses) < 2 {
+			continue
+		}
+		host := key.host
+		if host == "" {
+			host = "*"
+		}
+		for _, ingress := range ingresses {
+			var others []string
+			for _, other := range ingresses {
+				if other != ingress {
+					others = append(others, fmt.Sprintf("%s/%s", other.Namespace, other.Name))
+				}
+			}
+			sort.Strings(others)
+			d := checks.Diagnostic{
+				Severity: checks.Warning,
+				Message:  fmt.Sprintf("Host `%s` path `%s` is also defined by other ingresses. Which backend receives the traffic depends on the ingress controller.", host, key.path),
+				Kind:     checks.Ingress,
+				Object:   &ingress.ObjectMeta,
+				Owners:   ingress.ObjectMeta.GetOwnerReferences(),
+				Details:  fmt.Sprintf("Other ingresses: %s", strings.Join(others, ", ")),
+			}
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	return diagnostics, nil
+}
+
+type ingressClassCheck struct{}
+
+// Name returns a unique name for this check.
+func (i *ingressClassCheck) Name() string {
+	return "ingress-class"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (i *ingressClassCheck) Groups() []string {
+	return []string{"networking"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (i *ingressClassCheck) Description() string {
+	return "Checks if there are ingresses that no ingress class applies to"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (i *ingressClassCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.IngressClasses, kube.Ingresses}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (i *ingressClassCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	classes := make(map[string]struct{})
+	for _, class := range objects.IngressClasses.Items {
+		classes[class.Name] = struct{}{}
+	}
+	defaultClass := defaultIngressClass(objects)
+
+	for j := range objects.Ingresses.Items {
+		ingress := &objects.Ingresses.Items[j]
+		var message string
+		switch {
+		case ingress.Spec.IngressClassName != nil:
+			if _, ok := classes[*ingress.Spec.IngressClassName]; ok {
+				continue
+			}
+			message = fmt.Sprintf("Ingress class `%s` does not exist", *ingress.Spec.IngressClassName)
+		case ingress.Annotations[legacyIngressClassAnnotation] != "":
+			continue
+		case defaultClass == "":
+			message = "Ingress does not specify an ingress class and there is no default ingress class"
+		default:
+			continue
+		}
+		d := checks.Diagnostic{
+			Severity: checks.Warning,
+			Message:  message,
+			Kind:     checks.Ingress,
+			Object:   &ingress.ObjectMeta,
+			Owners:   ingress.ObjectMeta.GetOwnerReferences(),
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics, nil
+}
+
+// ingressBackend is a backend of an ingress along with the rule it belongs
+// to.
+type ingressBackend struct {
+	host    string
+	path    string
+	backend networkingv1.IngressBackend
+	// isDefault is set for the default backend of the ingress.
+	isDefault bool
+}
+
+func (b ingressBackend) describe() string {
+	if b.isDefault {
+		return "the default backend"
+	}
+	host := b.host
+	if host == "" {
+		host = "*"
+	}
+	return fmt.Sprintf("host `%s` path `%s`", host, b.path)
+}
+
+func ingressBackends(ingress *networkingv1.Ingress) []ingressBackend {
+	var backends []ingressBackend
+	if ingress.Spec.DefaultBackend != nil {
+		backends = append(backends, ingressBackend{backend: *ingress.Spec.DefaultBackend, isDefault: true})
+	}
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			backends = append(backends, ingressBackend{host: rule.Host, path: path.Path, backend: path.Backend})
+		}
+	}
+	return backends
+}
+
+// hasServicePort reports whether a service has a port with the given name, or
+// number if the name is empty.
+func hasServicePort(service *corev1.Service, name string, number int32) bool {
+	for _, port := range service.Spec.Ports {
+		if name != "" && port.Name == name {
+			return true
+		}
+		if name == "" && port.Port == number {
+			return true
+		}
+	}
+	return false
+}
+
+func describeServiceBackendPort(port networkingv1.ServiceBackendPort) string {
+	if port.Name != "" {
+		return port.Name
+	}
+	return fmt.Sprintf("%d", port.Number)
+}
+
+// defaultIngressClass returns the name of the default ingress class, if any.
+func defaultIngressClass(objects *kube.Objects) string {
+	for _, class := range objects.IngressClasses.Items {
+		if class.Annotations[defaultIngressClassAnnotation] == "true" {
+			return class.Name
+		}
+	}
+	return ""
+}
+
+// ingressClassName returns the class an ingress is handled by.
+func ingressClassName(ingress *networkingv1.Ingress, defaultClass string) string {
+	if ingress.Spec.IngressClassName != nil {
+		return *ingress.Spec.IngressClassName
+	}
+	if class := ingress.Annotations[legacyIngressClassAnnotation]; class != "" {
+		return class
+	}
+	return defaultClass
+}
@@ -0,0 +1,352 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package networking
+
+import (
+	"testing"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIngressChecksMeta(t *testing.T) {
+	for name, check := range map[string]checks.Check{
+		"ingress-backend":        &ingressBackendCheck{},
+		"ingress-tls":            &ingressTLSCheck{},
+		"ingress-duplicate-rule": &ingressDuplicateRuleCheck{},
+		"ingress-class":          &ingressClassCheck{},
+	} {
+		assert.Equal(t, name, check.Name())
+		assert.Equal(t, []string{"networking"}, check.Groups())
+		assert.NotEmpty(t, check.Description())
+
+		registered, err := checks.Get(name)
+		assert.NoError(t, err)
+		assert.IsType(t, check, registered)
+	}
+}
+
+func TestIngressBackendError(t *testing.T) {
+	tests := []struct {
+		name     string
+		backend  networkingv1.IngressServiceBackend
+		expected []string
+	}{
+		{
+			name:     "port by number",
+			backend:  serviceBackend("web", "", 80),
+			expected: nil,
+		},
+		{
+			name:     "port by name",
+			backend:  serviceBackend("web", "http", 0),
+			expected: nil,
+		},
+		{
+			name:     "missing service",
+			backend:  serviceBackend("api", "", 80),
+			expected: []string{"Backend for host `example.com` path `/` refers to service `api`, which does not exist"},
+		},
+		{
+			name:     "missing port number",
+			backend:  serviceBackend("web", "", 8080),
+			expected: []string{"Backend for host `example.com` path `/` refers to port `8080` of service `web`, which does not exist"},
+		},
+		{
+			name:     "missing port name",
+			backend:  serviceBackend("web", "https", 0),
+			expected: []string{"Backend for host `example.com` path `/` refers to port `https` of service `web`, which does not exist"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := initIngresses()
+			withIngress(objs, "web", nil, rule("example.com", "/", test.backend))
+
+			d, err := (&ingressBackendCheck{}).Run(objs)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, messagesFor(t, d, checks.Ingress, &objs.Ingresses.Items[0].ObjectMeta))
+		})
+	}
+}
+
+func TestIngressDefaultBackendError(t *testing.T) {
+	objs := initIngresses()
+	withIngress(objs, "web", nil)
+	backend := serviceBackend("api", "", 80)
+	objs.Ingresses.Items[0].Spec.DefaultBackend = &networkingv1.IngressBackend{Service: &backend}
+
+	d, err := (&ingressBackendCheck{}).Run(objs)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Backend for the default backend refers to service `api`, which does not exist"}, messagesFor(t, d, checks.Ingress, &objs.Ingresses.Items[0].ObjectMeta))
+}
+
+func TestHTTPRouteBackendError(t *testing.T) {
+	port := func(p int32) *int32 { return &p }
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name     string
+		ref      kube.HTTPBackendRef
+		filter   kube.ObjectFilter
+		expected []string
+	}{
+		{
+			name:     "existing service",
+			ref:      kube.HTTPBackendRef{Name: "web", Port: port(80)},
+			expected: nil,
+		},
+		{
+			name:     "missing service",
+			ref:      kube.HTTPBackendRef{Name: "api", Port: port(80)},
+			expected: []string{"Backend of rule 1 refers to service `k8s/api`, which does not exist"},
+		},
+		{
+			name:     "service in another namespace",
+			ref:      kube.HTTPBackendRef{Name: "web", Namespace: str("monitoring"), Port: port(80)},
+			expected: []string{"Backend of rule 1 refers to service `monitoring/web`, which does not exist"},
+		},
+		{
+			name:     "service in a namespace that was not fetched",
+			ref:      kube.HTTPBackendRef{Name: "web", Namespace: str("monitoring"), Port: port(80)},
+			filter:   kube.ObjectFilter{IncludeNamespace: "k8s"},
+			expected: nil,
+		},
+		{
+			name:     "service in an excluded namespace",
+			ref:      kube.HTTPBackendRef{Name: "web", Namespace: str("monitoring"), Port: port(80)},
+			filter:   kube.ObjectFilter{ExcludeNamespace: "monitoring"},
+			expected: nil,
+		},
+		{
+			name:     "missing port",
+			ref:      kube.HTTPBackendRef{Name: "web", Port: port(8080)},
+			expected: []string{"Backend of rule 1 refers to port `8080` of service `k8s/web`, which does not exist"},
+		},
+		{
+			name:     "not a service",
+			ref:      kube.HTTPBackendRef{Group: str("example.com"), Kind: str("Bucket"), Name: "assets"},
+			expected: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := initIngresses()
+			objs.Filter = test.filter
+			objs.HTTPRoutes.Items = []kube.HTTPRoute{{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "k8s"},
+				Spec: kube.HTTPRouteSpec{
+					Rules: []kube.HTTPRouteRule{{BackendRefs: []kube.HTTPBackendRef{test.ref}}},
+				},
+			}}
+
+			d, err := (&ingressBackendCheck{}).Run(objs)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, messagesFor(t, d, checks.HTTPRoute, &objs.HTTPRoutes.Items[0].ObjectMeta))
+		})
+	}
+}
+
+func TestIngressTLSError(t *testing.T) {
+	tests := []struct {
+		name     string
+		secret   string
+		expected []string
+	}{
+		{
+			name:     "tls secret",
+			secret:   "web-tls",
+			expected: nil,
+		},
+		{
+			name:     "default certificate",
+			secret:   "",
+			expected: nil,
+		},
+		{
+			name:     "missing secret",
+			secret:   "api-tls",
+			expected: []string{"TLS secret `api-tls` does not exist"},
+		},
+		{
+			name:     "wrong secret type",
+			secret:   "web-credentials",
+			expected: []string{"TLS secret `web-credentials` has type `Opaque` instead of `kubernetes.io/tls`"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := initIngresses()
+			withIngress(objs, "web", nil, rule("example.com", "/", serviceBackend("web", "", 80)))
+			objs.Ingresses.Items[0].Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{"example.com"}, SecretName: test.secret}}
+
+			d, err := (&ingressTLSCheck{}).Run(objs)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, messagesFor(t, d, checks.Ingress, &objs.Ingresses.Items[0].ObjectMeta))
+			for _, diagnostic := range d {
+				assert.Equal(t, "Hosts: example.com", diagnostic.Details)
+			}
+		})
+	}
+}
+
+func TestIngressDuplicateRuleWarning(t *testing.T) {
+	nginx := "nginx"
+	traefik := "traefik"
+
+	objs := initIngresses()
+	withIngress(objs, "web", &nginx, rule("example.com", "/", serviceBackend("web", "", 80)), rule("example.com", "/api", serviceBackend("web", "", 80)))
+	withIngress(objs, "web-canary", &nginx, rule("example.com", "/", serviceBackend("web", "", 80)))
+	withIngress(objs, "web-traefik", &traefik, rule("example.com", "/", serviceBackend("web", "", 80)))
+	withIngress(objs, "api", &nginx, rule("api.example.com", "/api", serviceBackend("web", "", 80)))
+
+	d, err := (&ingressDuplicateRuleCheck{}).Run(objs)
+	assert.NoError(t, err)
+	assert.Equal(t, []checks.Diagnostic{
+		{
+			Severity: checks.Warning,
+			Message:  "Host `example.com` path `/` is also defined by other ingresses. Which backend receives the traffic depends on the ingress controller.",
+			Kind:     checks.Ingress,
+			Object:   &objs.Ingresses.Items[0].ObjectMeta,
+			Details:  "Other ingresses: k8s/web-canary",
+		},
+		{
+			Severity: checks.Warning,
+			Message:  "Host `example.com` path `/` is also defined by other ingresses. Which backend receives the traffic depends on the ingress controller.",
+			Kind:     checks.Ingress,
+			Object:   &objs.Ingresses.Items[1].ObjectMeta,
+			Details:  "Other ingresses: k8s/web",
+		},
+	}, d)
+}
+
+func TestIngressClassWarning(t *testing.T) {
+	nginx := "nginx"
+	missing := "haproxy"
+
+	tests := []struct {
+		name         string
+		class        *string
+		annotations  map[string]string
+		defaultClass bool
+		expected     []string
+	}{
+		{
+			name:     "existing class",
+			class:    &nginx,
+			expected: nil,
+		},
+		{
+			name:     "missing class",
+			class:    &missing,
+			expected: []string{"Ingress class `haproxy` does not exist"},
+		},
+		{
+			name:         "default class",
+			defaultClass: true,
+			expected:     nil,
+		},
+		{
+			name:        "legacy annotation",
+			annotations: map[string]string{legacyIngressClassAnnotation: "nginx"},
+			expected:    nil,
+		},
+		{
+			name:     "no class and no default",
+			expected: []string{"Ingress does not specify an ingress class and there is no default ingress class"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := initIngresses()
+			if test.defaultClass {
+				objs.IngressClasses.Items[0].Annotations = map[string]string{defaultIngressClassAnnotation: "true"}
+			}
+			withIngress(objs, "web", test.class, rule("example.com", "/", serviceBackend("web", "", 80)))
+			objs.Ingresses.Items[0].Annotations = test.annotations
+
+			d, err := (&ingressClassCheck{}).Run(objs)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, messagesFor(t, d, checks.Ingress, &objs.Ingresses.Items[0].ObjectMeta))
+		})
+	}
+}
+
+func initIngresses() *kube.Objects {
+	objs := initServices()
+	withService(objs, "web", map[string]string{"app": "web"}, corev1.ServicePort{Name: "http", Port: 80})
+	objs.Secrets = &corev1.SecretList{
+		Items: []corev1.Secret{
+			{ObjectMeta: metav1.ObjectMeta{Name: "web-tls", Namespace: "k8s"}, Type: corev1.SecretTypeTLS},
+			{ObjectMeta: metav1.ObjectMeta{Name: "web-credentials", Namespace: "k8s"}, Type: corev1.SecretTypeOpaque},
+		},
+	}
+	objs.Ingresses = &networkingv1.IngressList{}
+	objs.IngressClasses = &networkingv1.IngressClassList{
+		Items: []networkingv1.IngressClass{{ObjectMeta: metav1.ObjectMeta{Name: "nginx"}}},
+	}
+	objs.HTTPRoutes = &kube.HTTPRouteList{}
+	return objs
+}
+
+func withIngress(objs *kube.Objects, name string, class *string, rules ...networkingv1.IngressRule) {
+	objs.Ingresses.Items = append(objs.Ingresses.Items, networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "k8s"},
+		Spec:       networkingv1.IngressSpec{IngressClassName: class, Rules: rules},
+	})
+}
+
+func rule(host, path string, backend networkingv1.IngressServiceBackend) networkingv1.IngressRule {
+	return networkingv1.IngressRule{
+		Host: host,
+		IngressRuleValue: networkingv1.IngressRuleValue{
+			HTTP: &networkingv1.HTTPIngressRuleValue{
+				Paths: []networkingv1.HTTPIngressPath{{
+					Path:    path,
+					Backend: networkingv1.IngressBackend{Service: &backend},
+				}},
+			},
+		},
+	}
+}
+
+func serviceBackend(name, portName string, portNumber int32) networkingv1.IngressServiceBackend {
+	return networkingv1.IngressServiceBackend{
+		Name: name,
+		Port: networkingv1.ServiceBackendPort{Name: portName, Number: portNumber},
+	}
+}
+
+// messagesFor returns the messages of the diagnostics after asserting that
+// they are all about the given object.
+func messagesFor(t *testing.T, diagnostics []checks.Diagnostic, kind checks.Kind, object *metav1.ObjectMeta) []string {
+	var messages []string
+	for _, d := range diagnostics {
+		assert.Equal(t, kind, d.Kind)
+		assert.Equal(t, object, d.Object)
+		messages = append(messages, d.Message)
+	}
+	return messages
+}
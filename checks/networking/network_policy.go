@@ -0,0 +1,143 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package networking
+
+import (
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// livePods returns the pods that have not terminated, grouped by namespace.
+func livePods(objects *kube.Objects) map[string][]*corev1.Pod {
+	pods := make(map[string][]*corev1.Pod)
+	for i := range objects.Pods.Items {
+		pod := &objects.Pods.Items[i]
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		pods[pod.Namespace] = append(pods[pod.Namespace], pod)
+	}
+	return pods
+}
+
+// policyPods returns the pods network policies apply to, grouped by
+// namespace. Pods in system namespaces, pods on the host network and pods
+// that have terminated are left out.
+func policyPods(objects *kube.Objects) map[string][]*corev1.Pod {
+	pods := make(map[string][]*corev1.Pod)
+	for namespace, live := range livePods(objects) {
+		if checks.IsSystemNamespace(namespace) {
+			continue
+		}
+		for _, pod := range live {
+			if !pod.Spec.HostNetwork {
+				pods[namespace] = append(pods[namespace], pod)
+			}
+		}
+	}
+	return pods
+}
+
+// policiesByNamespace returns the network policies grouped by namespace.
+func policiesByNamespace(objects *kube.Objects) map[string][]*networkingv1.NetworkPolicy {
+	policies := make(map[string][]*networkingv1.NetworkPolicy)
+	for i := range objects.NetworkPolicies.Items {
+		policy := &objects.NetworkPolicies.Items[i]
+		policies[policy.Namespace] = append(policies[policy.Namespace], policy)
+	}
+	return policies
+}
+
+// selects reports whether a network policy applies to a pod.
+func selects(policy *networkingv1.NetworkPolicy, pod *corev1.Pod) bool {
+	if policy.Namespace != pod.Namespace {
+		return false
+	}
+	return matches(&policy.Spec.PodSelector, pod.Labels)
+}
+
+// matches reports whether a label selector matches a set of labels. Invalid
+// selectors match nothing, like they do in the API server.
+func matches(selector *metav1.LabelSelector, set map[string]string) bool {
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false
+	}
+	return s.Matches(labels.Set(set))
+}
+
+// isolatesIngress reports whether a network policy restricts ingress traffic
+// to the pods it selects. Policies without policy types always apply to
+// ingress.
+func isolatesIngress(policy *networkingv1.NetworkPolicy) bool {
+	if len(policy.Spec.PolicyTypes) == 0 {
+		return true
+	}
+	for _, t := range policy.Spec.PolicyTypes {
+		if t == networkingv1.PolicyTypeIngress {
+			return true
+		}
+	}
+	return false
+}
+
+// isDefaultDeny reports whether a network policy denies all ingress traffic
+// to every pod in its namespace.
+func isDefaultDeny(policy *networkingv1.NetworkPolicy) bool {
+	return isEmptySelector(&policy.Spec.PodSelector) && isolatesIngress(policy) && len(policy.Spec.Ingress) == 0
+}
+
+// hasDefaultDeny reports whether any of the policies is a default-deny
+// policy.
+func hasDefaultDeny(policies []*networkingv1.NetworkPolicy) bool {
+	for _, policy := range policies {
+		if isDefaultDeny(policy) {
+			return true
+		}
+	}
+	return false
+}
+
+// isolated reports whether any of the policies restricts ingress traffic to a
+// pod.
+func isolated(policies []*networkingv1.NetworkPolicy, pod *corev1.Pod) bool {
+	for _, policy := range policies {
+		if isolatesIngress(policy) && selects(policy, pod) {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmptySelector(selector *metav1.LabelSelector) bool {
+	return len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 0
+}
+
+// namespaceMeta returns the metadata of a namespace, or metadata carrying
+// just the name if the namespace was not fetched.
+func namespaceMeta(objects *kube.Objects, name string) *metav1.ObjectMeta {
+	for i := range objects.Namespaces.Items {
+		if objects.Namespaces.Items[i].Name == name {
+			return &objects.Namespaces.Items[i].ObjectMeta
+		}
+	}
+	return &metav1.ObjectMeta{Name: name}
+}
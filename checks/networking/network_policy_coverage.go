@@ -0,0 +1,199 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package networking
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func init() {
+	checks.Register(&defaultDenyCheck{})
+	checks.Register(&networkPolicyCoverageCheck{})
+	checks.Register(&networkPolicySummaryCheck{})
+}
+
+type defaultDenyCheck struct{}
+
+// Name returns a unique name for this check.
+func (d *defaultDenyCheck) Name() string {
+	return "network-policy-default-deny"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (d *defaultDenyCheck) Groups() []string {
+	return []string{"networking"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (d *defaultDenyCheck) Description() string {
+	return "Checks if there are namespaces without a network policy that denies ingress traffic by default"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (d *defaultDenyCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Namespaces, kube.NetworkPolicies, kube.Pods}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (d *defaultDenyCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	pods := policyPods(objects)
+	policies := policiesByNamespace(objects)
+	for _, namespace := range sortedNamespaces(pods) {
+		if hasDefaultDeny(policies[namespace]) {
+			continue
+		}
+		meta := namespaceMeta(objects, namespace)
+		d := checks.Diagnostic{
+			Severity: checks.Warning,
+			Message:  "Namespace has no default-deny network policy. Pods not selected by another policy accept traffic from any source.",
+			Kind:     checks.Namespace,
+			Object:   meta,
+			Owners:   meta.GetOwnerReferences(),
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics, nil
+}
+
+type networkPolicyCoverageCheck struct{}
+
+// Name returns a unique name for this check.
+func (c *networkPolicyCoverageCheck) Name() string {
+	return "network-policy-coverage"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (c *networkPolicyCoverageCheck) Groups() []string {
+	return []string{"networking"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (c *networkPolicyCoverageCheck) Description() string {
+	return "Checks if there are pods that are not selected by any network policy restricting ingress traffic"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (c *networkPolicyCoverageCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.NetworkPolicies, kube.Pods}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (c *networkPolicyCoverageCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	pods := policyPods(objects)
+	policies := policiesByNamespace(objects)
+	seen := make(map[string]struct{})
+	for _, namespace := range sortedNamespaces(pods) {
+		for _, pod := range pods[namespace] {
+			workload := checks.WorkloadForPod(pod)
+			if _, ok := seen[workload.Key()]; ok {
+				continue
+			}
+			seen[workload.Key()] = struct{}{}
+			if isolated(policies[namespace], pod) {
+				continue
+			}
+			d := checks.Diagnostic{
+				Severity: checks.Warning,
+				Message:  "Pod is not selected by any network policy restricting ingress and accepts traffic from any source",
+				Kind:     workload.Kind,
+				Object:   workload.Object,
+				Owners:   pod.ObjectMeta.GetOwnerReferences(),
+			}
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	return diagnostics, nil
+}
+
+type networkPolicySummaryCheck struct{}
+
+// Name returns a unique name for this check.
+func (s *networkPolicySummaryCheck) Name() string {
+	return "network-policy-summary"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (s *networkPolicySummaryCheck) Groups() []string {
+	return []string{"networking"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (s *networkPolicySummaryCheck) Description() string {
+	return "Summarizes how many pods in each namespace are isolated by network policies"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (s *networkPolicySummaryCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Namespaces, kube.NetworkPolicies, kube.Pods}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (s *networkPolicySummaryCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	pods := policyPods(objects)
+	policies := policiesByNamespace(objects)
+	for _, namespace := range sortedNamespaces(pods) {
+		var open []string
+		for _, pod := range pods[namespace] {
+			if !isolated(policies[namespace], pod) {
+				open = append(open, pod.Name)
+			}
+		}
+		total := len(pods[namespace])
+		meta := namespaceMeta(objects, namespace)
+		d := checks.Diagnostic{
+			Severity: checks.Suggestion,
+			Message:  fmt.Sprintf("%d of %d pods are isolated for ingress, %d are open", total-len(open), total, len(open)),
+			Kind:     checks.Namespace,
+			Object:   meta,
+			Owners:   meta.GetOwnerReferences(),
+		}
+		if len(open) > 0 {
+			sort.Strings(open)
+			d.Details = fmt.Sprintf("Open pods: %s", strings.Join(open, ", "))
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics, nil
+}
+
+// sortedNamespaces returns the namespaces of the pods in a stable order.
+func sortedNamespaces(pods map[string][]*corev1.Pod) []string {
+	var namespaces []string
+	for namespace := range pods {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package networking
+
+import (
+	"testing"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNetworkPolicyChecksMeta(t *testing.T) {
+	for name, check := range map[string]checks.Check{
+		"network-policy-default-deny": &defaultDenyCheck{},
+		"network-policy-coverage":     &networkPolicyCoverageCheck{},
+		"network-policy-summary":      &networkPolicySummaryCheck{},
+		"network-policy-no-pods":      &networkPolicyNoPodsCheck{},
+		"network-policy-references":   &networkPolicyReferencesCheck{},
+	} {
+		assert.Equal(t, name, check.Name())
+		assert.Equal(t, []string{"networking"}, check.Groups())
+		assert.NotEmpty(t, check.Description())
+
+		registered, err := checks.Get(name)
+		assert.NoError(t, err)
+		assert.IsType(t, check, registered)
+	}
+}
+
+func TestDefaultDenyWarning(t *testing.T) {
+	tests := []struct {
+		name     string
+		objs     func() *kube.Objects
+		expected []string
+	}{
+		{
+			name:     "no pods",
+			objs:     initObjects,
+			expected: nil,
+		},
+		{
+			name: "no policies",
+			objs: func() *kube.Objects {
+				objs := initObjects()
+				withPod(objs, "k8s", "web", nil)
+				withPod(objs, "monitoring", "prometheus", nil)
+				return objs
+			},
+			expected: []string{"k8s", "monitoring"},
+		},
+		{
+			name: "default deny",
+			objs: func() *kube.Objects {
+				objs := initObjects()
+				withPod(objs, "k8s", "web", nil)
+				withPolicy(objs, "k8s", "deny", networkingv1.NetworkPolicySpec{})
+				return objs
+			},
+			expected: nil,
+		},
+		{
+			name: "allow all is not default deny",
+			objs: func() *kube.Objects {
+				objs := initObjects()
+				withPod(objs, "k8s", "web", nil)
+				withPolicy(objs, "k8s", "allow", networkingv1.NetworkPolicySpec{
+					Ingress: []networkingv1.NetworkPolicyIngressRule{{}},
+				})
+				return objs
+			},
+			expected: []string{"k8s"},
+		},
+		{
+			name: "egress only is not default deny",
+			objs: func() *kube.Objects {
+				objs := initObjects()
+				withPod(objs, "k8s", "web", nil)
+				withPolicy(objs, "k8s", "deny-egress", networkingv1.NetworkPolicySpec{
+					PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+				})
+				return objs
+			},
+			expected: []string{"k8s"},
+		},
+		{
+			name: "system namespaces and host network pods",
+			objs: func() *kube.Objects {
+				objs := initObjects()
+				withPod(objs, metav1.NamespaceSystem, "coredns", nil)
+				withPod(objs, "k8s", "node-exporter", nil)
+				objs.Pods.Items[1].Spec.HostNetwork = true
+				return objs
+			},
+			expected: nil,
+		},
+	}
+
+	check := &defaultDenyCheck{}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d, err := check.Run(test.objs())
+			assert.NoError(t, err)
+			var namespaces []string
+			for _, diagnostic := range d {
+				assert.Equal(t, checks.Namespace, diagnostic.Kind)
+				namespaces = append(namespaces, diagnostic.Object.Name)
+			}
+			assert.Equal(t, test.expected, namespaces)
+		})
+	}
+}
+
+func TestNetworkPolicyCoverageWarning(t *testing.T) {
+	objs := initObjects()
+	withPod(objs, "k8s", "web", map[string]string{"app": "web"})
+	withPod(objs, "k8s", "db", map[string]string{"app": "db"})
+	withPod(objs, "k8s", "cache-1", map[string]string{"app": "cache"})
+	withPod(objs, "k8s", "cache-2", map[string]string{"app": "cache"})
+	controller := true
+	for i := 2; i < 4; i++ {
+		objs.Pods.Items[i].OwnerReferences = []metav1.OwnerReference{{Kind: "StatefulSet", Name: "cache", Controller: &controller}}
+	}
+	withPolicy(objs, "k8s", "db", networkingv1.NetworkPolicySpec{PodSelector: selector(map[string]string{"app": "db"})})
+	withPolicy(objs, "k8s", "web-egress", networkingv1.NetworkPolicySpec{
+		PodSelector: selector(map[string]string{"app": "web"}),
+		PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+	})
+
+	d, err := (&networkPolicyCoverageCheck{}).Run(objs)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []checks.Diagnostic{
+		{
+			Severity: checks.Warning,
+			Message:  "Pod is not selected by any network policy restricting ingress and accepts traffic from any source",
+			Kind:     checks.Pod,
+			Object:   &objs.Pods.Items[0].ObjectMeta,
+		},
+		{
+			Severity: checks.Warning,
+			Message:  "Pod is not selected by any network policy restricting ingress and accepts traffic from any source",
+			Kind:     checks.StatefulSet,
+			Object:   &metav1.ObjectMeta{Name: "cache", Namespace: "k8s"},
+			Owners:   objs.Pods.Items[2].OwnerReferences,
+		},
+	}, d)
+}
+
+func TestNetworkPolicySummary(t *testing.T) {
+	objs := initObjects()
+	withPod(objs, "k8s", "web", map[string]string{"app": "web"})
+	withPod(objs, "k8s", "db", map[string]string{"app": "db"})
+	withPod(objs, "k8s", "job", map[string]string{"app": "job"})
+	objs.Pods.Items[2].Status.Phase = corev1.PodSucceeded
+	withPod(objs, "monitoring", "prometheus", nil)
+	withPolicy(objs, "k8s", "db", networkingv1.NetworkPolicySpec{PodSelector: selector(map[string]string{"app": "db"})})
+	withPolicy(objs, "monitoring", "deny", denyAll())
+
+	d, err := (&networkPolicySummaryCheck{}).Run(objs)
+	assert.NoError(t, err)
+	assert.Equal(t, []checks.Diagnostic{
+		{
+			Severity: checks.Suggestion,
+			Message:  "1 of 2 pods are isolated for ingress, 1 are open",
+			Kind:     checks.Namespace,
+			Object:   &objs.Namespaces.Items[0].ObjectMeta,
+			Details:  "Open pods: web",
+		},
+		{
+			Severity: checks.Suggestion,
+			Message:  "1 of 1 pods are isolated for ingress, 0 are open",
+			Kind:     checks.Namespace,
+			Object:   &objs.Namespaces.Items[1].ObjectMeta,
+		},
+	}, d)
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package networking
+
+import (
+	"fmt"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func init() {
+	checks.Register(&networkPolicyNoPodsCheck{})
+	checks.Register(&networkPolicyReferencesCheck{})
+}
+
+type networkPolicyNoPodsCheck struct{}
+
+// Name returns a unique name for this check.
+func (n *networkPolicyNoPodsCheck) Name() string {
+	return "network-policy-no-pods"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (n *networkPolicyNoPodsCheck) Groups() []string {
+	return []string{"networking"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (n *networkPolicyNoPodsCheck) Description() string {
+	return "Checks if there are network policies whose pod selector does not match any pods"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (n *networkPolicyNoPodsCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.NetworkPolicies, kube.Pods}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (n *networkPolicyNoPodsCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	// Policies in system namespaces and policies selecting pods on the host
+	// network still select pods, even if they have no effect on them.
+	pods := livePods(objects)
+	for i := range objects.NetworkPolicies.Items {
+		policy := &objects.NetworkPolicies.Items[i]
+		// Policies selecting all pods are namespace defaults and are expected
+		// to apply to pods created later.
+		if isEmptySelector(&policy.Spec.PodSelector) {
+			continue
+		}
+		if len(selectedPods(policy, pods[policy.Namespace])) > 0 {
+			continue
+		}
+		d := checks.Diagnostic{
+			Severity: checks.Warning,
+			Message:  "Network policy pod selector does not match any pods",
+			Kind:     checks.NetworkPolicy,
+			Object:   &policy.ObjectMeta,
+			Owners:   policy.ObjectMeta.GetOwnerReferences(),
+			Details:  fmt.Sprintf("Pod selector: %s", metav1.FormatLabelSelector(&policy.Spec.PodSelector)),
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics, nil
+}
+
+type networkPolicyReferencesCheck struct{}
+
+// Name returns a unique name for this check.
+func (r *networkPolicyReferencesCheck) Name() string {
+	return "network-policy-references"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (r *networkPolicyReferencesCheck) Groups() []string {
+	return []string{"networking"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (r *networkPolicyReferencesCheck) Description() string {
+	return "Checks if there are network policy ingress rules that reference namespaces or ports that do not exist"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (r *networkPolicyReferencesCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Namespaces, kube.NetworkPolicies, kube.Pods}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (r *networkPolicyReferencesCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	pods := policyPods(objects)
+	for i := range objects.NetworkPolicies.Items {
+		policy := &objects.NetworkPolicies.Items[i]
+		selected := selectedPods(policy, pods[policy.Namespace])
+		warn := func(message string) {
+			d := checks.Diagnostic{
+				Severity: checks.Warning,
+				Message:  message,
+				Kind:     checks.NetworkPolicy,
+				Object:   &policy.ObjectMeta,
+				Owners:   policy.ObjectMeta.GetOwnerReferences(),
+			}
+			diagnostics = append(diagnostics, d)
+		}
+
+		for j, rule := range policy.Spec.Ingress {
+			for _, peer := range rule.From {
+				if peer.NamespaceSelector == nil || isEmptySelector(peer.NamespaceSelector) {
+					continue
+				}
+				if len(objects.Namespaces.Items) > 0 && !matchesNamespace(objects, peer.NamespaceSelector) {
+					warn(fmt.Sprintf("Ingress rule %d allows traffic from namespaces matching `%s`, but no namespace matches", j+1, metav1.FormatLabelSelector(peer.NamespaceSelector)))
+				}
+			}
+			// Without selected pods there is nothing to compare the ports
+			// against; network-policy-no-pods reports the policy instead.
+			if len(selected) == 0 {
+				continue
+			}
+			for _, port := range rule.Ports {
+				if port.Port == nil || exposesPort(selected, port) {
+					continue
+				}
+				if port.Port.Type == intstr.Int && !declaresPorts(selected) {
+					// Numeric ports need not be declared by containers.
+					continue
+				}
+				warn(fmt.Sprintf("Ingress rule %d allows port `%s`, which no selected pod exposes", j+1, port.Port.String()))
+			}
+		}
+	}
+	return diagnostics, nil
+}
+
+// selectedPods returns the pods a network policy applies to.
+func selectedPods(policy *networkingv1.NetworkPolicy, pods []*corev1.Pod) []*corev1.Pod {
+	var selected []*corev1.Pod
+	for _, pod := range pods {
+		if selects(policy, pod) {
+			selected = append(selected, pod)
+		}
+	}
+	return selected
+}
+
+func matchesNamespace(objects *kube.Objects, selector *metav1.LabelSelector) bool {
+	for _, namespace := range objects.Namespaces.Items {
+		if matches(selector, namespace.Labels) {
+			return true
+		}
+	}
+	return false
+}
+
+// exposesPort reports whether any container of the pods declares a port
+// matching a network policy port.
+func exposesPort(pods []*corev1.Pod, port networkingv1.NetworkPolicyPort) bool {
+	protocol := corev1.ProtocolTCP
+	if port.Protocol != nil {
+		protocol = *port.Protocol
+	}
+	for _, pod := range pods {
+		for _, container := range pod.Spec.Containers {
+			for _, p := range container.Ports {
+				containerProtocol := p.Protocol
+				if containerProtocol == "" {
+					containerProtocol = corev1.ProtocolTCP
+				}
+				if containerProtocol != protocol {
+					continue
+				}
+				switch port.Port.Type {
+				case intstr.String:
+					if p.Name == port.Port.StrVal {
+						return true
+					}
+				case intstr.Int:
+					end := port.Port.IntVal
+					if port.EndPort != nil {
+						end = *port.EndPort
+					}
+					if p.ContainerPort >= port.Port.IntVal && p.ContainerPort <= end {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+func declaresPorts(pods []*corev1.Pod) bool {
+	for _, pod := range pods {
+		for _, container := range pod.Spec.Containers {
+			if len(container.Ports) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
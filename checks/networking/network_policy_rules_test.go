@@ -0,0 +1,192 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package networking
+
+import (
+	"testing"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestNetworkPolicyNoPodsWarning(t *testing.T) {
+	objs := initObjects()
+	withPod(objs, "k8s", "web", map[string]string{"app": "web"})
+	withPolicy(objs, "k8s", "web", networkingv1.NetworkPolicySpec{PodSelector: selector(map[string]string{"app": "web"})})
+	withPolicy(objs, "k8s", "api", networkingv1.NetworkPolicySpec{PodSelector: selector(map[string]string{"app": "api"})})
+	withPolicy(objs, "k8s", "deny", denyAll())
+	withPolicy(objs, "monitoring", "web", networkingv1.NetworkPolicySpec{PodSelector: selector(map[string]string{"app": "web"})})
+
+	d, err := (&networkPolicyNoPodsCheck{}).Run(objs)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []checks.Diagnostic{
+		{
+			Severity: checks.Warning,
+			Message:  "Network policy pod selector does not match any pods",
+			Kind:     checks.NetworkPolicy,
+			Object:   &objs.NetworkPolicies.Items[1].ObjectMeta,
+			Details:  "Pod selector: app=api",
+		},
+		{
+			Severity: checks.Warning,
+			Message:  "Network policy pod selector does not match any pods",
+			Kind:     checks.NetworkPolicy,
+			Object:   &objs.NetworkPolicies.Items[3].ObjectMeta,
+			Details:  "Pod selector: app=web",
+		},
+	}, d)
+}
+
+func TestNetworkPolicyNoPodsUnprotectedPods(t *testing.T) {
+	objs := initObjects()
+	withPod(objs, "kube-system", "dns", map[string]string{"app": "dns"})
+	withPod(objs, "k8s", "agent", map[string]string{"app": "agent"})
+	objs.Pods.Items[1].Spec.HostNetwork = true
+	withPod(objs, "k8s", "job", map[string]string{"app": "job"})
+	objs.Pods.Items[2].Status.Phase = corev1.PodSucceeded
+	withPolicy(objs, "kube-system", "dns", networkingv1.NetworkPolicySpec{PodSelector: selector(map[string]string{"app": "dns"})})
+	withPolicy(objs, "k8s", "agent", networkingv1.NetworkPolicySpec{PodSelector: selector(map[string]string{"app": "agent"})})
+	withPolicy(objs, "k8s", "job", networkingv1.NetworkPolicySpec{PodSelector: selector(map[string]string{"app": "job"})})
+
+	d, err := (&networkPolicyNoPodsCheck{}).Run(objs)
+	assert.NoError(t, err)
+	assert.Equal(t, []checks.Diagnostic{
+		{
+			Severity: checks.Warning,
+			Message:  "Network policy pod selector does not match any pods",
+			Kind:     checks.NetworkPolicy,
+			Object:   &objs.NetworkPolicies.Items[2].ObjectMeta,
+			Details:  "Pod selector: app=job",
+		},
+	}, d)
+}
+
+func TestNetworkPolicyReferencesWarning(t *testing.T) {
+	port := func(p intstr.IntOrString) networkingv1.NetworkPolicyPort {
+		return networkingv1.NetworkPolicyPort{Port: &p}
+	}
+	from := func(namespaceLabels map[string]string) networkingv1.NetworkPolicyPeer {
+		s := selector(namespaceLabels)
+		return networkingv1.NetworkPolicyPeer{NamespaceSelector: &s}
+	}
+	udp := corev1.ProtocolUDP
+	endPort := int32(9100)
+
+	tests := []struct {
+		name     string
+		ports    []corev1.ContainerPort
+		rule     networkingv1.NetworkPolicyIngressRule
+		expected []string
+	}{
+		{
+			name:  "existing namespace and port",
+			ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}},
+			rule: networkingv1.NetworkPolicyIngressRule{
+				From:  []networkingv1.NetworkPolicyPeer{from(map[string]string{"team": "observability"})},
+				Ports: []networkingv1.NetworkPolicyPort{port(intstr.FromString("http")), port(intstr.FromInt(8080))},
+			},
+			expected: nil,
+		},
+		{
+			name: "missing namespace",
+			rule: networkingv1.NetworkPolicyIngressRule{
+				From: []networkingv1.NetworkPolicyPeer{from(map[string]string{"team": "platform"})},
+			},
+			expected: []string{"Ingress rule 1 allows traffic from namespaces matching `team=platform`, but no namespace matches"},
+		},
+		{
+			name:  "missing named port",
+			ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}},
+			rule: networkingv1.NetworkPolicyIngressRule{
+				Ports: []networkingv1.NetworkPolicyPort{port(intstr.FromString("metrics"))},
+			},
+			expected: []string{"Ingress rule 1 allows port `metrics`, which no selected pod exposes"},
+		},
+		{
+			name:  "missing numeric port",
+			ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}},
+			rule: networkingv1.NetworkPolicyIngressRule{
+				Ports: []networkingv1.NetworkPolicyPort{port(intstr.FromInt(9090))},
+			},
+			expected: []string{"Ingress rule 1 allows port `9090`, which no selected pod exposes"},
+		},
+		{
+			name:  "wrong protocol",
+			ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}},
+			rule: networkingv1.NetworkPolicyIngressRule{
+				Ports: []networkingv1.NetworkPolicyPort{{Protocol: &udp, Port: &intstr.IntOrString{Type: intstr.Int, IntVal: 8080}}},
+			},
+			expected: []string{"Ingress rule 1 allows port `8080`, which no selected pod exposes"},
+		},
+		{
+			name:  "port range",
+			ports: []corev1.ContainerPort{{Name: "metrics", ContainerPort: 9100}},
+			rule: networkingv1.NetworkPolicyIngressRule{
+				Ports: []networkingv1.NetworkPolicyPort{{Port: &intstr.IntOrString{Type: intstr.Int, IntVal: 9000}, EndPort: &endPort}},
+			},
+			expected: nil,
+		},
+		{
+			name: "numeric port without declared ports",
+			rule: networkingv1.NetworkPolicyIngressRule{
+				Ports: []networkingv1.NetworkPolicyPort{port(intstr.FromInt(9090))},
+			},
+			expected: nil,
+		},
+	}
+
+	check := &networkPolicyReferencesCheck{}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := initObjects()
+			withPod(objs, "k8s", "web", map[string]string{"app": "web"}, test.ports...)
+			withPolicy(objs, "k8s", "web", networkingv1.NetworkPolicySpec{
+				PodSelector: selector(map[string]string{"app": "web"}),
+				Ingress:     []networkingv1.NetworkPolicyIngressRule{test.rule},
+			})
+
+			d, err := check.Run(objs)
+			assert.NoError(t, err)
+			var messages []string
+			for _, diagnostic := range d {
+				assert.Equal(t, checks.NetworkPolicy, diagnostic.Kind)
+				assert.Equal(t, &objs.NetworkPolicies.Items[0].ObjectMeta, diagnostic.Object)
+				messages = append(messages, diagnostic.Message)
+			}
+			assert.Equal(t, test.expected, messages)
+		})
+	}
+}
+
+func TestNetworkPolicyReferencesWithoutNamespaces(t *testing.T) {
+	objs := initObjects()
+	objs.Namespaces.Items = nil
+	s := metav1.LabelSelector{MatchLabels: map[string]string{"team": "platform"}}
+	withPolicy(objs, "k8s", "web", networkingv1.NetworkPolicySpec{
+		Ingress: []networkingv1.NetworkPolicyIngressRule{{
+			From: []networkingv1.NetworkPolicyPeer{{NamespaceSelector: &s}},
+		}},
+	})
+
+	d, err := (&networkPolicyReferencesCheck{}).Run(objs)
+	assert.NoError(t, err)
+	assert.Empty(t, d)
+}
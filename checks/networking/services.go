@@ -0,0 +1,338 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package networking
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func init() {
+	checks.Register(&serviceSelectorCheck{})
+	checks.Register(&serviceTargetPortCheck{})
+	checks.Register(&serviceEndpointsCheck{})
+}
+
+type serviceSelectorCheck struct{}
+
+// Name returns a unique name for this check.
+func (s *serviceSelectorCheck) Name() string {
+	return "service-selector"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (s *serviceSelectorCheck) Groups() []string {
+	return []string{"networking"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (s *serviceSelectorCheck) Description() string {
+	return "Checks if there are services whose selector does not match any pods"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (s *serviceSelectorCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Pods, kube.Services}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (s *serviceSelectorCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	pods := servicePods(objects)
+	for i := range objects.Services.Items {
+		service := &objects.Services.Items[i]
+		if !hasSelector(service) || len(selectedByService(service, pods[service.Namespace])) > 0 {
+			continue
+		}
+		details := fmt.Sprintf("Selector: %s", labels.Set(service.Spec.Selector))
+		// Pods matching part of the selector usually point to a typo in
+		// either the service or the pod labels.
+		if partial := partiallySelected(service, pods[service.Namespace]); len(partial) > 0 {
+			details = fmt.Sprintf("%s. Pods matching some of the selector labels: %s", details, podNames(partial))
+		}
+		d := checks.Diagnostic{
+			Severity: checks.Warning,
+			Message:  "Service selector does not match any pods",
+			Kind:     checks.Service,
+			Object:   &service.ObjectMeta,
+			Owners:   service.ObjectMeta.GetOwnerReferences(),
+			Details:  details,
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics, nil
+}
+
+type serviceTargetPortCheck struct{}
+
+// Name returns a unique name for this check.
+func (s *serviceTargetPortCheck) Name() string {
+	return "service-target-port"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (s *serviceTargetPortCheck) Groups() []string {
+	return []string{"networking"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (s *serviceTargetPortCheck) Description() string {
+	return "Checks if there are services whose target port is not exposed by the selected pods"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (s *serviceTargetPortCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Pods, kube.Services}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (s *serviceTargetPortCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	pods := servicePods(objects)
+	for i := range objects.Services.Items {
+		service := &objects.Services.Items[i]
+		if !hasSelector(service) {
+			continue
+		}
+		selected := selectedByService(service, pods[service.Namespace])
+		for _, port := range service.Spec.Ports {
+			target := port.TargetPort
+			if target.Type == intstr.Int && target.IntVal == 0 {
+				target = intstr.FromInt32(port.Port)
+			}
+			var mismatched []*corev1.Pod
+			for _, pod := range selected {
+				if !podExposes(pod, target, port.Protocol) {
+					mismatched = append(mismatched, pod)
+				}
+			}
+			if len(mismatched) == 0 {
+				continue
+			}
+			// Traffic to a named port that a pod does not declare is never
+			// routed to it. Numeric ports work even if they are not declared.
+			severity := checks.Error
+			if target.Type == intstr.Int {
+				severity = checks.Warning
+			}
+			d := checks.Diagnostic{
+				Severity: severity,
+				Message:  fmt.Sprintf("Service port `%s` targets port `%s`, which is not exposed by %d of %d selected pods", servicePortName(port), target.String(), len(mismatched), len(selected)),
+				Kind:     checks.Service,
+				Object:   &service.ObjectMeta,
+				Owners:   service.ObjectMeta.GetOwnerReferences(),
+				Details:  fmt.Sprintf("Pods: %s", podNames(mismatched)),
+			}
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	return diagnostics, nil
+}
+
+type serviceEndpointsCheck struct{}
+
+// Name returns a unique name for this check.
+func (s *serviceEndpointsCheck) Name() string {
+	return "service-endpoints"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (s *serviceEndpointsCheck) Groups() []string {
+	return []string{"networking"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (s *serviceEndpointsCheck) Description() string {
+	return "Checks if there are services selecting pods that have no ready endpoints"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (s *serviceEndpointsCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.EndpointSlices, kube.Pods, kube.Services}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (s *serviceEndpointsCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	pods := servicePods(objects)
+	ready := readyEndpoints(objects)
+	for i := range objects.Services.Items {
+		service := &objects.Services.Items[i]
+		if !hasSelector(service) {
+			continue
+		}
+		// Services selecting no pods are reported by service-selector.
+		selected := selectedByService(service, pods[service.Namespace])
+		if len(selected) == 0 || ready[kube.Identifier{Name: service.Name, Namespace: service.Namespace}] > 0 {
+			continue
+		}
+		var notReady []*corev1.Pod
+		for _, pod := range selected {
+			if !podReady(pod) {
+				notReady = append(notReady, pod)
+			}
+		}
+		d := checks.Diagnostic{
+			Severity: checks.Warning,
+			Message:  "Service has no ready endpoints",
+			Kind:     checks.Service,
+			Object:   &service.ObjectMeta,
+			Owners:   service.ObjectMeta.GetOwnerReferences(),
+		}
+		if len(notReady) > 0 {
+			d.Details = fmt.Sprintf("Pods not ready: %s", podNames(notReady))
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics, nil
+}
+
+// servicePods returns the pods that can back a service, grouped by
+// namespace. Pods that have terminated are left out.
+func servicePods(objects *kube.Objects) map[string][]*corev1.Pod {
+	pods := make(map[string][]*corev1.Pod)
+	for i := range objects.Pods.Items {
+		pod := &objects.Pods.Items[i]
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		pods[pod.Namespace] = append(pods[pod.Namespace], pod)
+	}
+	return pods
+}
+
+// hasSelector reports whether the endpoints of a service are managed by
+// Kubernetes based on a pod selector.
+func hasSelector(service *corev1.Service) bool {
+	return service.Spec.Type != corev1.ServiceTypeExternalName && len(service.Spec.Selector) > 0
+}
+
+func selectedByService(service *corev1.Service, pods []*corev1.Pod) []*corev1.Pod {
+	selector := labels.SelectorFromSet(service.Spec.Selector)
+	var selected []*corev1.Pod
+	for _, pod := range pods {
+		if selector.Matches(labels.Set(pod.Labels)) {
+			selected = append(selected, pod)
+		}
+	}
+	return selected
+}
+
+// partiallySelected returns the pods that have at least one of the labels of
+// a service's selector.
+func partiallySelected(service *corev1.Service, pods []*corev1.Pod) []*corev1.Pod {
+	var partial []*corev1.Pod
+	for _, pod := range pods {
+		for key, value := range service.Spec.Selector {
+			if pod.Labels[key] == value {
+				partial = append(partial, pod)
+				break
+			}
+		}
+	}
+	return partial
+}
+
+// podExposes reports whether a pod can receive traffic on a service's target
+// port. Pods that declare no ports at all accept traffic on any numeric port.
+func podExposes(pod *corev1.Pod, target intstr.IntOrString, protocol corev1.Protocol) bool {
+	if protocol == "" {
+		protocol = corev1.ProtocolTCP
+	}
+	declared := false
+	for _, container := range pod.Spec.Containers {
+		for _, p := range container.Ports {
+			declared = true
+			containerProtocol := p.Protocol
+			if containerProtocol == "" {
+				containerProtocol = corev1.ProtocolTCP
+			}
+			if containerProtocol != protocol {
+				continue
+			}
+			if target.Type == intstr.String && p.Name == target.StrVal {
+				return true
+			}
+			if target.Type == intstr.Int && p.ContainerPort == target.IntVal {
+				return true
+			}
+		}
+	}
+	return target.Type == intstr.Int && !declared
+}
+
+// readyEndpoints returns the number of ready endpoints of each service.
+func readyEndpoints(objects *kube.Objects) map[kube.Identifier]int {
+	ready := make(map[kube.Identifier]int)
+	for _, slice := range objects.EndpointSlices.Items {
+		name, ok := slice.Labels[discoveryv1.LabelServiceName]
+		if !ok {
+			continue
+		}
+		id := kube.Identifier{Name: name, Namespace: slice.Namespace}
+		for _, endpoint := range slice.Endpoints {
+			// A nil ready condition means the state is unknown, which
+			// consumers are meant to treat as ready.
+			if endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready {
+				ready[id]++
+			}
+		}
+	}
+	return ready
+}
+
+func podReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func servicePortName(port corev1.ServicePort) string {
+	if port.Name != "" {
+		return port.Name
+	}
+	return fmt.Sprintf("%d", port.Port)
+}
+
+func podNames(pods []*corev1.Pod) string {
+	var names []string
+	for _, pod := range pods {
+		names = append(names, pod.Name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,266 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package networking
+
+import (
+	"testing"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestServiceChecksMeta(t *testing.T) {
+	for name, check := range map[string]checks.Check{
+		"service-selector":    &serviceSelectorCheck{},
+		"service-target-port": &serviceTargetPortCheck{},
+		"service-endpoints":   &serviceEndpointsCheck{},
+	} {
+		assert.Equal(t, name, check.Name())
+		assert.Equal(t, []string{"networking"}, check.Groups())
+		assert.NotEmpty(t, check.Description())
+
+		registered, err := checks.Get(name)
+		assert.NoError(t, err)
+		assert.IsType(t, check, registered)
+	}
+}
+
+func TestServiceSelectorWarning(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector map[string]string
+		expected []checks.Diagnostic
+	}{
+		{
+			name:     "matching pods",
+			selector: map[string]string{"app": "web"},
+			expected: nil,
+		},
+		{
+			name:     "no matching pods",
+			selector: map[string]string{"app": "api"},
+			expected: []checks.Diagnostic{{
+				Severity: checks.Warning,
+				Message:  "Service selector does not match any pods",
+				Kind:     checks.Service,
+				Details:  "Selector: app=api",
+			}},
+		},
+		{
+			name:     "partially matching pods",
+			selector: map[string]string{"app": "web", "tier": "frontend"},
+			expected: []checks.Diagnostic{{
+				Severity: checks.Warning,
+				Message:  "Service selector does not match any pods",
+				Kind:     checks.Service,
+				Details:  "Selector: app=web,tier=frontend. Pods matching some of the selector labels: web-1, web-2",
+			}},
+		},
+		{
+			name:     "no selector",
+			selector: nil,
+			expected: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := initServices()
+			withService(objs, "web", test.selector, corev1.ServicePort{Port: 80})
+			for i := range test.expected {
+				test.expected[i].Object = &objs.Services.Items[0].ObjectMeta
+			}
+
+			d, err := (&serviceSelectorCheck{}).Run(objs)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, d)
+		})
+	}
+}
+
+func TestServiceTargetPortWarning(t *testing.T) {
+	tests := []struct {
+		name     string
+		port     corev1.ServicePort
+		expected []checks.Diagnostic
+	}{
+		{
+			name:     "named target port",
+			port:     corev1.ServicePort{Port: 80, TargetPort: intstr.FromString("http")},
+			expected: nil,
+		},
+		{
+			name:     "numeric target port",
+			port:     corev1.ServicePort{Port: 80, TargetPort: intstr.FromInt(8080)},
+			expected: nil,
+		},
+		{
+			name: "missing named target port",
+			port: corev1.ServicePort{Name: "web", Port: 80, TargetPort: intstr.FromString("metrics")},
+			expected: []checks.Diagnostic{{
+				Severity: checks.Error,
+				Message:  "Service port `web` targets port `metrics`, which is not exposed by 2 of 2 selected pods",
+				Kind:     checks.Service,
+				Details:  "Pods: web-1, web-2",
+			}},
+		},
+		{
+			name: "target port defaults to port",
+			port: corev1.ServicePort{Port: 80},
+			expected: []checks.Diagnostic{{
+				Severity: checks.Warning,
+				Message:  "Service port `80` targets port `80`, which is not exposed by 2 of 2 selected pods",
+				Kind:     checks.Service,
+				Details:  "Pods: web-1, web-2",
+			}},
+		},
+		{
+			name: "protocol mismatch",
+			port: corev1.ServicePort{Port: 80, TargetPort: intstr.FromInt(8080), Protocol: corev1.ProtocolUDP},
+			expected: []checks.Diagnostic{{
+				Severity: checks.Warning,
+				Message:  "Service port `80` targets port `8080`, which is not exposed by 2 of 2 selected pods",
+				Kind:     checks.Service,
+				Details:  "Pods: web-1, web-2",
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := initServices()
+			withService(objs, "web", map[string]string{"app": "web"}, test.port)
+			for i := range test.expected {
+				test.expected[i].Object = &objs.Services.Items[0].ObjectMeta
+			}
+
+			d, err := (&serviceTargetPortCheck{}).Run(objs)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, d)
+		})
+	}
+}
+
+func TestServiceTargetPortPartialMismatch(t *testing.T) {
+	objs := initServices()
+	// web-2 runs an older version of the pod template without the port.
+	objs.Pods.Items[1].Spec.Containers[0].Ports = []corev1.ContainerPort{{Name: "legacy", ContainerPort: 8000}}
+	withService(objs, "web", map[string]string{"app": "web"}, corev1.ServicePort{Port: 80, TargetPort: intstr.FromString("http")})
+
+	d, err := (&serviceTargetPortCheck{}).Run(objs)
+	assert.NoError(t, err)
+	assert.Equal(t, []checks.Diagnostic{{
+		Severity: checks.Error,
+		Message:  "Service port `80` targets port `http`, which is not exposed by 1 of 2 selected pods",
+		Kind:     checks.Service,
+		Object:   &objs.Services.Items[0].ObjectMeta,
+		Details:  "Pods: web-2",
+	}}, d)
+}
+
+func TestServiceEndpointsWarning(t *testing.T) {
+	trueVal := true
+	falseVal := false
+
+	tests := []struct {
+		name     string
+		ready    []*bool
+		expected []checks.Diagnostic
+	}{
+		{
+			name:     "ready endpoint",
+			ready:    []*bool{&falseVal, &trueVal},
+			expected: nil,
+		},
+		{
+			name:     "unknown readiness",
+			ready:    []*bool{nil},
+			expected: nil,
+		},
+		{
+			name:  "no ready endpoints",
+			ready: []*bool{&falseVal, &falseVal},
+			expected: []checks.Diagnostic{{
+				Severity: checks.Warning,
+				Message:  "Service has no ready endpoints",
+				Kind:     checks.Service,
+				Details:  "Pods not ready: web-2",
+			}},
+		},
+		{
+			name:  "no endpoint slices",
+			ready: nil,
+			expected: []checks.Diagnostic{{
+				Severity: checks.Warning,
+				Message:  "Service has no ready endpoints",
+				Kind:     checks.Service,
+				Details:  "Pods not ready: web-2",
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := initServices()
+			objs.Pods.Items[0].Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+			withService(objs, "web", map[string]string{"app": "web"}, corev1.ServicePort{Port: 80})
+			if test.ready != nil {
+				slice := discoveryv1.EndpointSlice{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "web-abcde",
+						Namespace: "k8s",
+						Labels:    map[string]string{discoveryv1.LabelServiceName: "web"},
+					},
+				}
+				for _, ready := range test.ready {
+					slice.Endpoints = append(slice.Endpoints, discoveryv1.Endpoint{Conditions: discoveryv1.EndpointConditions{Ready: ready}})
+				}
+				objs.EndpointSlices.Items = append(objs.EndpointSlices.Items, slice)
+			}
+			for i := range test.expected {
+				test.expected[i].Object = &objs.Services.Items[0].ObjectMeta
+			}
+
+			d, err := (&serviceEndpointsCheck{}).Run(objs)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, d)
+		})
+	}
+}
+
+func initServices() *kube.Objects {
+	objs := initObjects()
+	objs.Services = &corev1.ServiceList{}
+	objs.EndpointSlices = &discoveryv1.EndpointSliceList{}
+	ports := []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}}
+	withPod(objs, "k8s", "web-1", map[string]string{"app": "web"}, ports...)
+	withPod(objs, "k8s", "web-2", map[string]string{"app": "web"}, ports...)
+	withPod(objs, "k8s", "db", map[string]string{"app": "db"})
+	return objs
+}
+
+func withService(objs *kube.Objects, name string, selector map[string]string, ports ...corev1.ServicePort) {
+	objs.Services.Items = append(objs.Services.Items, corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "k8s"},
+		Spec:       corev1.ServiceSpec{Selector: selector, Ports: ports},
+	})
+}
@@ -43,6 +43,11 @@ func (nc *check) Description() string {
 	return "Does not check anything. Returns no errors."
 }
 
+// Resources returns the resources whose objects this check reads.
+func (nc *check) Resources() []kube.Resource {
+	return nil
+}
+
 // Run runs this check on a set of Kubernetes objects. It can return warnings
 // (low-priority problems) and errors (high-priority problems) as well as an
 // error value indicating that the check failed to run.
@@ -0,0 +1,70 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rules
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+)
+
+// The match expression of a rule is written in CEL, the Common Expression
+// Language used by Kubernetes validating admission policies. Besides the
+// standard definitions, the string functions of the CEL extensions, such as
+// lowerAscii() and split(), are available. Expressions are compiled when rules
+// are loaded, and type errors and references to unknown variables or
+// functions are reported then.
+
+// env is the environment match expressions are compiled in. The object a
+// rule is evaluated against is exposed as `object`.
+var env = func() *cel.Env {
+	e, err := cel.NewEnv(
+		cel.Variable("object", cel.MapType(cel.StringType, cel.DynType)),
+		ext.Strings(),
+	)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}()
+
+// compile compiles a match expression, which must evaluate to a bool.
+func compile(src string) (cel.Program, error) {
+	ast, issues := env.Compile(src)
+	if issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if t := ast.OutputType(); !t.IsExactType(cel.BoolType) && !t.IsExactType(cel.DynType) {
+		return nil, fmt.Errorf("expression evaluates to %s, not bool", t)
+	}
+	return env.Program(ast)
+}
+
+// matches evaluates a compiled match expression against an object.
+func matches(program cel.Program, content map[string]interface{}) (bool, error) {
+	out, _, err := program.Eval(map[string]interface{}{"object": content})
+	if err != nil {
+		return false, err
+	}
+	matched, ok := out.Value().(bool)
+	if !ok {
+		return false, errors.New("expression does not evaluate to a bool")
+	}
+	return matched, nil
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatches(t *testing.T) {
+	object := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":   "web",
+			"labels": map[string]interface{}{"app": "web"},
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(1),
+			"ratio":    0.5,
+			"containers": []interface{}{
+				map[string]interface{}{"name": "app", "image": "nginx:latest"},
+				map[string]interface{}{"name": "sidecar", "image": "envoy:1.30"},
+			},
+		},
+	}
+
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{`object.spec.replicas < 2`, true},
+		{`object.spec.ratio * 2.0 == 1.0`, true},
+		{`object.metadata.name == "web" && object.metadata.labels["app"] == 'web'`, true},
+		{`has(object.spec.replicas)`, true},
+		{`has(object.spec.template)`, false},
+		{`!has(object.spec.template) || object.spec.template.x`, true},
+		{`"app" in object.metadata.labels`, true},
+		{`object.metadata.name in ["api", "db"]`, false},
+		{`size(object.spec.containers) == 2`, true},
+		{`object.spec.containers.exists(c, c.image.endsWith(":latest"))`, true},
+		{`object.spec.containers.all(c, c.image.contains(":"))`, true},
+		{`object.spec.containers.exists_one(c, c.name.startsWith("s"))`, true},
+		{`object.spec.containers.filter(c, c.image.matches("^nginx")).map(c, c.name) == ["app"]`, true},
+		{`object.metadata.name.upperAscii() == "WEB"`, true},
+		{`object.spec.containers[1].image.split(":")[1] == "1.30"`, true},
+	}
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			program, err := compile(test.expr)
+			assert.NoError(t, err)
+			matched, err := matches(program, object)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, matched)
+		})
+	}
+}
+
+func TestMatchesErrors(t *testing.T) {
+	object := map[string]interface{}{
+		"spec": map[string]interface{}{"replicas": int64(1)},
+	}
+
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{`object.spec.template.x`, "no such key: template"},
+		{`object.spec.replicas / 0 == 1`, "division by zero"},
+		{`object.spec.replicas`, "expression does not evaluate to a bool"},
+	}
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			program, err := compile(test.expr)
+			assert.NoError(t, err)
+			_, err = matches(program, object)
+			assert.EqualError(t, err, test.expected)
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{`obj.spec`, "undeclared reference to 'obj'"},
+		{`object.spec.items.exists(i, x)`, "undeclared reference to 'x'"},
+		{`lower(object.kind)`, "undeclared reference to 'lower'"},
+		{`object.kind == "Pod`, "Syntax error"},
+		{`1 + 2`, "expression evaluates to int, not bool"},
+		{`"a" + 1 == "a1"`, "found no matching overload for '_+_'"},
+	}
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			_, err := compile(test.expr)
+			assert.ErrorContains(t, err, test.expected)
+		})
+	}
+}
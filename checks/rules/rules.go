@@ -0,0 +1,310 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rules loads checks declared in YAML files. Each rule names the
+// kinds of objects it applies to, an expression selecting the objects to
+// report, and a template for the diagnostic message:
+//
+//	name: single-replica-deployment
+//	groups: ["custom"]
+//	description: Checks for deployments running a single replica
+//	severity: warning
+//	kinds: ["Deployment"]
+//	match: has(object.spec.replicas) && object.spec.replicas < 2
+//	message: Deployment {{ .object.metadata.name }} runs a single replica
+//
+// Several rules may be placed in one file, separated by "---".
+package rules
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	"github.com/google/cel-go/cel"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// spec is the YAML form of a rule.
+type spec struct {
+	Name        string          `json:"name"`
+	Groups      []string        `json:"groups"`
+	Description string          `json:"description"`
+	Severity    checks.Severity `json:"severity"`
+	Kinds       []string        `json:"kinds"`
+	Match       string          `json:"match"`
+	Message     string          `json:"message"`
+}
+
+// Rule is a check declared in YAML.
+type Rule struct {
+	spec    spec
+	match   cel.Program
+	message *template.Template
+}
+
+// Load parses the rules in the .yaml and .yml files of a directory.
+func Load(dir string) ([]*Rule, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if !entry.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+
+	var ret []*Rule
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		rules, err := Parse(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		ret = append(ret, rules...)
+	}
+	return ret, nil
+}
+
+// Parse parses the rules in a YAML stream.
+func Parse(r io.Reader) ([]*Rule, error) {
+	docs, err := documents(r)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*Rule, 0, len(docs))
+	for _, doc := range docs {
+		var s spec
+		if err := yaml.UnmarshalStrict(doc, &s); err != nil {
+			return nil, err
+		}
+		rule, err := newRule(s)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, rule)
+	}
+	return ret, nil
+}
+
+func newRule(s spec) (*Rule, error) {
+	if s.Name == "" {
+		return nil, errors.New("rules must have non-empty names")
+	}
+	switch s.Severity {
+	case "":
+		s.Severity = checks.Warning
+	case checks.Error, checks.Warning, checks.Suggestion:
+	default:
+		return nil, fmt.Errorf("rule %s: unknown severity %q", s.Name, s.Severity)
+	}
+	if len(s.Kinds) == 0 {
+		return nil, fmt.Errorf("rule %s: no kinds specified", s.Name)
+	}
+	for _, kind := range s.Kinds {
+		if _, ok := targets[kind]; !ok {
+			return nil, fmt.Errorf("rule %s: unsupported kind %q", s.Name, kind)
+		}
+	}
+	if s.Match == "" {
+		return nil, fmt.Errorf("rule %s: no match expression", s.Name)
+	}
+	match, err := compile(s.Match)
+	if err != nil {
+		return nil, fmt.Errorf("rule %s: match: %w", s.Name, err)
+	}
+	if s.Message == "" {
+		return nil, fmt.Errorf("rule %s: no message", s.Name)
+	}
+	message, err := template.New(s.Name).Parse(s.Message)
+	if err != nil {
+		return nil, fmt.Errorf("rule %s: message: %w", s.Name, err)
+	}
+	return &Rule{spec: s, match: match, message: message}, nil
+}
+
+// Name returns a unique name for this check.
+func (r *Rule) Name() string {
+	return r.spec.Name
+}
+
+// Groups returns a list of group names this check should be part of.
+func (r *Rule) Groups() []string {
+	return r.spec.Groups
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (r *Rule) Description() string {
+	return r.spec.Description
+}
+
+// Resources returns the resources of the kinds the rule applies to.
+func (r *Rule) Resources() []kube.Resource {
+	ret := make([]kube.Resource, 0, len(r.spec.Kinds))
+	for _, kind := range r.spec.Kinds {
+		ret = append(ret, targets[kind].resource)
+	}
+	return ret
+}
+
+// Run evaluates the rule against the objects of the kinds it applies to.
+func (r *Rule) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	for _, kind := range r.spec.Kinds {
+		t := targets[kind]
+		objs, err := t.objects(kind, objects)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objs {
+			if d := r.evaluate(t.kind, obj); d != nil {
+				diagnostics = append(diagnostics, *d)
+			}
+		}
+	}
+	return diagnostics, nil
+}
+
+// Test evaluates the rule against manifests, as read by ReadManifests,
+// instead of objects fetched from a cluster.
+func (r *Rule) Test(manifests []map[string]interface{}) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	for _, manifest := range manifests {
+		kind, _ := manifest["kind"].(string)
+		if !r.appliesTo(kind) {
+			continue
+		}
+		m := &metav1.ObjectMeta{}
+		if metadata, ok := manifest["metadata"].(map[string]interface{}); ok {
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(metadata, m); err != nil {
+				return nil, fmt.Errorf("%s metadata: %w", kind, err)
+			}
+		}
+		if d := r.evaluate(targets[kind].kind, object{meta: m, content: manifest}); d != nil {
+			diagnostics = append(diagnostics, *d)
+		}
+	}
+	return diagnostics, nil
+}
+
+func (r *Rule) appliesTo(kind string) bool {
+	for _, k := range r.spec.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluate returns a diagnostic for the object if it matches the rule. An
+// object the rule cannot be evaluated against, for example because the match
+// expression selects a field the object does not have, is reported with the
+// error instead, so that the other objects are still checked.
+func (r *Rule) evaluate(kind checks.Kind, obj object) *checks.Diagnostic {
+	matched, err := matches(r.match, obj.content)
+	if err != nil {
+		return r.diagnostic(kind, obj, fmt.Sprintf("Rule could not be evaluated: %v", err))
+	}
+	if !matched {
+		return nil
+	}
+
+	var message strings.Builder
+	if err := r.message.Execute(&message, map[string]interface{}{"object": obj.content}); err != nil {
+		return r.diagnostic(kind, obj, fmt.Sprintf("Rule message could not be rendered: %v", err))
+	}
+	return r.diagnostic(kind, obj, message.String())
+}
+
+func (r *Rule) diagnostic(kind checks.Kind, obj object, message string) *checks.Diagnostic {
+	return &checks.Diagnostic{
+		Check:    r.Name(),
+		Severity: r.spec.Severity,
+		Message:  message,
+		Kind:     kind,
+		Object:   obj.meta,
+		Owners:   obj.meta.GetOwnerReferences(),
+	}
+}
+
+// ReadManifests reads the objects in a stream of YAML or JSON manifests.
+// Documents without a kind, such as empty ones, are skipped.
+func ReadManifests(r io.Reader) ([]map[string]interface{}, error) {
+	docs, err := documents(r)
+	if err != nil {
+		return nil, err
+	}
+	var ret []map[string]interface{}
+	for _, doc := range docs {
+		manifest := map[string]interface{}{}
+		if err := utilyaml.Unmarshal(doc, &manifest); err != nil {
+			return nil, err
+		}
+		if _, ok := manifest["kind"]; ok {
+			ret = append(ret, manifest)
+		}
+	}
+	return ret, nil
+}
+
+// documents splits a YAML stream into its non-empty documents.
+func documents(r io.Reader) ([][]byte, error) {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(r))
+	var docs [][]byte
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !blank(doc) {
+			docs = append(docs, doc)
+		}
+	}
+}
+
+// blank reports whether a document holds nothing but comments.
+func blank(doc []byte) bool {
+	for _, line := range strings.Split(string(doc), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && line != "---" && !strings.HasPrefix(line, "#") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,266 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const singleReplica = `
+name: single-replica
+groups: ["custom"]
+description: Checks for deployments running a single replica
+severity: suggestion
+kinds: ["Deployment", "StatefulSet"]
+match: has(object.spec.replicas) && object.spec.replicas < 2
+message: '{{ .object.kind }} {{ .object.metadata.name }} runs {{ .object.spec.replicas }} replica'
+`
+
+func parseRule(t *testing.T, src string) *Rule {
+	rules, err := Parse(strings.NewReader(src))
+	assert.NoError(t, err)
+	assert.Len(t, rules, 1)
+	return rules[0]
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := Parse(strings.NewReader(singleReplica + `
+---
+# A second rule, with the default severity.
+name: latest-tag
+kinds: ["Pod"]
+match: object.spec.containers.exists(c, c.image.endsWith(":latest"))
+message: uses the latest tag
+`))
+	assert.NoError(t, err)
+	assert.Len(t, rules, 2)
+
+	rule := rules[0]
+	assert.Equal(t, "single-replica", rule.Name())
+	assert.Equal(t, []string{"custom"}, rule.Groups())
+	assert.Equal(t, "Checks for deployments running a single replica", rule.Description())
+	assert.Equal(t, checks.Suggestion, rule.spec.Severity)
+	assert.Equal(t, []kube.Resource{kube.Deployments, kube.StatefulSets}, checks.Resources(rule))
+
+	assert.Equal(t, "latest-tag", rules[1].Name())
+	assert.Equal(t, checks.Warning, rules[1].spec.Severity)
+}
+
+func TestParseRulesErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		expected string
+	}{
+		{
+			name:     "no name",
+			rule:     `kinds: ["Pod"]`,
+			expected: "rules must have non-empty names",
+		},
+		{
+			name:     "unknown field",
+			rule:     "name: r\nkind: Pod",
+			expected: `error unmarshaling JSON: while decoding JSON: json: unknown field "kind"`,
+		},
+		{
+			name:     "unknown severity",
+			rule:     "name: r\nseverity: fatal",
+			expected: `rule r: unknown severity "fatal"`,
+		},
+		{
+			name:     "no kinds",
+			rule:     "name: r",
+			expected: "rule r: no kinds specified",
+		},
+		{
+			name:     "unsupported kind",
+			rule:     "name: r\nkinds: [Certificate]",
+			expected: `rule r: unsupported kind "Certificate"`,
+		},
+		{
+			name:     "invalid match",
+			rule:     "name: r\nkinds: [Pod]\nmatch: spec.nodeName",
+			expected: "rule r: match: ERROR: <input>:1:1: undeclared reference to 'spec' (in container '')\n | spec.nodeName\n | ^",
+		},
+		{
+			name:     "no message",
+			rule:     "name: r\nkinds: [Pod]\nmatch: 'true'",
+			expected: "rule r: no message",
+		},
+		{
+			name:     "invalid message",
+			rule:     "name: r\nkinds: [Pod]\nmatch: 'true'\nmessage: '{{ .object'",
+			expected: "rule r: message: template: r:1: unclosed action",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(test.rule))
+			assert.EqualError(t, err, test.expected)
+		})
+	}
+}
+
+func replicas(n int32) *int32 {
+	return &n
+}
+
+func TestRuleRun(t *testing.T) {
+	rule := parseRule(t, singleReplica)
+	objects := &kube.Objects{
+		Deployments: &appsv1.DeploymentList{
+			Items: []appsv1.Deployment{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "k8s"},
+					Spec:       appsv1.DeploymentSpec{Replicas: replicas(1)},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "k8s"},
+					Spec:       appsv1.DeploymentSpec{Replicas: replicas(3)},
+				},
+			},
+		},
+	}
+
+	diagnostics, err := rule.Run(objects)
+	assert.NoError(t, err)
+	assert.Equal(t, []checks.Diagnostic{
+		{
+			Check:    "single-replica",
+			Severity: checks.Suggestion,
+			Message:  "Deployment web runs 1 replica",
+			Kind:     checks.Deployment,
+			Object:   &objects.Deployments.Items[0].ObjectMeta,
+		},
+	}, diagnostics)
+}
+
+func TestRuleRunEvaluationError(t *testing.T) {
+	rule := parseRule(t, `
+name: node-name
+kinds: ["Deployment"]
+match: object.spec.template.spec.nodeName != ""
+message: pinned to a node
+`)
+	objects := &kube.Objects{
+		Deployments: &appsv1.DeploymentList{
+			Items: []appsv1.Deployment{
+				{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "k8s"}},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "k8s"},
+					Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{NodeName: "node-1"},
+					}},
+				},
+			},
+		},
+	}
+
+	diagnostics, err := rule.Run(objects)
+	assert.NoError(t, err)
+	assert.Equal(t, []checks.Diagnostic{
+		{
+			Check:    "node-name",
+			Severity: checks.Warning,
+			Message:  "Rule could not be evaluated: no such key: nodeName",
+			Kind:     checks.Deployment,
+			Object:   &objects.Deployments.Items[0].ObjectMeta,
+		},
+		{
+			Check:    "node-name",
+			Severity: checks.Warning,
+			Message:  "pinned to a node",
+			Kind:     checks.Deployment,
+			Object:   &objects.Deployments.Items[1].ObjectMeta,
+		},
+	}, diagnostics)
+}
+
+func TestRuleTest(t *testing.T) {
+	rule := parseRule(t, singleReplica)
+	manifests, err := ReadManifests(strings.NewReader(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: k8s
+  ownerReferences:
+  - apiVersion: example.com/v1
+    kind: App
+    name: web
+    uid: "1234"
+spec:
+  replicas: 1
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+  namespace: k8s
+spec:
+  replicas: 3
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web-1234
+spec:
+  replicas: 1
+`))
+	assert.NoError(t, err)
+	assert.Len(t, manifests, 3)
+
+	owners := []metav1.OwnerReference{{APIVersion: "example.com/v1", Kind: "App", Name: "web", UID: "1234"}}
+	diagnostics, err := rule.Test(manifests)
+	assert.NoError(t, err)
+	assert.Equal(t, []checks.Diagnostic{
+		{
+			Check:    "single-replica",
+			Severity: checks.Suggestion,
+			Message:  "Deployment web runs 1 replica",
+			Kind:     checks.Deployment,
+			Object:   &metav1.ObjectMeta{Name: "web", Namespace: "k8s", OwnerReferences: owners},
+			Owners:   owners,
+		},
+	}, diagnostics)
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "replicas.yaml"), []byte(singleReplica), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Rules"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "broken.yml"), []byte("name: broken"), 0o644))
+
+	_, err := Load(dir)
+	assert.EqualError(t, err, filepath.Join(dir, "broken.yml")+": rule broken: no kinds specified")
+
+	assert.NoError(t, os.Remove(filepath.Join(dir, "broken.yml")))
+	rules, err := Load(dir)
+	assert.NoError(t, err)
+	assert.Len(t, rules, 1)
+	assert.Equal(t, "single-replica", rules[0].Name())
+}
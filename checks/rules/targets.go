@@ -0,0 +1,138 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rules
+
+import (
+	"reflect"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// target describes a kind of object that rules may be written against.
+type target struct {
+	apiVersion string
+	resource   kube.Resource
+	kind       checks.Kind
+	list       func(*kube.Objects) runtime.Object
+}
+
+// targets maps the kinds accepted in a rule's kinds field to the objects
+// fetched by clusterlint.
+var targets = map[string]target{
+	"Pod": {"v1", kube.Pods, checks.Pod,
+		func(o *kube.Objects) runtime.Object { return o.Pods }},
+	"PodTemplate": {"v1", kube.PodTemplates, checks.PodTemplate,
+		func(o *kube.Objects) runtime.Object { return o.PodTemplates }},
+	"PersistentVolumeClaim": {"v1", kube.PersistentVolumeClaims, checks.PersistentVolumeClaim,
+		func(o *kube.Objects) runtime.Object { return o.PersistentVolumeClaims }},
+	"PersistentVolume": {"v1", kube.PersistentVolumes, checks.PersistentVolume,
+		func(o *kube.Objects) runtime.Object { return o.PersistentVolumes }},
+	"ConfigMap": {"v1", kube.ConfigMaps, checks.ConfigMap,
+		func(o *kube.Objects) runtime.Object { return o.ConfigMaps }},
+	"Service": {"v1", kube.Services, checks.Service,
+		func(o *kube.Objects) runtime.Object { return o.Services }},
+	"Secret": {"v1", kube.Secrets, checks.Secret,
+		func(o *kube.Objects) runtime.Object { return o.Secrets }},
+	"ServiceAccount": {"v1", kube.ServiceAccounts, checks.ServiceAccount,
+		func(o *kube.Objects) runtime.Object { return o.ServiceAccounts }},
+	"ResourceQuota": {"v1", kube.ResourceQuotas, checks.ResourceQuota,
+		func(o *kube.Objects) runtime.Object { return o.ResourceQuotas }},
+	"LimitRange": {"v1", kube.LimitRanges, checks.LimitRange,
+		func(o *kube.Objects) runtime.Object { return o.LimitRanges }},
+	"Namespace": {"v1", kube.Namespaces, checks.Namespace,
+		func(o *kube.Objects) runtime.Object { return o.Namespaces }},
+	"Node": {"v1", kube.Nodes, checks.Node,
+		func(o *kube.Objects) runtime.Object { return o.Nodes }},
+	"StorageClass": {"storage.k8s.io/v1", kube.StorageClasses, checks.StorageClass,
+		func(o *kube.Objects) runtime.Object { return o.StorageClasses }},
+	"VolumeAttachment": {"storage.k8s.io/v1", kube.VolumeAttachments, checks.VolumeAttachment,
+		func(o *kube.Objects) runtime.Object { return o.VolumeAttachments }},
+	"MutatingWebhookConfiguration": {"admissionregistration.k8s.io/v1", kube.MutatingWebhookConfigurations, checks.MutatingWebhookConfiguration,
+		func(o *kube.Objects) runtime.Object { return o.MutatingWebhookConfigurations }},
+	"ValidatingWebhookConfiguration": {"admissionregistration.k8s.io/v1", kube.ValidatingWebhookConfigurations, checks.ValidatingWebhookConfiguration,
+		func(o *kube.Objects) runtime.Object { return o.ValidatingWebhookConfigurations }},
+	"CronJob": {"batch/v1", kube.CronJobs, checks.CronJob,
+		func(o *kube.Objects) runtime.Object { return o.CronJobs }},
+	"Job": {"batch/v1", kube.Jobs, checks.Job,
+		func(o *kube.Objects) runtime.Object { return o.Jobs }},
+	"Deployment": {"apps/v1", kube.Deployments, checks.Deployment,
+		func(o *kube.Objects) runtime.Object { return o.Deployments }},
+	"StatefulSet": {"apps/v1", kube.StatefulSets, checks.StatefulSet,
+		func(o *kube.Objects) runtime.Object { return o.StatefulSets }},
+	"DaemonSet": {"apps/v1", kube.DaemonSets, checks.DaemonSet,
+		func(o *kube.Objects) runtime.Object { return o.DaemonSets }},
+	"PriorityClass": {"scheduling.k8s.io/v1", kube.PriorityClasses, checks.PriorityClass,
+		func(o *kube.Objects) runtime.Object { return o.PriorityClasses }},
+	"HorizontalPodAutoscaler": {"autoscaling/v2", kube.HorizontalPodAutoscalers, checks.HorizontalPodAutoscaler,
+		func(o *kube.Objects) runtime.Object { return o.HorizontalPodAutoscalers }},
+	"Role": {"rbac.authorization.k8s.io/v1", kube.Roles, checks.Role,
+		func(o *kube.Objects) runtime.Object { return o.Roles }},
+	"ClusterRole": {"rbac.authorization.k8s.io/v1", kube.ClusterRoles, checks.ClusterRole,
+		func(o *kube.Objects) runtime.Object { return o.ClusterRoles }},
+	"RoleBinding": {"rbac.authorization.k8s.io/v1", kube.RoleBindings, checks.RoleBinding,
+		func(o *kube.Objects) runtime.Object { return o.RoleBindings }},
+	"ClusterRoleBinding": {"rbac.authorization.k8s.io/v1", kube.ClusterRoleBindings, checks.ClusterRoleBinding,
+		func(o *kube.Objects) runtime.Object { return o.ClusterRoleBindings }},
+	"NetworkPolicy": {"networking.k8s.io/v1", kube.NetworkPolicies, checks.NetworkPolicy,
+		func(o *kube.Objects) runtime.Object { return o.NetworkPolicies }},
+	"Ingress": {"networking.k8s.io/v1", kube.Ingresses, checks.Ingress,
+		func(o *kube.Objects) runtime.Object { return o.Ingresses }},
+}
+
+// object is an object a rule is evaluated against: its metadata, reported
+// in diagnostics, and its content as exposed to expressions and messages.
+type object struct {
+	meta    *metav1.ObjectMeta
+	content map[string]interface{}
+}
+
+// objects returns the objects of the target kind, converted to the form
+// they have in manifests.
+func (t target) objects(kind string, objs *kube.Objects) ([]object, error) {
+	list := t.list(objs)
+	if list == nil || reflect.ValueOf(list).IsNil() {
+		return nil, nil
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]object, 0, len(items))
+	for _, item := range items {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(item)
+		if err != nil {
+			return nil, err
+		}
+		content["apiVersion"] = t.apiVersion
+		content["kind"] = kind
+		accessor, ok := item.(metav1.ObjectMetaAccessor)
+		if !ok {
+			continue
+		}
+		m, ok := accessor.GetObjectMeta().(*metav1.ObjectMeta)
+		if !ok {
+			continue
+		}
+		ret = append(ret, object{meta: m, content: content})
+	}
+	return ret, nil
+}
@@ -21,18 +21,46 @@ import (
 	"errors"
 	"fmt"
 	"runtime/debug"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/digitalocean/clusterlint/kube"
 	"golang.org/x/sync/errgroup"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
+// RunOption configures how checks are run.
+type RunOption func(*runOptions)
+
+type runOptions struct {
+	checkTimeout time.Duration
+	fetchOptions []kube.FetchOption
+}
+
+// WithCheckTimeout returns a RunOption that limits how long each check may
+// run. Checks that don't complete in time are reported as failures in the
+// CheckResult. A timeout of zero means checks are not limited.
+func WithCheckTimeout(timeout time.Duration) RunOption {
+	return func(o *runOptions) {
+		o.checkTimeout = timeout
+	}
+}
+
+// WithFetchOptions returns a RunOption that passes options to FetchObjects.
+// Checks that read resources which could not be fetched are skipped.
+func WithFetchOptions(opts ...kube.FetchOption) RunOption {
+	return func(o *runOptions) {
+		o.fetchOptions = append(o.fetchOptions, opts...)
+	}
+}
+
 // Run applies the filters and runs the resultant check list in parallel
-func Run(ctx context.Context, client *kube.Client, checkFilter CheckFilter, diagnosticFilter DiagnosticFilter, objectFilter kube.ObjectFilter) (*CheckResult, error) {
-	objects, err := client.FetchObjects(ctx, objectFilter)
-	if err != nil {
-		return nil, err
+func Run(ctx context.Context, client *kube.Client, checkFilter CheckFilter, diagnosticFilter DiagnosticFilter, objectFilter kube.ObjectFilter, opts ...RunOption) (*CheckResult, error) {
+	options := &runOptions{}
+	for _, opt := range opts {
+		opt(options)
 	}
 
 	all, err := checkFilter.FilterChecks()
@@ -42,22 +70,47 @@ func Run(ctx context.Context, client *kube.Client, checkFilter CheckFilter, diag
 	if len(all) == 0 {
 		return nil, errors.New("No checks to run. Are you sure that you provided the right names for groups and checks?")
 	}
+
+	// Only fetch the objects that the checks read.
+	var resources []kube.Resource
+	var unstructured []schema.GroupVersionResource
+	for _, check := range all {
+		resources = append(resources, Resources(check)...)
+		unstructured = append(unstructured, unstructuredResources(check)...)
+	}
+	fetchOpts := append([]kube.FetchOption{
+		kube.WithResources(resources...),
+		kube.WithMetadataOnly(metadataOnly(all)...),
+		kube.WithUnstructured(unstructured...),
+	}, options.fetchOptions...)
+	objects, err := client.FetchObjects(ctx, objectFilter, fetchOpts...)
+	if err != nil {
+		return nil, err
+	}
 	var diagnostics []Diagnostic
+	var statuses []CheckStatus
 	var mu sync.Mutex
-	var g errgroup.Group
+	g, gCtx := errgroup.WithContext(ctx)
 	checkDuration := make(map[string]time.Duration)
 	for _, check := range all {
 		check := check
-		g.Go(func() (err error) {
-			defer func() {
-				if r := recover(); r != nil {
-					err = fmt.Errorf("Recovered from panic in check '%s': %v", check.Name(), string(debug.Stack()))
-				}
-			}()
+		g.Go(func() error {
+			if missing := unavailable(check, objects); len(missing) > 0 {
+				mu.Lock()
+				statuses = append(statuses, CheckStatus{
+					Check:  check.Name(),
+					Status: StatusSkipped,
+					Error:  fmt.Sprintf("missing permission for %s", strings.Join(missing, ", ")),
+				})
+				mu.Unlock()
+				return nil
+			}
 			start := time.Now()
-			d, err := check.Run(objects)
+			d, status := runCheck(gCtx, check, objects, options.checkTimeout)
 			elapsed := time.Since(start)
-			if err != nil {
+			// Checks stopped because the run was cancelled have no outcome
+			// to report, and neither does the run.
+			if err := gCtx.Err(); err != nil && status.Status != StatusOK {
 				return err
 			}
 			mu.Lock()
@@ -68,6 +121,7 @@ func Run(ctx context.Context, client *kube.Client, checkFilter CheckFilter, diag
 				d[i].Check = check.Name()
 			}
 			diagnostics = append(diagnostics, d...)
+			statuses = append(statuses, status)
 			checkDuration[check.Name()] = elapsed
 			mu.Unlock()
 			return nil
@@ -79,10 +133,60 @@ func Run(ctx context.Context, client *kube.Client, checkFilter CheckFilter, diag
 	}
 	diagnostics = filterEnabled(diagnostics)
 	diagnostics = filterSeverity(diagnosticFilter.Severity, diagnostics)
-	CheckResult := &CheckResult{Diagnostics: diagnostics, Durations: checkDuration}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Check < statuses[j].Check })
+	CheckResult := &CheckResult{Diagnostics: diagnostics, Checks: statuses, Durations: checkDuration}
 	return CheckResult, err
 }
 
+// runCheck runs a single check and describes its outcome. Errors, panics and
+// timeouts of the check are turned into its status.
+func runCheck(ctx context.Context, check Check, objects *kube.Objects, timeout time.Duration) (d []Diagnostic, status CheckStatus) {
+	status = CheckStatus{Check: check.Name(), Status: StatusOK}
+	defer func() {
+		if r := recover(); r != nil {
+			stack := debug.Stack()
+			if p, ok := r.(checkPanic); ok {
+				r, stack = p.value, p.stack
+			}
+			d = nil
+			status.Status = StatusPanicked
+			status.Error = fmt.Sprintf("recovered from panic: %v", r)
+			status.Stack = string(stack)
+		}
+	}()
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	d, err := WithContext(check).RunWithContext(ctx, objects)
+	switch {
+	case err == nil:
+	case errors.Is(err, context.DeadlineExceeded) && ctx.Err() == context.DeadlineExceeded:
+		d = nil
+		status.Status = StatusTimedOut
+		status.Error = fmt.Sprintf("check timed out after %s", timeout)
+	default:
+		d = nil
+		status.Status = StatusError
+		status.Error = err.Error()
+	}
+	return d, status
+}
+
+// unavailable returns the names of the resources a check reads that could not
+// be fetched.
+func unavailable(check Check, objects *kube.Objects) []string {
+	var missing []string
+	for _, resource := range Resources(check) {
+		if !objects.Available(resource) && !contains(missing, resource.String()) {
+			missing = append(missing, resource.String())
+		}
+	}
+	return missing
+}
+
 func filterEnabled(diagnostics []Diagnostic) []Diagnostic {
 	var ret []Diagnostic
 	for _, d := range diagnostics {
@@ -109,5 +213,48 @@ func filterSeverity(level Severity, diagnostics []Diagnostic) []Diagnostic {
 // CheckResult is the output returned by the Run function
 type CheckResult struct {
 	Diagnostics []Diagnostic
+	Checks      []CheckStatus
 	Durations   map[string]time.Duration
 }
+
+// Failed returns the statuses of the checks that did not complete, so their
+// diagnostics are missing from the result.
+func (r *CheckResult) Failed() []CheckStatus {
+	var failed []CheckStatus
+	for _, status := range r.Checks {
+		if status.Status.Failed() {
+			failed = append(failed, status)
+		}
+	}
+	return failed
+}
+
+// Status is the outcome of running a check.
+type Status string
+
+const (
+	// StatusOK means that the check ran to completion.
+	StatusOK Status = "ok"
+	// StatusError means that the check returned an error.
+	StatusError Status = "error"
+	// StatusPanicked means that the check panicked.
+	StatusPanicked Status = "panicked"
+	// StatusSkipped means that the check was not run.
+	StatusSkipped Status = "skipped"
+	// StatusTimedOut means that the check did not complete in time.
+	StatusTimedOut Status = "timed out"
+)
+
+// Failed reports whether a check with this status failed to run.
+func (s Status) Failed() bool {
+	return s == StatusError || s == StatusPanicked || s == StatusTimedOut
+}
+
+// CheckStatus describes the outcome of running a check. Error describes why a
+// check failed or was skipped, and Stack is the stack trace of a panic.
+type CheckStatus struct {
+	Check  string
+	Status Status
+	Error  string `json:",omitempty"`
+	Stack  string `json:",omitempty"`
+}
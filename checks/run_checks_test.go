@@ -18,68 +18,215 @@ package checks
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/digitalocean/clusterlint/kube"
 	csi "github.com/kubernetes-csi/external-snapshotter/client/v4/clientset/versioned/fake"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
 )
 
 func TestRun(t *testing.T) {
 	tests := []struct {
 		name                string
 		check               string
+		expectedStatus      Status
 		expectedErr         string
 		expectedDiagnostics int
 	}{
 		{
 			name:                "test failure",
 			check:               "always-fail",
+			expectedStatus:      StatusOK,
 			expectedDiagnostics: 1,
 		},
 		{
-			name:        "test panic",
-			check:       "panic-check",
-			expectedErr: "Recovered from panic in check 'panic-check':",
+			name:           "test panic",
+			check:          "panic-check",
+			expectedStatus: StatusPanicked,
+			expectedErr:    "recovered from panic: runtime error: invalid memory address or nil pointer dereference",
+		},
+		{
+			name:           "test error",
+			check:          "error-check",
+			expectedStatus: StatusError,
+			expectedErr:    "objects are missing",
 		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			Register(&alwaysFail{})
 			Register(&panicCheck{})
+			Register(&errorCheck{})
 			filter := CheckFilter{
 				IncludeChecks: []string{test.check},
 			}
 
-			client := &kube.Client{
-				KubeClient: fake.NewSimpleClientset(),
-				CSIClient:  csi.NewSimpleClientset(),
-			}
-			client.KubeClient.CoreV1().Namespaces().Create(context.Background(), &corev1.Namespace{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: "kube-system",
-				},
-			}, metav1.CreateOptions{})
-
 			check, err := Get(test.check)
 			assert.NoError(t, err)
 
-			result, err := Run(context.Background(), client, filter, DiagnosticFilter{}, kube.ObjectFilter{})
-			if test.expectedErr == "" {
-				assert.NoError(t, err)
-				assert.Len(t, result.Diagnostics, 1)
-				assert.Equal(t, check.Name(), result.Diagnostics[0].Check)
+			result, err := Run(context.Background(), initClient(), filter, DiagnosticFilter{}, kube.ObjectFilter{})
+			assert.NoError(t, err)
+			assert.Len(t, result.Diagnostics, test.expectedDiagnostics)
+			for _, d := range result.Diagnostics {
+				assert.Equal(t, check.Name(), d.Check)
+			}
+			assert.Len(t, result.Checks, 1)
+			assert.Equal(t, check.Name(), result.Checks[0].Check)
+			assert.Equal(t, test.expectedStatus, result.Checks[0].Status)
+			assert.Equal(t, test.expectedErr, result.Checks[0].Error)
+			if test.expectedStatus == StatusPanicked {
+				assert.Contains(t, result.Checks[0].Stack, "panicCheck")
 			} else {
-				assert.Contains(t, err.Error(), test.expectedErr)
-				assert.Nil(t, result)
+				assert.Empty(t, result.Checks[0].Stack)
 			}
 		})
 	}
 }
 
+func TestRunPartialResults(t *testing.T) {
+	Register(&alwaysFail{})
+	Register(&panicCheck{})
+	Register(&errorCheck{})
+	filter := CheckFilter{
+		IncludeChecks: []string{"always-fail", "panic-check", "error-check"},
+	}
+
+	result, err := Run(context.Background(), initClient(), filter, DiagnosticFilter{}, kube.ObjectFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, result.Diagnostics, 1)
+	assert.Equal(t, "always-fail", result.Diagnostics[0].Check)
+
+	var failed []string
+	for _, status := range result.Failed() {
+		failed = append(failed, status.Check)
+	}
+	assert.Equal(t, []string{"error-check", "panic-check"}, failed)
+}
+
+func TestRunCheckTimeout(t *testing.T) {
+	Register(&alwaysFail{})
+	Register(&slowCheck{})
+	Register(&contextCheck{})
+	filter := CheckFilter{
+		IncludeChecks: []string{"always-fail", "slow-check", "context-check"},
+	}
+
+	result, err := Run(context.Background(), initClient(), filter, DiagnosticFilter{}, kube.ObjectFilter{}, WithCheckTimeout(10*time.Millisecond))
+	assert.NoError(t, err)
+	assert.Len(t, result.Diagnostics, 1)
+	assert.Equal(t, "always-fail", result.Diagnostics[0].Check)
+	assert.Equal(t, []CheckStatus{
+		{Check: "always-fail", Status: StatusOK},
+		{Check: "context-check", Status: StatusTimedOut, Error: "check timed out after 10ms"},
+		{Check: "slow-check", Status: StatusTimedOut, Error: "check timed out after 10ms"},
+	}, result.Checks)
+}
+
+func TestRunCancelled(t *testing.T) {
+	Register(&contextCheck{})
+	filter := CheckFilter{
+		IncludeChecks: []string{"context-check"},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	client := initClient()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	result, err := Run(ctx, client, filter, DiagnosticFilter{}, kube.ObjectFilter{}, WithCheckTimeout(time.Minute))
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, result)
+}
+
+func TestRunSkipsUnavailable(t *testing.T) {
+	Register(&alwaysFail{})
+	Register(&errorCheck{})
+	filter := CheckFilter{
+		IncludeChecks: []string{"always-fail", "error-check"},
+	}
+	client := initClient()
+	client.KubeClient.(*fake.Clientset).PrependReactor("list", "secrets", func(action ktesting.Action) (bool, runtime.Object, error) {
+		return true, nil, kerrors.NewForbidden(action.GetResource().GroupResource(), "", errors.New("access denied"))
+	})
+
+	result, err := Run(context.Background(), client, filter, DiagnosticFilter{}, kube.ObjectFilter{}, WithFetchOptions(kube.Lenient()))
+	assert.NoError(t, err)
+	assert.Len(t, result.Diagnostics, 1)
+	assert.Equal(t, []CheckStatus{
+		{Check: "always-fail", Status: StatusOK},
+		{Check: "error-check", Status: StatusSkipped, Error: "missing permission for secrets"},
+	}, result.Checks)
+	assert.Empty(t, result.Failed())
+}
+
+func TestRunFetchesDeclaredResources(t *testing.T) {
+	Register(&alwaysFail{})
+	filter := CheckFilter{
+		IncludeChecks: []string{"always-fail"},
+	}
+	client := initClient()
+
+	_, err := Run(context.Background(), client, filter, DiagnosticFilter{}, kube.ObjectFilter{})
+	assert.NoError(t, err)
+	var listed []string
+	for _, action := range client.KubeClient.(*fake.Clientset).Actions() {
+		if action.GetVerb() == "list" {
+			listed = append(listed, action.GetResource().Resource)
+		}
+	}
+	assert.Equal(t, []string{"pods"}, listed)
+}
+
+func TestRunOutputHasNoSecretValues(t *testing.T) {
+	Register(&secretCheck{})
+	filter := CheckFilter{
+		IncludeChecks: []string{"secret-check"},
+	}
+	client := initClient()
+	client.KubeClient.CoreV1().Secrets("default").Create(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "db",
+			Namespace:   "default",
+			Annotations: map[string]string{"kubectl.kubernetes.io/last-applied-configuration": `{"stringData":{"password":"hunter2"}}`},
+		},
+		Data:       map[string][]byte{"password": []byte("hunter2")},
+		StringData: map[string]string{"password": "hunter2"},
+	}, metav1.CreateOptions{})
+
+	result, err := Run(context.Background(), client, filter, DiagnosticFilter{}, kube.ObjectFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, result.Diagnostics, 1)
+	out, err := json.Marshal(result)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(out), "hunter2")
+	assert.NotContains(t, string(out), base64.StdEncoding.EncodeToString([]byte("hunter2")))
+}
+
+func initClient() *kube.Client {
+	client := &kube.Client{
+		KubeClient: fake.NewSimpleClientset(),
+		CSIClient:  csi.NewSimpleClientset(),
+	}
+	client.KubeClient.CoreV1().Namespaces().Create(context.Background(), &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "kube-system",
+		},
+	}, metav1.CreateOptions{})
+	return client
+}
+
 type alwaysFail struct{}
 
 // Name returns a unique name for this check.
@@ -98,6 +245,11 @@ func (nc *alwaysFail) Description() string {
 	return "Does not check anything. Always returns an error.."
 }
 
+// Resources returns the resources whose objects this check reads.
+func (nc *alwaysFail) Resources() []kube.Resource {
+	return []kube.Resource{kube.Pods}
+}
+
 // Run runs this check on a set of Kubernetes objects. It can return warnings
 // (low-priority problems) and errors (high-priority problems) as well as an
 // error value indicating that the check failed to run.
@@ -139,3 +291,125 @@ func (nc *panicCheck) Run(*kube.Objects) ([]Diagnostic, error) {
 	_ = s.x
 	return nil, nil
 }
+
+type errorCheck struct{}
+
+// Name returns a unique name for this check.
+func (nc *errorCheck) Name() string {
+	return "error-check"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (nc *errorCheck) Groups() []string {
+	return nil
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (nc *errorCheck) Description() string {
+	return "Does not check anything. Always fails to run."
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (nc *errorCheck) Run(*kube.Objects) ([]Diagnostic, error) {
+	return nil, errors.New("objects are missing")
+}
+
+type secretCheck struct{}
+
+// Name returns a unique name for this check.
+func (nc *secretCheck) Name() string {
+	return "secret-check"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (nc *secretCheck) Groups() []string {
+	return nil
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (nc *secretCheck) Description() string {
+	return "Reports all secrets along with their values."
+}
+
+// Resources returns the resources whose objects this check reads.
+func (nc *secretCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Secrets}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (nc *secretCheck) Run(objects *kube.Objects) ([]Diagnostic, error) {
+	var diagnostics []Diagnostic
+	for i := range objects.Secrets.Items {
+		secret := &objects.Secrets.Items[i]
+		diagnostics = append(diagnostics, Diagnostic{
+			Severity: Warning,
+			Message:  fmt.Sprintf("Secret has values %v %v", secret.Data, secret.StringData),
+			Kind:     Secret,
+			Object:   &secret.ObjectMeta,
+		})
+	}
+	return diagnostics, nil
+}
+
+type slowCheck struct{}
+
+// Name returns a unique name for this check.
+func (nc *slowCheck) Name() string {
+	return "slow-check"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (nc *slowCheck) Groups() []string {
+	return nil
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (nc *slowCheck) Description() string {
+	return "Does not check anything. Takes a long time and ignores cancellation."
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (nc *slowCheck) Run(*kube.Objects) ([]Diagnostic, error) {
+	time.Sleep(time.Second)
+	return nil, nil
+}
+
+type contextCheck struct{}
+
+// Name returns a unique name for this check.
+func (nc *contextCheck) Name() string {
+	return "context-check"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (nc *contextCheck) Groups() []string {
+	return nil
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (nc *contextCheck) Description() string {
+	return "Does not check anything. Runs until it is cancelled."
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (nc *contextCheck) Run(objects *kube.Objects) ([]Diagnostic, error) {
+	return nc.RunWithContext(context.Background(), objects)
+}
+
+// RunWithContext runs this check until the context is done.
+func (nc *contextCheck) RunWithContext(ctx context.Context, _ *kube.Objects) ([]Diagnostic, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
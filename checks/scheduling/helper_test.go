@@ -0,0 +1,71 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"github.com/digitalocean/clusterlint/kube"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func initObjects() *kube.Objects {
+	return &kube.Objects{
+		Nodes: &corev1.NodeList{
+			Items: []corev1.Node{
+				node("node-1", "nyc1-a", "pool-a"),
+				node("node-2", "nyc1-b", "pool-a"),
+				node("node-3", "nyc1-b", "pool-b"),
+			},
+		},
+		Pods:         &corev1.PodList{},
+		Deployments:  &appsv1.DeploymentList{},
+		StatefulSets: &appsv1.StatefulSetList{},
+	}
+}
+
+func node(name, zone, pool string) corev1.Node {
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				corev1.LabelHostname:     name,
+				corev1.LabelTopologyZone: zone,
+				"pool":                   pool,
+			},
+		},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+}
+
+// withReplica adds a pod of the deployment `web` running on the given node.
+func withReplica(objs *kube.Objects, name, nodeName string) {
+	controller := true
+	objs.Pods.Items = append(objs.Pods.Items, corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "k8s",
+			Labels:    map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: "5d8f7c9b4"},
+			OwnerReferences: []metav1.OwnerReference{
+				{Kind: "ReplicaSet", Name: "web-5d8f7c9b4", Controller: &controller},
+			},
+		},
+		Spec: corev1.PodSpec{NodeName: nodeName},
+	})
+}
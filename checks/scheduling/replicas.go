@@ -0,0 +1,200 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"fmt"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func init() {
+	checks.Register(&replicaPlacementCheck{})
+	checks.Register(&replicaSpreadCheck{})
+}
+
+type replicaPlacementCheck struct{}
+
+// Name returns a unique name for this check.
+func (r *replicaPlacementCheck) Name() string {
+	return "replica-placement"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (r *replicaPlacementCheck) Groups() []string {
+	return []string{"scheduling", "doks"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (r *replicaPlacementCheck) Description() string {
+	return "Checks if all replicas of a replicated workload run on the same node or in the same zone"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (r *replicaPlacementCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Nodes, kube.Pods}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (r *replicaPlacementCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	zones := make(map[string]string)
+	clusterZones := make(map[string]struct{})
+	for _, node := range objects.Nodes.Items {
+		if zone, ok := node.Labels[corev1.LabelTopologyZone]; ok {
+			zones[node.Name] = zone
+			clusterZones[zone] = struct{}{}
+		}
+	}
+
+	var order []string
+	workloads := make(map[string]checks.Workload)
+	owners := make(map[string][]metav1.OwnerReference)
+	nodes := make(map[string][]string)
+	for _, pod := range scheduledPods(objects.Pods.Items) {
+		workload := checks.WorkloadForPod(pod)
+		if !isReplicated(workload.Kind) {
+			continue
+		}
+		key := workload.Key()
+		if _, ok := workloads[key]; !ok {
+			order = append(order, key)
+			workloads[key] = workload
+			owners[key] = pod.ObjectMeta.GetOwnerReferences()
+		}
+		nodes[key] = append(nodes[key], pod.Spec.NodeName)
+	}
+
+	for _, key := range order {
+		replicas := nodes[key]
+		if len(replicas) < 2 {
+			continue
+		}
+		var message string
+		if node, ok := same(replicas, func(node string) string { return node }); ok {
+			message = fmt.Sprintf("All %d replicas run on node `%s`. The workload is unavailable while the node is drained or replaced.", len(replicas), node)
+		} else if zone, ok := same(replicas, func(node string) string { return zones[node] }); ok && zone != "" && len(clusterZones) > 1 {
+			message = fmt.Sprintf("All %d replicas run in zone `%s`. The workload is unavailable if the zone fails.", len(replicas), zone)
+		} else {
+			continue
+		}
+		workload := workloads[key]
+		d := checks.Diagnostic{
+			Severity: checks.Warning,
+			Message:  message,
+			Kind:     workload.Kind,
+			Object:   workload.Object,
+			Owners:   owners[key],
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics, nil
+}
+
+type replicaSpreadCheck struct{}
+
+// Name returns a unique name for this check.
+func (r *replicaSpreadCheck) Name() string {
+	return "replica-spread"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (r *replicaSpreadCheck) Groups() []string {
+	return []string{"scheduling"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (r *replicaSpreadCheck) Description() string {
+	return "Checks if workloads with more than one replica have topology spread constraints or pod anti-affinity"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (r *replicaSpreadCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Deployments, kube.StatefulSets}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (r *replicaSpreadCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	check := func(kind checks.Kind, meta *metav1.ObjectMeta, replicas *int32, spec corev1.PodSpec) {
+		// replicas defaults to 1.
+		if replicas == nil || *replicas < 2 || spreads(spec) {
+			return
+		}
+		diagnostics = append(diagnostics, checks.Diagnostic{
+			Severity: checks.Suggestion,
+			Message:  fmt.Sprintf("Workload with %d replicas has no topology spread constraints or pod anti-affinity, so all replicas may be scheduled onto the same node", *replicas),
+			Kind:     kind,
+			Object:   meta,
+			Owners:   meta.GetOwnerReferences(),
+		})
+	}
+
+	for i := range objects.Deployments.Items {
+		deployment := &objects.Deployments.Items[i]
+		check(checks.Deployment, &deployment.ObjectMeta, deployment.Spec.Replicas, deployment.Spec.Template.Spec)
+	}
+	for i := range objects.StatefulSets.Items {
+		statefulSet := &objects.StatefulSets.Items[i]
+		check(checks.StatefulSet, &statefulSet.ObjectMeta, statefulSet.Spec.Replicas, statefulSet.Spec.Template.Spec)
+	}
+	return diagnostics, nil
+}
+
+// isReplicated reports whether a workload runs interchangeable replicas that
+// are meant to be available at the same time.
+func isReplicated(kind checks.Kind) bool {
+	switch kind {
+	case checks.Deployment, checks.ReplicaSet, checks.StatefulSet, checks.ReplicationController:
+		return true
+	}
+	return false
+}
+
+// spreads reports whether a pod spec asks the scheduler to spread its
+// replicas, either with topology spread constraints or pod anti-affinity.
+func spreads(spec corev1.PodSpec) bool {
+	if len(spec.TopologySpreadConstraints) > 0 {
+		return true
+	}
+	if spec.Affinity == nil || spec.Affinity.PodAntiAffinity == nil {
+		return false
+	}
+	antiAffinity := spec.Affinity.PodAntiAffinity
+	return len(antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution) > 0 ||
+		len(antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution) > 0
+}
+
+// same returns the value all elements map to, if they map to the same value.
+func same(elements []string, value func(string) string) (string, bool) {
+	first := value(elements[0])
+	for _, e := range elements[1:] {
+		if value(e) != first {
+			return "", false
+		}
+	}
+	return first, true
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReplicaChecksMeta(t *testing.T) {
+	for name, check := range map[string]checks.Check{
+		"replica-placement": &replicaPlacementCheck{},
+		"replica-spread":    &replicaSpreadCheck{},
+	} {
+		assert.Equal(t, name, check.Name())
+		assert.Contains(t, check.Groups(), "scheduling")
+		assert.NotEmpty(t, check.Description())
+
+		registered, err := checks.Get(name)
+		assert.NoError(t, err)
+		assert.IsType(t, check, registered)
+	}
+}
+
+func TestReplicaPlacementWarning(t *testing.T) {
+	tests := []struct {
+		name     string
+		nodes    []string
+		zones    bool
+		expected []string
+	}{
+		{
+			name:     "spread across nodes and zones",
+			nodes:    []string{"node-1", "node-2"},
+			zones:    true,
+			expected: nil,
+		},
+		{
+			name:     "single replica",
+			nodes:    []string{"node-1"},
+			zones:    true,
+			expected: nil,
+		},
+		{
+			name:     "same node",
+			nodes:    []string{"node-2", "node-2", "node-2"},
+			zones:    true,
+			expected: []string{"All 3 replicas run on node `node-2`. The workload is unavailable while the node is drained or replaced."},
+		},
+		{
+			name:     "same zone",
+			nodes:    []string{"node-2", "node-3"},
+			zones:    true,
+			expected: []string{"All 2 replicas run in zone `nyc1-b`. The workload is unavailable if the zone fails."},
+		},
+		{
+			name:     "nodes without zones",
+			nodes:    []string{"node-2", "node-3"},
+			zones:    false,
+			expected: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := initObjects()
+			if !test.zones {
+				for i := range objs.Nodes.Items {
+					delete(objs.Nodes.Items[i].Labels, corev1.LabelTopologyZone)
+				}
+			}
+			for _, node := range test.nodes {
+				withReplica(objs, "web-5d8f7c9b4-"+node, node)
+			}
+
+			d, err := (&replicaPlacementCheck{}).Run(objs)
+			assert.NoError(t, err)
+			var messages []string
+			for _, diagnostic := range d {
+				assert.Equal(t, checks.Deployment, diagnostic.Kind)
+				assert.Equal(t, "web", diagnostic.Object.Name)
+				assert.Equal(t, objs.Pods.Items[0].OwnerReferences, diagnostic.Owners)
+				messages = append(messages, diagnostic.Message)
+			}
+			assert.Equal(t, test.expected, messages)
+		})
+	}
+}
+
+func TestReplicaSpreadSuggestion(t *testing.T) {
+	replicas := func(n int32) *int32 { return &n }
+
+	tests := []struct {
+		name     string
+		replicas *int32
+		spec     corev1.PodSpec
+		expected bool
+	}{
+		{
+			name:     "single replica",
+			replicas: replicas(1),
+			expected: false,
+		},
+		{
+			name:     "default replicas",
+			expected: false,
+		},
+		{
+			name:     "no spreading",
+			replicas: replicas(3),
+			expected: true,
+		},
+		{
+			name:     "topology spread constraints",
+			replicas: replicas(3),
+			spec: corev1.PodSpec{TopologySpreadConstraints: []corev1.TopologySpreadConstraint{{
+				MaxSkew:           1,
+				TopologyKey:       corev1.LabelHostname,
+				WhenUnsatisfiable: corev1.ScheduleAnyway,
+			}}},
+			expected: false,
+		},
+		{
+			name:     "preferred pod anti-affinity",
+			replicas: replicas(3),
+			spec: corev1.PodSpec{Affinity: &corev1.Affinity{PodAntiAffinity: &corev1.PodAntiAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{
+					Weight:          100,
+					PodAffinityTerm: corev1.PodAffinityTerm{TopologyKey: corev1.LabelHostname},
+				}},
+			}}},
+			expected: false,
+		},
+		{
+			name:     "node affinity only",
+			replicas: replicas(2),
+			spec:     corev1.PodSpec{Affinity: &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{}}},
+			expected: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := initObjects()
+			objs.StatefulSets.Items = []appsv1.StatefulSet{{
+				ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "k8s"},
+				Spec: appsv1.StatefulSetSpec{
+					Replicas: test.replicas,
+					Template: corev1.PodTemplateSpec{Spec: test.spec},
+				},
+			}}
+
+			var expected []checks.Diagnostic
+			if test.expected {
+				expected = append(expected, checks.Diagnostic{
+					Severity: checks.Suggestion,
+					Message:  fmt.Sprintf("Workload with %d replicas has no topology spread constraints or pod anti-affinity, so all replicas may be scheduled onto the same node", *test.replicas),
+					Kind:     checks.StatefulSet,
+					Object:   &objs.StatefulSets.Items[0].ObjectMeta,
+				})
+			}
+
+			d, err := (&replicaSpreadCheck{}).Run(objs)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, d)
+		})
+	}
+}
+
+func TestReplicaSpreadDeployment(t *testing.T) {
+	replicas := int32(2)
+	objs := &kube.Objects{
+		Deployments: &appsv1.DeploymentList{Items: []appsv1.Deployment{{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "k8s"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		}}},
+		StatefulSets: &appsv1.StatefulSetList{},
+	}
+
+	d, err := (&replicaSpreadCheck{}).Run(objs)
+	assert.NoError(t, err)
+	assert.Len(t, d, 1)
+	assert.Equal(t, checks.Deployment, d[0].Kind)
+}
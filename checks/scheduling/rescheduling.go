@@ -0,0 +1,93 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+)
+
+func init() {
+	checks.Register(&podReschedulingCheck{})
+}
+
+type podReschedulingCheck struct{}
+
+// Name returns a unique name for this check.
+func (p *podReschedulingCheck) Name() string {
+	return "pod-rescheduling"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (p *podReschedulingCheck) Groups() []string {
+	return []string{"scheduling", "doks"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (p *podReschedulingCheck) Description() string {
+	return "Checks if there are pods whose node selector, node affinity and tolerations do not allow them to be rescheduled onto any other node"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (p *podReschedulingCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Nodes, kube.Pods}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (p *podReschedulingCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	// A single node cluster has nowhere else to reschedule pods to, so every
+	// pod would be reported.
+	if len(objects.Nodes.Items) < 2 {
+		return nil, nil
+	}
+
+	for _, pod := range scheduledPods(objects.Pods.Items) {
+		var reasons []string
+		for i := range objects.Nodes.Items {
+			node := &objects.Nodes.Items[i]
+			if node.Name == pod.Spec.NodeName {
+				continue
+			}
+			reason := unschedulableReason(pod.Spec, node)
+			if reason == "" {
+				reasons = nil
+				break
+			}
+			reasons = append(reasons, fmt.Sprintf("%s (%s)", node.Name, reason))
+		}
+		if len(reasons) == 0 {
+			continue
+		}
+		d := checks.Diagnostic{
+			Severity: checks.Warning,
+			Message:  fmt.Sprintf("Pod cannot be rescheduled onto any other node and stays pending when node `%s` is drained or replaced", pod.Spec.NodeName),
+			Kind:     checks.Pod,
+			Object:   &pod.ObjectMeta,
+			Owners:   pod.ObjectMeta.GetOwnerReferences(),
+			Details:  fmt.Sprintf("Other nodes: %s", strings.Join(reasons, ", ")),
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics, nil
+}
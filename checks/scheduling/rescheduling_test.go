@@ -0,0 +1,133 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"testing"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodReschedulingCheckMeta(t *testing.T) {
+	podReschedulingCheck := podReschedulingCheck{}
+	assert.Equal(t, "pod-rescheduling", podReschedulingCheck.Name())
+	assert.Equal(t, []string{"scheduling", "doks"}, podReschedulingCheck.Groups())
+	assert.NotEmpty(t, podReschedulingCheck.Description())
+}
+
+func TestPodReschedulingCheckRegistration(t *testing.T) {
+	podReschedulingCheck := &podReschedulingCheck{}
+	check, err := checks.Get("pod-rescheduling")
+	assert.NoError(t, err)
+	assert.Equal(t, check, podReschedulingCheck)
+}
+
+func TestPodReschedulingWarning(t *testing.T) {
+	tests := []struct {
+		name     string
+		objs     func(*kube.Objects)
+		expected []checks.Diagnostic
+	}{
+		{
+			name:     "no constraints",
+			expected: nil,
+		},
+		{
+			name: "another node in the pool",
+			objs: func(objs *kube.Objects) {
+				objs.Pods.Items[0].Spec.NodeSelector = map[string]string{"pool": "pool-a"}
+			},
+			expected: nil,
+		},
+		{
+			name: "only node in the pool",
+			objs: func(objs *kube.Objects) {
+				objs.Pods.Items[0].Spec.NodeName = "node-3"
+				objs.Pods.Items[0].Spec.NodeSelector = map[string]string{"pool": "pool-b"}
+			},
+			expected: []checks.Diagnostic{{
+				Severity: checks.Warning,
+				Message:  "Pod cannot be rescheduled onto any other node and stays pending when node `node-3` is drained or replaced",
+				Kind:     checks.Pod,
+				Details:  "Other nodes: node-1 (node does not match the node selector), node-2 (node does not match the node selector)",
+			}},
+		},
+		{
+			name: "other nodes are cordoned or tainted",
+			objs: func(objs *kube.Objects) {
+				objs.Nodes.Items[1].Spec.Unschedulable = true
+				objs.Nodes.Items[2].Spec.Taints = []corev1.Taint{{Key: "gpu", Effect: corev1.TaintEffectNoExecute}}
+			},
+			expected: []checks.Diagnostic{{
+				Severity: checks.Warning,
+				Message:  "Pod cannot be rescheduled onto any other node and stays pending when node `node-1` is drained or replaced",
+				Kind:     checks.Pod,
+				Details:  "Other nodes: node-2 (node is cordoned), node-3 (taint `gpu:NoExecute` is not tolerated)",
+			}},
+		},
+		{
+			name: "pinned to the node by host name",
+			objs: func(objs *kube.Objects) {
+				objs.Pods.Items[0].Spec.NodeSelector = map[string]string{corev1.LabelHostname: "node-1"}
+			},
+			expected: []checks.Diagnostic{{
+				Severity: checks.Warning,
+				Message:  "Pod cannot be rescheduled onto any other node and stays pending when node `node-1` is drained or replaced",
+				Kind:     checks.Pod,
+				Details:  "Other nodes: node-2 (node does not match the node selector), node-3 (node does not match the node selector)",
+			}},
+		},
+		{
+			name: "daemon set pod",
+			objs: func(objs *kube.Objects) {
+				controller := true
+				objs.Pods.Items[0].OwnerReferences = []metav1.OwnerReference{{Kind: "DaemonSet", Name: "agent", Controller: &controller}}
+				objs.Pods.Items[0].Spec.NodeSelector = map[string]string{corev1.LabelHostname: "node-1"}
+			},
+			expected: nil,
+		},
+		{
+			name: "single node cluster",
+			objs: func(objs *kube.Objects) {
+				objs.Nodes.Items = objs.Nodes.Items[:1]
+			},
+			expected: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := initObjects()
+			withReplica(objs, "web-5d8f7c9b4-x2x7q", "node-1")
+			if test.objs != nil {
+				test.objs(objs)
+			}
+			for i := range test.expected {
+				test.expected[i].Object = &objs.Pods.Items[0].ObjectMeta
+				test.expected[i].Owners = objs.Pods.Items[0].OwnerReferences
+			}
+
+			d, err := (&podReschedulingCheck{}).Run(objs)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, d)
+		})
+	}
+}
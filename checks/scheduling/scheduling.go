@@ -0,0 +1,170 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/digitalocean/clusterlint/checks"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// nodeNameField is the only field that node selector terms can match.
+const nodeNameField = "metadata.name"
+
+// scheduledPods returns the pods that are running on a node and are managed
+// by a controller that recreates them elsewhere when the node goes away.
+// DaemonSet pods are left out because they are bound to their node.
+func scheduledPods(pods []corev1.Pod) []*corev1.Pod {
+	var ret []*corev1.Pod
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Spec.NodeName == "" {
+			continue
+		}
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if _, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]; ok {
+			continue
+		}
+		switch checks.WorkloadForPod(pod).Kind {
+		case checks.Pod, checks.DaemonSet:
+			continue
+		}
+		ret = append(ret, pod)
+	}
+	return ret
+}
+
+// unschedulableReason returns why a pod cannot be scheduled onto a node, or
+// an empty string if it can. Resource requests and inter-pod affinity are not
+// taken into account, since they depend on what else runs on the node.
+func unschedulableReason(spec corev1.PodSpec, node *corev1.Node) string {
+	if node.Spec.Unschedulable {
+		return "node is cordoned"
+	}
+	if !nodeReady(node) {
+		return "node is not ready"
+	}
+	if !labels.SelectorFromSet(spec.NodeSelector).Matches(labels.Set(node.Labels)) {
+		return "node does not match the node selector"
+	}
+	if affinity := spec.Affinity; affinity != nil && affinity.NodeAffinity != nil {
+		required := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+		if required != nil && !matchesNodeSelectorTerms(required.NodeSelectorTerms, node) {
+			return "node does not match the required node affinity"
+		}
+	}
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		if !toleratesTaint(spec.Tolerations, taint) {
+			return fmt.Sprintf("taint `%s` is not tolerated", taint.ToString())
+		}
+	}
+	return ""
+}
+
+// nodeReady reports whether the node's Ready condition is true. Nodes without
+// conditions are assumed to be ready.
+func nodeReady(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return true
+}
+
+func toleratesTaint(tolerations []corev1.Toleration, taint *corev1.Taint) bool {
+	for i := range tolerations {
+		if tolerations[i].ToleratesTaint(taint) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesNodeSelectorTerms reports whether a node matches any of the terms.
+// The requirements of a term must all match.
+func matchesNodeSelectorTerms(terms []corev1.NodeSelectorTerm, node *corev1.Node) bool {
+	for _, term := range terms {
+		if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+			continue
+		}
+		matched := true
+		for _, requirement := range term.MatchExpressions {
+			value, ok := node.Labels[requirement.Key]
+			if !matchesRequirement(requirement, value, ok) {
+				matched = false
+			}
+		}
+		for _, requirement := range term.MatchFields {
+			if requirement.Key != nodeNameField || !matchesRequirement(requirement, node.Name, true) {
+				matched = false
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesRequirement(requirement corev1.NodeSelectorRequirement, value string, exists bool) bool {
+	switch requirement.Operator {
+	case corev1.NodeSelectorOpIn:
+		return exists && contains(requirement.Values, value)
+	case corev1.NodeSelectorOpNotIn:
+		return !exists || !contains(requirement.Values, value)
+	case corev1.NodeSelectorOpExists:
+		return exists
+	case corev1.NodeSelectorOpDoesNotExist:
+		return !exists
+	case corev1.NodeSelectorOpGt, corev1.NodeSelectorOpLt:
+		if !exists || len(requirement.Values) != 1 {
+			return false
+		}
+		actual, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return false
+		}
+		expected, err := strconv.ParseInt(requirement.Values[0], 10, 64)
+		if err != nil {
+			return false
+		}
+		if requirement.Operator == corev1.NodeSelectorOpGt {
+			return actual > expected
+		}
+		return actual < expected
+	}
+	return false
+}
+
+func contains(list []string, name string) bool {
+	for _, l := range list {
+		if l == name {
+			return true
+		}
+	}
+	return false
+}
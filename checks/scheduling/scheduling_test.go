@@ -0,0 +1,122 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestUnschedulableReason(t *testing.T) {
+	affinity := func(terms ...corev1.NodeSelectorTerm) *corev1.Affinity {
+		return &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: terms},
+		}}
+	}
+	expression := func(key string, op corev1.NodeSelectorOperator, values ...string) corev1.NodeSelectorTerm {
+		return corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: key, Operator: op, Values: values}}}
+	}
+	taint := corev1.Taint{Key: "dedicated", Value: "db", Effect: corev1.TaintEffectNoSchedule}
+
+	tests := []struct {
+		name     string
+		spec     corev1.PodSpec
+		node     func(*corev1.Node)
+		expected string
+	}{
+		{
+			name:     "no constraints",
+			expected: "",
+		},
+		{
+			name:     "cordoned",
+			node:     func(n *corev1.Node) { n.Spec.Unschedulable = true },
+			expected: "node is cordoned",
+		},
+		{
+			name:     "not ready",
+			node:     func(n *corev1.Node) { n.Status.Conditions[0].Status = corev1.ConditionFalse },
+			expected: "node is not ready",
+		},
+		{
+			name:     "matching node selector",
+			spec:     corev1.PodSpec{NodeSelector: map[string]string{"pool": "pool-a"}},
+			expected: "",
+		},
+		{
+			name:     "node selector",
+			spec:     corev1.PodSpec{NodeSelector: map[string]string{"pool": "pool-b"}},
+			expected: "node does not match the node selector",
+		},
+		{
+			name:     "matching node affinity",
+			spec:     corev1.PodSpec{Affinity: affinity(expression("pool", corev1.NodeSelectorOpIn, "pool-b"), expression("pool", corev1.NodeSelectorOpIn, "pool-a"))},
+			expected: "",
+		},
+		{
+			name:     "node affinity",
+			spec:     corev1.PodSpec{Affinity: affinity(expression("pool", corev1.NodeSelectorOpNotIn, "pool-a"))},
+			expected: "node does not match the required node affinity",
+		},
+		{
+			name:     "node affinity on missing label",
+			spec:     corev1.PodSpec{Affinity: affinity(expression("gpu", corev1.NodeSelectorOpExists))},
+			expected: "node does not match the required node affinity",
+		},
+		{
+			name:     "node affinity on node name",
+			spec:     corev1.PodSpec{Affinity: affinity(corev1.NodeSelectorTerm{MatchFields: []corev1.NodeSelectorRequirement{{Key: nodeNameField, Operator: corev1.NodeSelectorOpIn, Values: []string{"node-2"}}}})},
+			expected: "node does not match the required node affinity",
+		},
+		{
+			name:     "node affinity with numeric comparison",
+			spec:     corev1.PodSpec{Affinity: affinity(expression("cpus", corev1.NodeSelectorOpGt, "4"))},
+			node:     func(n *corev1.Node) { n.Labels["cpus"] = "8" },
+			expected: "",
+		},
+		{
+			name:     "untolerated taint",
+			node:     func(n *corev1.Node) { n.Spec.Taints = []corev1.Taint{taint} },
+			expected: "taint `dedicated=db:NoSchedule` is not tolerated",
+		},
+		{
+			name:     "tolerated taint",
+			spec:     corev1.PodSpec{Tolerations: []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "db"}}},
+			node:     func(n *corev1.Node) { n.Spec.Taints = []corev1.Taint{taint} },
+			expected: "",
+		},
+		{
+			name: "prefer no schedule taint",
+			node: func(n *corev1.Node) {
+				n.Spec.Taints = []corev1.Taint{{Key: "dedicated", Value: "db", Effect: corev1.TaintEffectPreferNoSchedule}}
+			},
+			expected: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			n := node("node-1", "nyc1-a", "pool-a")
+			if test.node != nil {
+				test.node(&n)
+			}
+			assert.Equal(t, test.expected, unschedulableReason(test.spec, &n))
+		})
+	}
+}
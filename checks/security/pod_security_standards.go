@@ -0,0 +1,510 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package security
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// enforceLabel is the namespace label used by the PodSecurity admission
+// controller to select the level enforced in a namespace.
+const enforceLabel = "pod-security.kubernetes.io/enforce"
+
+// Pod Security Standards levels.
+const (
+	levelPrivileged = "privileged"
+	levelBaseline   = "baseline"
+	levelRestricted = "restricted"
+)
+
+func init() {
+	checks.Register(&podSecurityStandardsCheck{defaultLevel: levelBaseline})
+}
+
+type podSecurityStandardsCheck struct {
+	defaultLevel string
+}
+
+// Name returns a unique name for this check.
+func (p *podSecurityStandardsCheck) Name() string {
+	return "pod-security-standards"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (p *podSecurityStandardsCheck) Groups() []string {
+	return []string{"security"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (p *podSecurityStandardsCheck) Description() string {
+	return "Checks if there are pods violating the baseline or restricted Pod Security Standards"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (p *podSecurityStandardsCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Namespaces, kube.Pods}
+}
+
+// Configure sets the level used for namespaces that do not enforce one. The
+// only supported setting is `default-level`.
+func (p *podSecurityStandardsCheck) Configure(settings map[string]string) error {
+	for key, value := range settings {
+		if key != "default-level" {
+			return fmt.Errorf("unknown setting %q", key)
+		}
+		switch value {
+		case levelPrivileged, levelBaseline, levelRestricted:
+			p.defaultLevel = value
+		default:
+			return fmt.Errorf("invalid level %q", value)
+		}
+	}
+	return nil
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (p *podSecurityStandardsCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	enforced := make(map[string]string)
+	if objects.Namespaces != nil {
+		for _, ns := range objects.Namespaces.Items {
+			if level, ok := ns.Labels[enforceLabel]; ok {
+				enforced[ns.Name] = level
+			}
+		}
+	}
+
+	for _, pod := range objects.Pods.Items {
+		pod := pod
+		// Pods violating an enforced level are rejected when they are
+		// recreated. Violations of the default level are only advisory.
+		severity := checks.Error
+		level, ok := enforced[pod.Namespace]
+		if !ok {
+			severity = checks.Warning
+			level = p.defaultLevel
+		}
+		if level != levelBaseline && level != levelRestricted {
+			continue
+		}
+		for _, control := range podSecurityControls {
+			if control.level == levelRestricted && level != levelRestricted {
+				continue
+			}
+			violations := control.check(&pod)
+			if len(violations) == 0 {
+				continue
+			}
+			d := checks.Diagnostic{
+				Severity: severity,
+				Message:  fmt.Sprintf("Pod does not meet the %s Pod Security Standard: %s", control.level, strings.Join(violations, "; ")),
+				Kind:     checks.Pod,
+				Object:   &pod.ObjectMeta,
+				Owners:   pod.ObjectMeta.GetOwnerReferences(),
+				Details:  fmt.Sprintf("Control: %s", control.id),
+			}
+			diagnostics = append(diagnostics, d)
+		}
+	}
+
+	return diagnostics, nil
+}
+
+// podSecurityControl is a single control of the Pod Security Standards. The IDs
+// match those used by the PodSecurity admission controller.
+type podSecurityControl struct {
+	id    string
+	level string
+	check func(*corev1.Pod) []string
+}
+
+var podSecurityControls = []podSecurityControl{
+	{"hostNamespaces", levelBaseline, checkHostNamespaces},
+	{"privileged", levelBaseline, checkPrivilegedContainers},
+	{"capabilities_baseline", levelBaseline, checkCapabilitiesBaseline},
+	{"hostPathVolumes", levelBaseline, checkHostPathVolumes},
+	{"hostPorts", levelBaseline, checkHostPorts},
+	{"appArmorProfile", levelBaseline, checkAppArmorProfile},
+	{"seLinuxOptions", levelBaseline, checkSELinuxOptions},
+	{"procMount", levelBaseline, checkProcMount},
+	{"seccompProfile_baseline", levelBaseline, checkSeccompBaseline},
+	{"sysctls", levelBaseline, checkSysctls},
+	{"windowsHostProcess", levelBaseline, checkWindowsHostProcess},
+	{"restrictedVolumes", levelRestricted, checkRestrictedVolumes},
+	{"allowPrivilegeEscalation", levelRestricted, checkAllowPrivilegeEscalation},
+	{"runAsNonRoot", levelRestricted, checkRunAsNonRoot},
+	{"runAsUser", levelRestricted, checkRunAsUser},
+	{"seccompProfile_restricted", levelRestricted, checkSeccompRestricted},
+	{"capabilities_restricted", levelRestricted, checkCapabilitiesRestricted},
+}
+
+// podContainer holds the fields common to containers, init containers and
+// ephemeral containers.
+type podContainer struct {
+	name            string
+	securityContext *corev1.SecurityContext
+	ports           []corev1.ContainerPort
+}
+
+func podContainers(pod *corev1.Pod) []podContainer {
+	var ret []podContainer
+	for _, c := range pod.Spec.InitContainers {
+		ret = append(ret, podContainer{c.Name, c.SecurityContext, c.Ports})
+	}
+	for _, c := range pod.Spec.Containers {
+		ret = append(ret, podContainer{c.Name, c.SecurityContext, c.Ports})
+	}
+	for _, c := range pod.Spec.EphemeralContainers {
+		ret = append(ret, podContainer{c.Name, c.SecurityContext, c.Ports})
+	}
+	return ret
+}
+
+func checkHostNamespaces(pod *corev1.Pod) []string {
+	var shared []string
+	if pod.Spec.HostNetwork {
+		shared = append(shared, "hostNetwork")
+	}
+	if pod.Spec.HostPID {
+		shared = append(shared, "hostPID")
+	}
+	if pod.Spec.HostIPC {
+		shared = append(shared, "hostIPC")
+	}
+	if len(shared) == 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s must not be set", strings.Join(shared, ", "))}
+}
+
+func checkPrivilegedContainers(pod *corev1.Pod) []string {
+	var violations []string
+	for _, c := range podContainers(pod) {
+		if c.securityContext != nil && c.securityContext.Privileged != nil && *c.securityContext.Privileged {
+			violations = append(violations, fmt.Sprintf("container `%s` must not be privileged", c.name))
+		}
+	}
+	return violations
+}
+
+var baselineCapabilities = map[corev1.Capability]struct{}{
+	"AUDIT_WRITE": {}, "CHOWN": {}, "DAC_OVERRIDE": {}, "FOWNER": {}, "FSETID": {},
+	"KILL": {}, "MKNOD": {}, "NET_BIND_SERVICE": {}, "SETFCAP": {}, "SETGID": {},
+	"SETPCAP": {}, "SETUID": {}, "SYS_CHROOT": {},
+}
+
+func checkCapabilitiesBaseline(pod *corev1.Pod) []string {
+	var violations []string
+	for _, c := range podContainers(pod) {
+		if c.securityContext == nil || c.securityContext.Capabilities == nil {
+			continue
+		}
+		var added []string
+		for _, capability := range c.securityContext.Capabilities.Add {
+			if _, ok := baselineCapabilities[capability]; !ok {
+				added = append(added, string(capability))
+			}
+		}
+		if len(added) > 0 {
+			violations = append(violations, fmt.Sprintf("container `%s` must not add capabilities %s", c.name, strings.Join(added, ", ")))
+		}
+	}
+	return violations
+}
+
+func checkHostPathVolumes(pod *corev1.Pod) []string {
+	var violations []string
+	for _, v := range pod.Spec.Volumes {
+		if v.HostPath != nil {
+			violations = append(violations, fmt.Sprintf("volume `%s` must not use hostPath", v.Name))
+		}
+	}
+	return violations
+}
+
+func checkHostPorts(pod *corev1.Pod) []string {
+	var violations []string
+	for _, c := range podContainers(pod) {
+		for _, port := range c.ports {
+			if port.HostPort != 0 {
+				violations = append(violations, fmt.Sprintf("container `%s` must not use host port %d", c.name, port.HostPort))
+			}
+		}
+	}
+	return violations
+}
+
+func checkAppArmorProfile(pod *corev1.Pod) []string {
+	var violations []string
+	if sc := pod.Spec.SecurityContext; sc != nil && sc.AppArmorProfile != nil && sc.AppArmorProfile.Type == corev1.AppArmorProfileTypeUnconfined {
+		violations = append(violations, "pod must not set an unconfined AppArmor profile")
+	}
+	for _, c := range podContainers(pod) {
+		if sc := c.securityContext; sc != nil && sc.AppArmorProfile != nil && sc.AppArmorProfile.Type == corev1.AppArmorProfileTypeUnconfined {
+			violations = append(violations, fmt.Sprintf("container `%s` must not set an unconfined AppArmor profile", c.name))
+		}
+	}
+	var keys []string
+	for key := range pod.Annotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !strings.HasPrefix(key, corev1.DeprecatedAppArmorBetaContainerAnnotationKeyPrefix) {
+			continue
+		}
+		value := pod.Annotations[key]
+		if value != corev1.DeprecatedAppArmorBetaProfileRuntimeDefault && !strings.HasPrefix(value, corev1.DeprecatedAppArmorBetaProfileNamePrefix) {
+			violations = append(violations, fmt.Sprintf("annotation %s must not be %q", key, value))
+		}
+	}
+	return violations
+}
+
+var allowedSELinuxTypes = map[string]struct{}{
+	"": {}, "container_t": {}, "container_init_t": {}, "container_kvm_t": {}, "container_engine_t": {},
+}
+
+func checkSELinuxOptions(pod *corev1.Pod) []string {
+	var violations []string
+	check := func(subject string, opts *corev1.SELinuxOptions) {
+		if opts == nil {
+			return
+		}
+		if _, ok := allowedSELinuxTypes[opts.Type]; !ok {
+			violations = append(violations, fmt.Sprintf("%s must not set SELinux type %q", subject, opts.Type))
+		}
+		if opts.User != "" || opts.Role != "" {
+			violations = append(violations, fmt.Sprintf("%s must not set a custom SELinux user or role", subject))
+		}
+	}
+	if pod.Spec.SecurityContext != nil {
+		check("pod", pod.Spec.SecurityContext.SELinuxOptions)
+	}
+	for _, c := range podContainers(pod) {
+		if c.securityContext != nil {
+			check(fmt.Sprintf("container `%s`", c.name), c.securityContext.SELinuxOptions)
+		}
+	}
+	return violations
+}
+
+func checkProcMount(pod *corev1.Pod) []string {
+	var violations []string
+	for _, c := range podContainers(pod) {
+		if sc := c.securityContext; sc != nil && sc.ProcMount != nil && *sc.ProcMount != corev1.DefaultProcMount {
+			violations = append(violations, fmt.Sprintf("container `%s` must use the default proc mount", c.name))
+		}
+	}
+	return violations
+}
+
+func checkSeccompBaseline(pod *corev1.Pod) []string {
+	var violations []string
+	if sc := pod.Spec.SecurityContext; sc != nil && sc.SeccompProfile != nil && sc.SeccompProfile.Type == corev1.SeccompProfileTypeUnconfined {
+		violations = append(violations, "pod must not set an unconfined seccomp profile")
+	}
+	for _, c := range podContainers(pod) {
+		if sc := c.securityContext; sc != nil && sc.SeccompProfile != nil && sc.SeccompProfile.Type == corev1.SeccompProfileTypeUnconfined {
+			violations = append(violations, fmt.Sprintf("container `%s` must not set an unconfined seccomp profile", c.name))
+		}
+	}
+	return violations
+}
+
+var safeSysctls = map[string]struct{}{
+	"kernel.shm_rmid_forced":              {},
+	"net.ipv4.ip_local_port_range":        {},
+	"net.ipv4.ip_unprivileged_port_start": {},
+	"net.ipv4.tcp_syncookies":             {},
+	"net.ipv4.ping_group_range":           {},
+	"net.ipv4.ip_local_reserved_ports":    {},
+	"net.ipv4.tcp_keepalive_time":         {},
+	"net.ipv4.tcp_fin_timeout":            {},
+	"net.ipv4.tcp_keepalive_intvl":        {},
+	"net.ipv4.tcp_keepalive_probes":       {},
+}
+
+func checkSysctls(pod *corev1.Pod) []string {
+	if pod.Spec.SecurityContext == nil {
+		return nil
+	}
+	var unsafe []string
+	for _, sysctl := range pod.Spec.SecurityContext.Sysctls {
+		if _, ok := safeSysctls[sysctl.Name]; !ok {
+			unsafe = append(unsafe, sysctl.Name)
+		}
+	}
+	if len(unsafe) == 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("pod must not set sysctls %s", strings.Join(unsafe, ", "))}
+}
+
+func checkWindowsHostProcess(pod *corev1.Pod) []string {
+	var violations []string
+	if sc := pod.Spec.SecurityContext; sc != nil && sc.WindowsOptions != nil && sc.WindowsOptions.HostProcess != nil && *sc.WindowsOptions.HostProcess {
+		violations = append(violations, "pod must not run as a Windows host process")
+	}
+	for _, c := range podContainers(pod) {
+		if sc := c.securityContext; sc != nil && sc.WindowsOptions != nil && sc.WindowsOptions.HostProcess != nil && *sc.WindowsOptions.HostProcess {
+			violations = append(violations, fmt.Sprintf("container `%s` must not run as a Windows host process", c.name))
+		}
+	}
+	return violations
+}
+
+func checkRestrictedVolumes(pod *corev1.Pod) []string {
+	var violations []string
+	for _, v := range pod.Spec.Volumes {
+		s := v.VolumeSource
+		if s.ConfigMap != nil || s.CSI != nil || s.DownwardAPI != nil || s.EmptyDir != nil ||
+			s.Ephemeral != nil || s.PersistentVolumeClaim != nil || s.Projected != nil ||
+			s.Secret != nil || s.Image != nil {
+			continue
+		}
+		violations = append(violations, fmt.Sprintf("volume `%s` must use an allowed volume type", v.Name))
+	}
+	return violations
+}
+
+func isWindowsPod(pod *corev1.Pod) bool {
+	return pod.Spec.OS != nil && pod.Spec.OS.Name == corev1.Windows
+}
+
+func checkAllowPrivilegeEscalation(pod *corev1.Pod) []string {
+	if isWindowsPod(pod) {
+		return nil
+	}
+	var violations []string
+	for _, c := range podContainers(pod) {
+		sc := c.securityContext
+		if sc == nil || sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+			violations = append(violations, fmt.Sprintf("container `%s` must set allowPrivilegeEscalation to false", c.name))
+		}
+	}
+	return violations
+}
+
+func checkRunAsNonRoot(pod *corev1.Pod) []string {
+	var violations []string
+	podNonRoot := false
+	if sc := pod.Spec.SecurityContext; sc != nil && sc.RunAsNonRoot != nil {
+		if !*sc.RunAsNonRoot {
+			violations = append(violations, "pod must not set runAsNonRoot to false")
+		}
+		podNonRoot = *sc.RunAsNonRoot
+	}
+	for _, c := range podContainers(pod) {
+		sc := c.securityContext
+		if sc != nil && sc.RunAsNonRoot != nil {
+			if !*sc.RunAsNonRoot {
+				violations = append(violations, fmt.Sprintf("container `%s` must not set runAsNonRoot to false", c.name))
+			}
+			continue
+		}
+		if !podNonRoot {
+			violations = append(violations, fmt.Sprintf("container `%s` must set runAsNonRoot to true", c.name))
+		}
+	}
+	return violations
+}
+
+func checkRunAsUser(pod *corev1.Pod) []string {
+	var violations []string
+	if sc := pod.Spec.SecurityContext; sc != nil && sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+		violations = append(violations, "pod must not set runAsUser to 0")
+	}
+	for _, c := range podContainers(pod) {
+		if sc := c.securityContext; sc != nil && sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+			violations = append(violations, fmt.Sprintf("container `%s` must not set runAsUser to 0", c.name))
+		}
+	}
+	return violations
+}
+
+func checkSeccompRestricted(pod *corev1.Pod) []string {
+	if isWindowsPod(pod) {
+		return nil
+	}
+	allowed := func(p *corev1.SeccompProfile) bool {
+		return p.Type == corev1.SeccompProfileTypeRuntimeDefault || p.Type == corev1.SeccompProfileTypeLocalhost
+	}
+	var violations []string
+	podSet := false
+	if sc := pod.Spec.SecurityContext; sc != nil && sc.SeccompProfile != nil {
+		if !allowed(sc.SeccompProfile) {
+			violations = append(violations, fmt.Sprintf("pod must not set seccomp profile %q", sc.SeccompProfile.Type))
+		}
+		podSet = true
+	}
+	for _, c := range podContainers(pod) {
+		sc := c.securityContext
+		if sc != nil && sc.SeccompProfile != nil {
+			if !allowed(sc.SeccompProfile) {
+				violations = append(violations, fmt.Sprintf("container `%s` must not set seccomp profile %q", c.name, sc.SeccompProfile.Type))
+			}
+			continue
+		}
+		if !podSet {
+			violations = append(violations, fmt.Sprintf("container `%s` must set a RuntimeDefault or Localhost seccomp profile", c.name))
+		}
+	}
+	return violations
+}
+
+func checkCapabilitiesRestricted(pod *corev1.Pod) []string {
+	if isWindowsPod(pod) {
+		return nil
+	}
+	var violations []string
+	for _, c := range podContainers(pod) {
+		var caps *corev1.Capabilities
+		if c.securityContext != nil {
+			caps = c.securityContext.Capabilities
+		}
+		dropsAll := false
+		if caps != nil {
+			for _, capability := range caps.Drop {
+				if capability == "ALL" {
+					dropsAll = true
+				}
+			}
+		}
+		if !dropsAll {
+			violations = append(violations, fmt.Sprintf("container `%s` must drop ALL capabilities", c.name))
+		}
+		if caps == nil {
+			continue
+		}
+		for _, capability := range caps.Add {
+			if capability != "NET_BIND_SERVICE" {
+				violations = append(violations, fmt.Sprintf("container `%s` must not add capability %s", c.name, capability))
+			}
+		}
+	}
+	return violations
+}
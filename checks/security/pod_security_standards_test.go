@@ -0,0 +1,195 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package security
+
+import (
+	"testing"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodSecurityStandardsCheckMeta(t *testing.T) {
+	podSecurityStandardsCheck := podSecurityStandardsCheck{}
+	assert.Equal(t, "pod-security-standards", podSecurityStandardsCheck.Name())
+	assert.Equal(t, []string{"security"}, podSecurityStandardsCheck.Groups())
+	assert.NotEmpty(t, podSecurityStandardsCheck.Description())
+}
+
+func TestPodSecurityStandardsCheckRegistration(t *testing.T) {
+	podSecurityStandardsCheck := &podSecurityStandardsCheck{defaultLevel: levelBaseline}
+	check, err := checks.Get("pod-security-standards")
+	assert.NoError(t, err)
+	assert.Equal(t, check, podSecurityStandardsCheck)
+}
+
+func TestPodSecurityStandards(t *testing.T) {
+	trueVal := true
+	falseVal := false
+	root := int64(0)
+
+	restrictedContainer := corev1.Container{
+		Name: "bar",
+		SecurityContext: &corev1.SecurityContext{
+			AllowPrivilegeEscalation: &falseVal,
+			Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+		},
+	}
+	restrictedPodSecurityContext := &corev1.PodSecurityContext{
+		RunAsNonRoot:   &trueVal,
+		SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+	}
+
+	tests := []struct {
+		name         string
+		level        string
+		defaultLevel string
+		spec         corev1.PodSpec
+		expected     []checks.Diagnostic
+	}{
+		{
+			name: "compliant pod",
+			spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "bar"}}},
+		},
+		{
+			name: "host namespaces in unlabelled namespace",
+			spec: corev1.PodSpec{
+				HostNetwork: true,
+				HostPID:     true,
+				Containers:  []corev1.Container{{Name: "bar"}},
+			},
+			expected: []checks.Diagnostic{
+				pssDiagnostic(checks.Warning, "Pod does not meet the baseline Pod Security Standard: hostNetwork, hostPID must not be set", "hostNamespaces"),
+			},
+		},
+		{
+			name:  "one diagnostic per control in enforced namespace",
+			level: levelBaseline,
+			spec: corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Name: "bar",
+					SecurityContext: &corev1.SecurityContext{
+						Privileged:   &trueVal,
+						Capabilities: &corev1.Capabilities{Add: []corev1.Capability{"SYS_ADMIN", "CHOWN"}},
+					},
+					Ports: []corev1.ContainerPort{{ContainerPort: 80, HostPort: 80}},
+				}},
+				Volumes: []corev1.Volume{{
+					Name:         "host",
+					VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/"}},
+				}},
+			},
+			expected: []checks.Diagnostic{
+				pssDiagnostic(checks.Error, "Pod does not meet the baseline Pod Security Standard: container `bar` must not be privileged", "privileged"),
+				pssDiagnostic(checks.Error, "Pod does not meet the baseline Pod Security Standard: container `bar` must not add capabilities SYS_ADMIN", "capabilities_baseline"),
+				pssDiagnostic(checks.Error, "Pod does not meet the baseline Pod Security Standard: volume `host` must not use hostPath", "hostPathVolumes"),
+				pssDiagnostic(checks.Error, "Pod does not meet the baseline Pod Security Standard: container `bar` must not use host port 80", "hostPorts"),
+			},
+		},
+		{
+			name:  "privileged namespace",
+			level: levelPrivileged,
+			spec: corev1.PodSpec{
+				HostNetwork: true,
+				Containers:  []corev1.Container{{Name: "bar"}},
+			},
+		},
+		{
+			name:  "baseline pod in restricted namespace",
+			level: levelRestricted,
+			spec: corev1.PodSpec{
+				SecurityContext: &corev1.PodSecurityContext{RunAsUser: &root},
+				Containers:      []corev1.Container{{Name: "bar"}},
+			},
+			expected: []checks.Diagnostic{
+				pssDiagnostic(checks.Error, "Pod does not meet the restricted Pod Security Standard: container `bar` must set allowPrivilegeEscalation to false", "allowPrivilegeEscalation"),
+				pssDiagnostic(checks.Error, "Pod does not meet the restricted Pod Security Standard: container `bar` must set runAsNonRoot to true", "runAsNonRoot"),
+				pssDiagnostic(checks.Error, "Pod does not meet the restricted Pod Security Standard: pod must not set runAsUser to 0", "runAsUser"),
+				pssDiagnostic(checks.Error, "Pod does not meet the restricted Pod Security Standard: container `bar` must set a RuntimeDefault or Localhost seccomp profile", "seccompProfile_restricted"),
+				pssDiagnostic(checks.Error, "Pod does not meet the restricted Pod Security Standard: container `bar` must drop ALL capabilities", "capabilities_restricted"),
+			},
+		},
+		{
+			name:  "restricted pod in restricted namespace",
+			level: levelRestricted,
+			spec: corev1.PodSpec{
+				SecurityContext: restrictedPodSecurityContext,
+				Containers:      []corev1.Container{restrictedContainer},
+				Volumes: []corev1.Volume{{
+					Name:         "config",
+					VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{}},
+				}},
+			},
+		},
+		{
+			name:         "restricted default level",
+			defaultLevel: levelRestricted,
+			spec: corev1.PodSpec{
+				SecurityContext: restrictedPodSecurityContext,
+				Containers:      []corev1.Container{restrictedContainer},
+				Volumes: []corev1.Volume{{
+					Name:         "nfs",
+					VolumeSource: corev1.VolumeSource{NFS: &corev1.NFSVolumeSource{Server: "nfs", Path: "/"}},
+				}},
+			},
+			expected: []checks.Diagnostic{
+				pssDiagnostic(checks.Warning, "Pod does not meet the restricted Pod Security Standard: volume `nfs` must use an allowed volume type", "restrictedVolumes"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			check := &podSecurityStandardsCheck{defaultLevel: levelBaseline}
+			if test.defaultLevel != "" {
+				assert.NoError(t, check.Configure(map[string]string{"default-level": test.defaultLevel}))
+			}
+			objs := initPod()
+			objs.Pods.Items[0].Spec = test.spec
+			objs.Namespaces = &corev1.NamespaceList{
+				Items: []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "k8s"}}},
+			}
+			if test.level != "" {
+				objs.Namespaces.Items[0].Labels = map[string]string{enforceLabel: test.level}
+			}
+
+			d, err := check.Run(objs)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, test.expected, d)
+		})
+	}
+}
+
+func TestPodSecurityStandardsConfigure(t *testing.T) {
+	check := &podSecurityStandardsCheck{}
+	assert.Error(t, check.Configure(map[string]string{"default-level": "strict"}))
+	assert.Error(t, check.Configure(map[string]string{"level": "baseline"}))
+}
+
+func pssDiagnostic(severity checks.Severity, message, control string) checks.Diagnostic {
+	objs := initPod()
+	return checks.Diagnostic{
+		Severity: severity,
+		Message:  message,
+		Kind:     checks.Pod,
+		Object:   &objs.Pods.Items[0].ObjectMeta,
+		Owners:   objs.Pods.Items[0].GetOwnerReferences(),
+		Details:  "Control: " + control,
+	}
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package security
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// bootstrappingLabel marks the default roles and bindings created by the API
+// server.
+const bootstrappingLabel = "kubernetes.io/bootstrapping"
+
+// binding is a RoleBinding or a ClusterRoleBinding.
+type binding struct {
+	kind     checks.Kind
+	meta     *metav1.ObjectMeta
+	roleRef  rbacv1.RoleRef
+	subjects []rbacv1.Subject
+}
+
+// bindings returns the user-managed role bindings and cluster role bindings in
+// the cluster.
+func bindings(objects *kube.Objects) []binding {
+	var ret []binding
+	for i := range objects.ClusterRoleBindings.Items {
+		b := &objects.ClusterRoleBindings.Items[i]
+		if isSystemRBACObject(&b.ObjectMeta) {
+			continue
+		}
+		ret = append(ret, binding{checks.ClusterRoleBinding, &b.ObjectMeta, b.RoleRef, b.Subjects})
+	}
+	for i := range objects.RoleBindings.Items {
+		b := &objects.RoleBindings.Items[i]
+		if isSystemRBACObject(&b.ObjectMeta) {
+			continue
+		}
+		ret = append(ret, binding{checks.RoleBinding, &b.ObjectMeta, b.RoleRef, b.Subjects})
+	}
+	return ret
+}
+
+// rules returns the policy rules of the role a binding refers to.
+func (b binding) rules(objects *kube.Objects) []rbacv1.PolicyRule {
+	switch b.roleRef.Kind {
+	case "ClusterRole":
+		for _, role := range objects.ClusterRoles.Items {
+			if role.Name == b.roleRef.Name {
+				return role.Rules
+			}
+		}
+	case "Role":
+		for _, role := range objects.Roles.Items {
+			if role.Name == b.roleRef.Name && role.Namespace == b.meta.Namespace {
+				return role.Rules
+			}
+		}
+	}
+	return nil
+}
+
+// subjectNamespace returns the namespace of a service account subject, which
+// defaults to the namespace of the binding.
+func (b binding) subjectNamespace(subject rbacv1.Subject) string {
+	if subject.Namespace != "" {
+		return subject.Namespace
+	}
+	return b.meta.Namespace
+}
+
+func (b binding) describeSubject(subject rbacv1.Subject) string {
+	if subject.Kind == rbacv1.ServiceAccountKind {
+		return fmt.Sprintf("%s `%s/%s`", subject.Kind, b.subjectNamespace(subject), subject.Name)
+	}
+	return fmt.Sprintf("%s `%s`", subject.Kind, subject.Name)
+}
+
+// isSystemSubject reports whether a subject is managed by Kubernetes or lives
+// in a system namespace.
+func (b binding) isSystemSubject(subject rbacv1.Subject) bool {
+	if strings.HasPrefix(subject.Name, "system:") {
+		return true
+	}
+	if subject.Kind == rbacv1.ServiceAccountKind {
+		return checks.IsSystemNamespace(b.subjectNamespace(subject))
+	}
+	return false
+}
+
+func isSystemRBACObject(meta *metav1.ObjectMeta) bool {
+	if strings.HasPrefix(meta.Name, "system:") {
+		return true
+	}
+	_, ok := meta.Labels[bootstrappingLabel]
+	return ok
+}
+
+// permissionDiagnostics returns a diagnostic for every non-system subject of a
+// binding whose role has a rule matched by dangerous. dangerous returns a
+// description of the permission the rule grants.
+func permissionDiagnostics(objects *kube.Objects, b binding, severity checks.Severity, dangerous func(rbacv1.PolicyRule) string) []checks.Diagnostic {
+	var diagnostics []checks.Diagnostic
+	var permissions []string
+	for _, rule := range b.rules(objects) {
+		if p := dangerous(rule); p != "" {
+			permissions = append(permissions, p)
+		}
+	}
+	if len(permissions) == 0 {
+		return nil
+	}
+	for _, subject := range b.subjects {
+		if b.isSystemSubject(subject) {
+			continue
+		}
+		d := checks.Diagnostic{
+			Severity: severity,
+			Message:  fmt.Sprintf("%s can %s through %s `%s`", b.describeSubject(subject), strings.Join(permissions, " and "), b.roleRef.Kind, b.roleRef.Name),
+			Kind:     b.kind,
+			Object:   b.meta,
+			Owners:   b.meta.GetOwnerReferences(),
+			Details:  fmt.Sprintf("Rules: %s", describeRules(b.rules(objects), dangerous)),
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics
+}
+
+func describeRules(rules []rbacv1.PolicyRule, match func(rbacv1.PolicyRule) string) string {
+	var ret []string
+	for _, rule := range rules {
+		if match(rule) == "" {
+			continue
+		}
+		ret = append(ret, fmt.Sprintf("{apiGroups: %v, resources: %v, verbs: %v}", rule.APIGroups, rule.Resources, rule.Verbs))
+	}
+	return strings.Join(ret, ", ")
+}
+
+func hasAny(list []string, values ...string) bool {
+	for _, l := range list {
+		for _, v := range values {
+			if l == v {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package security
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func init() {
+	checks.Register(&missingServiceAccountCheck{})
+	checks.Register(&unusedRoleCheck{})
+}
+
+type missingServiceAccountCheck struct{}
+
+// Name returns a unique name for this check.
+func (m *missingServiceAccountCheck) Name() string {
+	return "rbac-missing-service-account"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (m *missingServiceAccountCheck) Groups() []string {
+	return []string{"security"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (m *missingServiceAccountCheck) Description() string {
+	return "Checks if there are role bindings referencing service accounts that do not exist"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (m *missingServiceAccountCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.ClusterRoleBindings, kube.RoleBindings, kube.ServiceAccounts}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (m *missingServiceAccountCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	accounts := make(map[kube.Identifier]struct{})
+	namespaces := make(map[string]struct{})
+	for _, sa := range objects.ServiceAccounts.Items {
+		accounts[kube.Identifier{Name: sa.Name, Namespace: sa.Namespace}] = struct{}{}
+		namespaces[sa.Namespace] = struct{}{}
+	}
+
+	for _, b := range bindings(objects) {
+		for _, subject := range b.subjects {
+			if subject.Kind != rbacv1.ServiceAccountKind {
+				continue
+			}
+			namespace := b.subjectNamespace(subject)
+			// Every namespace has a default service account, so if none were
+			// fetched for the namespace, it was filtered out and there is
+			// nothing to compare against.
+			if _, ok := namespaces[namespace]; !ok {
+				continue
+			}
+			if _, ok := accounts[kube.Identifier{Name: subject.Name, Namespace: namespace}]; ok {
+				continue
+			}
+			d := checks.Diagnostic{
+				Severity: checks.Warning,
+				Message:  fmt.Sprintf("%s bound to %s `%s` does not exist. Anyone who creates it gains the role's permissions.", b.describeSubject(subject), b.roleRef.Kind, b.roleRef.Name),
+				Kind:     b.kind,
+				Object:   b.meta,
+				Owners:   b.meta.GetOwnerReferences(),
+			}
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	return diagnostics, nil
+}
+
+type unusedRoleCheck struct{}
+
+// Name returns a unique name for this check.
+func (u *unusedRoleCheck) Name() string {
+	return "unused-role"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (u *unusedRoleCheck) Groups() []string {
+	return []string{"security"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (u *unusedRoleCheck) Description() string {
+	return "Checks if there are roles and cluster roles that are not bound to any subject"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (u *unusedRoleCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.ClusterRoleBindings, kube.ClusterRoles, kube.RoleBindings, kube.Roles}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (u *unusedRoleCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	usedRoles := make(map[kube.Identifier]struct{})
+	usedClusterRoles := make(map[string]struct{})
+	for _, b := range objects.ClusterRoleBindings.Items {
+		usedClusterRoles[b.RoleRef.Name] = struct{}{}
+	}
+	for _, b := range objects.RoleBindings.Items {
+		if b.RoleRef.Kind == "ClusterRole" {
+			usedClusterRoles[b.RoleRef.Name] = struct{}{}
+		} else {
+			usedRoles[kube.Identifier{Name: b.RoleRef.Name, Namespace: b.Namespace}] = struct{}{}
+		}
+	}
+
+	for _, role := range objects.Roles.Items {
+		role := role
+		if isSystemRBACObject(&role.ObjectMeta) {
+			continue
+		}
+		if _, ok := usedRoles[kube.Identifier{Name: role.Name, Namespace: role.Namespace}]; ok {
+			continue
+		}
+		d := checks.Diagnostic{
+			Severity: checks.Warning,
+			Message:  "Unused role",
+			Kind:     checks.Role,
+			Object:   &role.ObjectMeta,
+			Owners:   role.ObjectMeta.GetOwnerReferences(),
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	// Cluster roles may be bound by role bindings in namespaces that were
+	// filtered out, so they are only reported when all namespaces are checked.
+	if !objects.Filter.AllNamespaces() {
+		return diagnostics, nil
+	}
+	for _, role := range objects.ClusterRoles.Items {
+		role := role
+		if isSystemRBACObject(&role.ObjectMeta) || isAggregated(role) {
+			continue
+		}
+		if _, ok := usedClusterRoles[role.Name]; ok {
+			continue
+		}
+		d := checks.Diagnostic{
+			Severity: checks.Warning,
+			Message:  "Unused cluster role",
+			Kind:     checks.ClusterRole,
+			Object:   &role.ObjectMeta,
+			Owners:   role.ObjectMeta.GetOwnerReferences(),
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics, nil
+}
+
+// isAggregated reports whether a cluster role's rules are aggregated into
+// other cluster roles, in which case it is used without being bound.
+func isAggregated(role rbacv1.ClusterRole) bool {
+	for key := range role.Labels {
+		if strings.HasPrefix(key, "rbac.authorization.k8s.io/aggregate-to-") {
+			return true
+		}
+	}
+	return false
+}
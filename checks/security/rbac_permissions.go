@@ -0,0 +1,262 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package security
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func init() {
+	checks.Register(&clusterAdminBindingCheck{})
+	checks.Register(&rbacWildcardCheck{})
+	checks.Register(&rbacSecretsAccessCheck{})
+	checks.Register(&rbacEscalationCheck{})
+}
+
+type clusterAdminBindingCheck struct{}
+
+// Name returns a unique name for this check.
+func (c *clusterAdminBindingCheck) Name() string {
+	return "cluster-admin-binding"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (c *clusterAdminBindingCheck) Groups() []string {
+	return []string{"security"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (c *clusterAdminBindingCheck) Description() string {
+	return "Checks if there are subjects outside system namespaces bound to the cluster-admin role"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (c *clusterAdminBindingCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.ClusterRoleBindings, kube.RoleBindings}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (c *clusterAdminBindingCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	for _, b := range bindings(objects) {
+		if b.roleRef.Kind != "ClusterRole" || b.roleRef.Name != "cluster-admin" {
+			continue
+		}
+		scope := "the whole cluster"
+		if b.kind == checks.RoleBinding {
+			scope = fmt.Sprintf("namespace `%s`", b.meta.Namespace)
+		}
+		for _, subject := range b.subjects {
+			if b.isSystemSubject(subject) {
+				continue
+			}
+			d := checks.Diagnostic{
+				Severity: checks.Warning,
+				Message:  fmt.Sprintf("%s has full administrative access to %s through ClusterRole `cluster-admin`", b.describeSubject(subject), scope),
+				Kind:     b.kind,
+				Object:   b.meta,
+				Owners:   b.meta.GetOwnerReferences(),
+			}
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	return diagnostics, nil
+}
+
+type rbacWildcardCheck struct{}
+
+// Name returns a unique name for this check.
+func (w *rbacWildcardCheck) Name() string {
+	return "rbac-wildcard"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (w *rbacWildcardCheck) Groups() []string {
+	return []string{"security"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (w *rbacWildcardCheck) Description() string {
+	return "Checks if there are subjects bound to roles that use wildcard verbs or resources"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (w *rbacWildcardCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.ClusterRoleBindings, kube.ClusterRoles, kube.RoleBindings, kube.Roles}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (w *rbacWildcardCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	for _, b := range bindings(objects) {
+		// cluster-admin is covered by the cluster-admin-binding check.
+		if b.roleRef.Kind == "ClusterRole" && b.roleRef.Name == "cluster-admin" {
+			continue
+		}
+		diagnostics = append(diagnostics, permissionDiagnostics(objects, b, checks.Warning, wildcardPermission)...)
+	}
+	return diagnostics, nil
+}
+
+func wildcardPermission(rule rbacv1.PolicyRule) string {
+	verbs := hasAny(rule.Verbs, rbacv1.VerbAll)
+	resources := hasAny(rule.Resources, rbacv1.ResourceAll)
+	switch {
+	case len(rule.Resources) == 0:
+		// Rules for non-resource URLs, such as /metrics, have no resources.
+		if verbs && len(rule.NonResourceURLs) > 0 {
+			return fmt.Sprintf("perform any action on non-resource URLs %s", strings.Join(rule.NonResourceURLs, ", "))
+		}
+		return ""
+	case verbs && resources:
+		return fmt.Sprintf("perform any action on any resource in API groups %s", describeAPIGroups(rule.APIGroups))
+	case verbs:
+		return fmt.Sprintf("perform any action on %s", strings.Join(rule.Resources, ", "))
+	case resources:
+		return fmt.Sprintf("%s any resource in API groups %s", strings.Join(rule.Verbs, ", "), describeAPIGroups(rule.APIGroups))
+	}
+	return ""
+}
+
+func describeAPIGroups(groups []string) string {
+	var ret []string
+	for _, g := range groups {
+		if g == "" {
+			g = "core"
+		}
+		ret = append(ret, fmt.Sprintf("`%s`", g))
+	}
+	return strings.Join(ret, ", ")
+}
+
+type rbacSecretsAccessCheck struct{}
+
+// Name returns a unique name for this check.
+func (s *rbacSecretsAccessCheck) Name() string {
+	return "rbac-secrets-access"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (s *rbacSecretsAccessCheck) Groups() []string {
+	return []string{"security"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (s *rbacSecretsAccessCheck) Description() string {
+	return "Checks if there are subjects that can read secrets in all namespaces"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (s *rbacSecretsAccessCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.ClusterRoleBindings, kube.ClusterRoles, kube.RoleBindings, kube.Roles}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (s *rbacSecretsAccessCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	for _, b := range bindings(objects) {
+		if b.kind != checks.ClusterRoleBinding {
+			continue
+		}
+		if b.roleRef.Kind == "ClusterRole" && b.roleRef.Name == "cluster-admin" {
+			continue
+		}
+		diagnostics = append(diagnostics, permissionDiagnostics(objects, b, checks.Warning, secretsReadPermission)...)
+	}
+	return diagnostics, nil
+}
+
+func secretsReadPermission(rule rbacv1.PolicyRule) string {
+	if !hasAny(rule.APIGroups, "", rbacv1.APIGroupAll) || !hasAny(rule.Resources, "secrets", rbacv1.ResourceAll) {
+		return ""
+	}
+	var verbs []string
+	for _, verb := range rule.Verbs {
+		if verb == "get" || verb == "list" || verb == "watch" || verb == rbacv1.VerbAll {
+			verbs = append(verbs, verb)
+		}
+	}
+	if len(verbs) == 0 {
+		return ""
+	}
+	// Rules restricted to named secrets do not grant access to all secrets.
+	if len(rule.ResourceNames) > 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s secrets in all namespaces", strings.Join(verbs, ", "))
+}
+
+type rbacEscalationCheck struct{}
+
+// Name returns a unique name for this check.
+func (e *rbacEscalationCheck) Name() string {
+	return "rbac-escalation"
+}
+
+// Groups returns a list of group names this check should be part of.
+func (e *rbacEscalationCheck) Groups() []string {
+	return []string{"security"}
+}
+
+// Description returns a detailed human-readable description of what this check
+// does.
+func (e *rbacEscalationCheck) Description() string {
+	return "Checks if there are subjects granted the escalate, bind or impersonate verbs"
+}
+
+// Resources returns the resources whose objects this check reads.
+func (e *rbacEscalationCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.ClusterRoleBindings, kube.ClusterRoles, kube.RoleBindings, kube.Roles}
+}
+
+// Run runs this check on a set of Kubernetes objects. It can return warnings
+// (low-priority problems) and errors (high-priority problems) as well as an
+// error value indicating that the check failed to run.
+func (e *rbacEscalationCheck) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
+	var diagnostics []checks.Diagnostic
+	for _, b := range bindings(objects) {
+		diagnostics = append(diagnostics, permissionDiagnostics(objects, b, checks.Warning, escalationPermission)...)
+	}
+	return diagnostics, nil
+}
+
+func escalationPermission(rule rbacv1.PolicyRule) string {
+	var verbs []string
+	for _, verb := range rule.Verbs {
+		if verb == "escalate" || verb == "bind" || verb == "impersonate" {
+			verbs = append(verbs, verb)
+		}
+	}
+	if len(verbs) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s %s", strings.Join(verbs, ", "), strings.Join(rule.Resources, ", "))
+}
@@ -0,0 +1,374 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package security
+
+import (
+	"testing"
+
+	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/kube"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRBACCheckMeta(t *testing.T) {
+	tests := []struct {
+		check checks.Check
+		name  string
+	}{
+		{&clusterAdminBindingCheck{}, "cluster-admin-binding"},
+		{&rbacWildcardCheck{}, "rbac-wildcard"},
+		{&rbacSecretsAccessCheck{}, "rbac-secrets-access"},
+		{&rbacEscalationCheck{}, "rbac-escalation"},
+		{&missingServiceAccountCheck{}, "rbac-missing-service-account"},
+		{&unusedRoleCheck{}, "unused-role"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.name, test.check.Name())
+			assert.Equal(t, []string{"security"}, test.check.Groups())
+			assert.NotEmpty(t, test.check.Description())
+
+			check, err := checks.Get(test.name)
+			assert.NoError(t, err)
+			assert.Equal(t, test.check, check)
+		})
+	}
+}
+
+func TestClusterAdminBinding(t *testing.T) {
+	tests := []struct {
+		name     string
+		objs     func() *kube.Objects
+		expected []string
+	}{
+		{
+			name:     "no bindings",
+			objs:     initRBAC,
+			expected: nil,
+		},
+		{
+			name: "user service account bound cluster-wide",
+			objs: func() *kube.Objects {
+				objs := initRBAC()
+				withClusterRoleBinding(objs, "admin-binding", "cluster-admin", serviceAccount("app", "ci"))
+				return objs
+			},
+			expected: []string{"ServiceAccount `app/ci` has full administrative access to the whole cluster through ClusterRole `cluster-admin`"},
+		},
+		{
+			name: "user bound in namespace",
+			objs: func() *kube.Objects {
+				objs := initRBAC()
+				withRoleBinding(objs, "app", "admin-binding", "ClusterRole", "cluster-admin", rbacv1.Subject{Kind: rbacv1.UserKind, Name: "jane"})
+				return objs
+			},
+			expected: []string{"User `jane` has full administrative access to namespace `app` through ClusterRole `cluster-admin`"},
+		},
+		{
+			name: "system subjects",
+			objs: func() *kube.Objects {
+				objs := initRBAC()
+				withClusterRoleBinding(objs, "admin-binding", "cluster-admin",
+					serviceAccount("kube-system", "cilium"),
+					rbacv1.Subject{Kind: rbacv1.GroupKind, Name: "system:masters"},
+				)
+				withClusterRoleBinding(objs, "system:controller", "cluster-admin", serviceAccount("app", "ci"))
+				return objs
+			},
+			expected: nil,
+		},
+	}
+
+	check := &clusterAdminBindingCheck{}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d, err := check.Run(test.objs())
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, test.expected, messages(d))
+		})
+	}
+}
+
+func TestRBACPermissions(t *testing.T) {
+	tests := []struct {
+		name     string
+		check    checks.Check
+		rules    []rbacv1.PolicyRule
+		expected []string
+	}{
+		{
+			name:  "wildcard verbs and resources",
+			check: &rbacWildcardCheck{},
+			rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"apps"}, Resources: []string{"*"}, Verbs: []string{"*"}},
+				{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}},
+			},
+			expected: []string{"ServiceAccount `app/ci` can perform any action on any resource in API groups `apps` through ClusterRole `deployer`"},
+		},
+		{
+			name:  "wildcard verbs",
+			check: &rbacWildcardCheck{},
+			rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"pods", "services"}, Verbs: []string{"*"}},
+			},
+			expected: []string{"ServiceAccount `app/ci` can perform any action on pods, services through ClusterRole `deployer`"},
+		},
+		{
+			name:  "wildcard verbs on non-resource URLs",
+			check: &rbacWildcardCheck{},
+			rules: []rbacv1.PolicyRule{
+				{NonResourceURLs: []string{"/metrics", "/healthz"}, Verbs: []string{"*"}},
+			},
+			expected: []string{"ServiceAccount `app/ci` can perform any action on non-resource URLs /metrics, /healthz through ClusterRole `deployer`"},
+		},
+		{
+			name:  "no wildcards",
+			check: &rbacWildcardCheck{},
+			rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list"}},
+			},
+			expected: nil,
+		},
+		{
+			name:  "cluster-wide secrets read",
+			check: &rbacSecretsAccessCheck{},
+			rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"secrets", "configmaps"}, Verbs: []string{"get", "list", "create"}},
+			},
+			expected: []string{"ServiceAccount `app/ci` can get, list secrets in all namespaces through ClusterRole `deployer`"},
+		},
+		{
+			name:  "named secrets",
+			check: &rbacSecretsAccessCheck{},
+			rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"secrets"}, ResourceNames: []string{"registry"}, Verbs: []string{"get"}},
+			},
+			expected: nil,
+		},
+		{
+			name:  "escalation verbs",
+			check: &rbacEscalationCheck{},
+			rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"rbac.authorization.k8s.io"}, Resources: []string{"clusterroles"}, Verbs: []string{"bind", "escalate"}},
+				{APIGroups: []string{""}, Resources: []string{"users"}, Verbs: []string{"impersonate"}},
+			},
+			expected: []string{"ServiceAccount `app/ci` can bind, escalate clusterroles and impersonate users through ClusterRole `deployer`"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := initRBAC()
+			withClusterRole(objs, "deployer", test.rules...)
+			withClusterRoleBinding(objs, "deployer", "deployer", serviceAccount("app", "ci"))
+
+			d, err := test.check.Run(objs)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, test.expected, messages(d))
+		})
+	}
+}
+
+func TestRBACSecretsAccessIgnoresRoleBindings(t *testing.T) {
+	objs := initRBAC()
+	withClusterRole(objs, "reader", rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}})
+	withRoleBinding(objs, "app", "reader", "ClusterRole", "reader", serviceAccount("app", "ci"))
+
+	d, err := (&rbacSecretsAccessCheck{}).Run(objs)
+	assert.NoError(t, err)
+	assert.Empty(t, d)
+}
+
+func TestRBACPermissionDetails(t *testing.T) {
+	objs := initRBAC()
+	withClusterRole(objs, "deployer", rbacv1.PolicyRule{APIGroups: []string{"apps"}, Resources: []string{"*"}, Verbs: []string{"*"}})
+	withClusterRoleBinding(objs, "deployer", "deployer", serviceAccount("app", "ci"))
+
+	d, err := (&rbacWildcardCheck{}).Run(objs)
+	assert.NoError(t, err)
+	assert.Equal(t, []checks.Diagnostic{{
+		Severity: checks.Warning,
+		Message:  "ServiceAccount `app/ci` can perform any action on any resource in API groups `apps` through ClusterRole `deployer`",
+		Kind:     checks.ClusterRoleBinding,
+		Object:   &objs.ClusterRoleBindings.Items[0].ObjectMeta,
+		Details:  "Rules: {apiGroups: [apps], resources: [*], verbs: [*]}",
+	}}, d)
+}
+
+func TestMissingServiceAccount(t *testing.T) {
+	tests := []struct {
+		name     string
+		subject  rbacv1.Subject
+		expected []string
+	}{
+		{
+			name:     "existing service account",
+			subject:  serviceAccount("app", "ci"),
+			expected: nil,
+		},
+		{
+			name:     "missing service account",
+			subject:  serviceAccount("app", "deleted"),
+			expected: []string{"ServiceAccount `app/deleted` bound to Role `reader` does not exist. Anyone who creates it gains the role's permissions."},
+		},
+		{
+			name:     "namespace defaults to binding namespace",
+			subject:  rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "deleted"},
+			expected: []string{"ServiceAccount `app/deleted` bound to Role `reader` does not exist. Anyone who creates it gains the role's permissions."},
+		},
+		{
+			name:     "namespace not fetched",
+			subject:  serviceAccount("other", "deleted"),
+			expected: nil,
+		},
+		{
+			name:     "users are not checked",
+			subject:  rbacv1.Subject{Kind: rbacv1.UserKind, Name: "jane"},
+			expected: nil,
+		},
+	}
+
+	check := &missingServiceAccountCheck{}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := initRBAC()
+			objs.ServiceAccounts.Items = []corev1.ServiceAccount{
+				{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "app"}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "ci", Namespace: "app"}},
+			}
+			withRoleBinding(objs, "app", "reader", "Role", "reader", test.subject)
+
+			d, err := check.Run(objs)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, test.expected, messages(d))
+		})
+	}
+}
+
+func TestUnusedRole(t *testing.T) {
+	objs := initRBAC()
+	objs.Roles.Items = []rbacv1.Role{
+		{ObjectMeta: metav1.ObjectMeta{Name: "used", Namespace: "app"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "unused", Namespace: "app"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "used", Namespace: "other"}},
+	}
+	withClusterRole(objs, "bound-cluster-wide")
+	withClusterRole(objs, "bound-in-namespace")
+	withClusterRole(objs, "unused")
+	withClusterRole(objs, "system:auth-delegator")
+	withClusterRole(objs, "admin")
+	objs.ClusterRoles.Items[len(objs.ClusterRoles.Items)-1].Labels = map[string]string{bootstrappingLabel: "rbac-defaults"}
+	withClusterRole(objs, "crd-view")
+	objs.ClusterRoles.Items[len(objs.ClusterRoles.Items)-1].Labels = map[string]string{"rbac.authorization.k8s.io/aggregate-to-view": "true"}
+
+	withRoleBinding(objs, "app", "a", "Role", "used", serviceAccount("app", "ci"))
+	withRoleBinding(objs, "app", "b", "ClusterRole", "bound-in-namespace", serviceAccount("app", "ci"))
+	withClusterRoleBinding(objs, "c", "bound-cluster-wide", serviceAccount("app", "ci"))
+
+	d, err := (&unusedRoleCheck{}).Run(objs)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []checks.Diagnostic{
+		{
+			Severity: checks.Warning,
+			Message:  "Unused role",
+			Kind:     checks.Role,
+			Object:   &objs.Roles.Items[1].ObjectMeta,
+		},
+		{
+			Severity: checks.Warning,
+			Message:  "Unused role",
+			Kind:     checks.Role,
+			Object:   &objs.Roles.Items[2].ObjectMeta,
+		},
+		{
+			Severity: checks.Warning,
+			Message:  "Unused cluster role",
+			Kind:     checks.ClusterRole,
+			Object:   &objs.ClusterRoles.Items[2].ObjectMeta,
+		},
+	}, d)
+}
+
+func TestUnusedRoleFilteredNamespaces(t *testing.T) {
+	objs := initRBAC()
+	objs.Filter = kube.ObjectFilter{ExcludeNamespace: "app"}
+	objs.Roles.Items = []rbacv1.Role{
+		{ObjectMeta: metav1.ObjectMeta{Name: "unused", Namespace: "other"}},
+	}
+	// Bound by a role binding in the excluded namespace, which was not
+	// fetched.
+	withClusterRole(objs, "bound-in-namespace")
+
+	d, err := (&unusedRoleCheck{}).Run(objs)
+	assert.NoError(t, err)
+	assert.Equal(t, []checks.Diagnostic{
+		{
+			Severity: checks.Warning,
+			Message:  "Unused role",
+			Kind:     checks.Role,
+			Object:   &objs.Roles.Items[0].ObjectMeta,
+		},
+	}, d)
+}
+
+func initRBAC() *kube.Objects {
+	return &kube.Objects{
+		ServiceAccounts:     &corev1.ServiceAccountList{},
+		Roles:               &rbacv1.RoleList{},
+		ClusterRoles:        &rbacv1.ClusterRoleList{},
+		RoleBindings:        &rbacv1.RoleBindingList{},
+		ClusterRoleBindings: &rbacv1.ClusterRoleBindingList{},
+	}
+}
+
+func withClusterRole(objs *kube.Objects, name string, rules ...rbacv1.PolicyRule) {
+	objs.ClusterRoles.Items = append(objs.ClusterRoles.Items, rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Rules:      rules,
+	})
+}
+
+func withClusterRoleBinding(objs *kube.Objects, name, role string, subjects ...rbacv1.Subject) {
+	objs.ClusterRoleBindings.Items = append(objs.ClusterRoleBindings.Items, rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: role},
+		Subjects:   subjects,
+	})
+}
+
+func withRoleBinding(objs *kube.Objects, namespace, name, kind, role string, subjects ...rbacv1.Subject) {
+	objs.RoleBindings.Items = append(objs.RoleBindings.Items, rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: kind, Name: role},
+		Subjects:   subjects,
+	})
+}
+
+func serviceAccount(namespace, name string) rbacv1.Subject {
+	return rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: namespace, Name: name}
+}
+
+func messages(diagnostics []checks.Diagnostic) []string {
+	var ret []string
+	for _, d := range diagnostics {
+		ret = append(ret, d.Message)
+	}
+	return ret
+}
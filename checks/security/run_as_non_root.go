@@ -46,6 +46,11 @@ func (nr *nonRootUserCheck) Description() string {
 	return "Checks if there are pods which run as root user"
 }
 
+// Resources returns the resources whose objects this check reads.
+func (nr *nonRootUserCheck) Resources() []kube.Resource {
+	return []kube.Resource{kube.Pods}
+}
+
 // Run runs this check on a set of Kubernetes objects. It can return warnings
 // (low-priority problems) and errors (high-priority problems) as well as an
 // error value indicating that the check failed to run.
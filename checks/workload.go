@@ -0,0 +1,80 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checks
+
+import (
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Workload identifies the controller that manages a set of pods.
+type Workload struct {
+	Kind   Kind
+	Object *metav1.ObjectMeta
+}
+
+// Key returns a string that uniquely identifies the workload in a cluster.
+func (w Workload) Key() string {
+	return strings.Join([]string{string(w.Kind), w.Object.Namespace, w.Object.Name}, "/")
+}
+
+var workloadKinds = map[string]Kind{
+	"Deployment":            Deployment,
+	"ReplicaSet":            ReplicaSet,
+	"StatefulSet":           StatefulSet,
+	"DaemonSet":             DaemonSet,
+	"Job":                   Job,
+	"CronJob":               CronJob,
+	"ReplicationController": ReplicationController,
+}
+
+// WorkloadForPod returns the workload that a pod belongs to. Pods owned by a
+// ReplicaSet created by a Deployment are attributed to the Deployment. Pods
+// without a known controller are their own workload.
+//
+// The returned object carries the pod's annotations, which are copied from
+// the workload's pod template, so that checks disabled on the workload are
+// honored.
+func WorkloadForPod(pod *corev1.Pod) Workload {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return Workload{Kind: Pod, Object: &pod.ObjectMeta}
+	}
+	kind, ok := workloadKinds[owner.Kind]
+	if !ok {
+		return Workload{Kind: Pod, Object: &pod.ObjectMeta}
+	}
+	name := owner.Name
+	if owner.Kind == "ReplicaSet" {
+		hash := pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey]
+		if hash != "" && strings.HasSuffix(name, "-"+hash) {
+			kind = Deployment
+			name = strings.TrimSuffix(name, "-"+hash)
+		}
+	}
+	return Workload{
+		Kind: kind,
+		Object: &metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   pod.Namespace,
+			Annotations: pod.Annotations,
+		},
+	}
+}
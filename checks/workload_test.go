@@ -0,0 +1,84 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWorkloadForPod(t *testing.T) {
+	controller := true
+	tests := []struct {
+		name         string
+		owners       []metav1.OwnerReference
+		labels       map[string]string
+		expectedKind Kind
+		expectedName string
+	}{
+		{
+			name:         "bare pod",
+			expectedKind: Pod,
+			expectedName: "web-6d4cf56db6-x7k2p",
+		},
+		{
+			name:         "deployment",
+			owners:       []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-6d4cf56db6", Controller: &controller}},
+			labels:       map[string]string{"pod-template-hash": "6d4cf56db6"},
+			expectedKind: Deployment,
+			expectedName: "web",
+		},
+		{
+			name:         "replica set without deployment",
+			owners:       []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web", Controller: &controller}},
+			expectedKind: ReplicaSet,
+			expectedName: "web",
+		},
+		{
+			name:         "stateful set",
+			owners:       []metav1.OwnerReference{{Kind: "StatefulSet", Name: "db", Controller: &controller}},
+			expectedKind: StatefulSet,
+			expectedName: "db",
+		},
+		{
+			name:         "unknown controller",
+			owners:       []metav1.OwnerReference{{Kind: "Rollout", Name: "web", Controller: &controller}},
+			expectedKind: Pod,
+			expectedName: "web-6d4cf56db6-x7k2p",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "web-6d4cf56db6-x7k2p",
+					Namespace:       "k8s",
+					Labels:          test.labels,
+					OwnerReferences: test.owners,
+				},
+			}
+			workload := WorkloadForPod(pod)
+			assert.Equal(t, test.expectedKind, workload.Kind)
+			assert.Equal(t, test.expectedName, workload.Object.Name)
+			assert.Equal(t, "k8s", workload.Object.Namespace)
+		})
+	}
+}
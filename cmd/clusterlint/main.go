@@ -19,6 +19,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"plugin"
@@ -26,9 +27,12 @@ import (
 	"time"
 
 	"github.com/digitalocean/clusterlint/checks"
+	"github.com/digitalocean/clusterlint/checks/external"
+	"github.com/digitalocean/clusterlint/checks/rules"
 	"github.com/digitalocean/clusterlint/kube"
 	"github.com/fatih/color"
 	"github.com/urfave/cli"
+	"sigs.k8s.io/yaml"
 
 	// Side-effect import to get all the checks registered.
 	_ "github.com/digitalocean/clusterlint/checks/all"
@@ -36,6 +40,13 @@ import (
 
 const delimiter = ":"
 
+// checksFailedExitCode is the exit code used when the run completed but some
+// checks failed to run, panicked or timed out.
+const checksFailedExitCode = 2
+
+// wasmPagesPerMiB is the number of WebAssembly memory pages in a MiB.
+const wasmPagesPerMiB = 16
+
 var Version string
 
 func main() {
@@ -59,7 +70,20 @@ func main() {
 		},
 		cli.StringSliceFlag{
 			Name:  "plugins",
-			Usage: "paths of Go plugins to load containing local checks",
+			Usage: "paths of Go plugins to load containing local checks (deprecated: use plugin executables)",
+		},
+		cli.StringSliceFlag{
+			Name:  "plugin-dir",
+			Usage: "directories searched for clusterlint-check-* plugin executables",
+		},
+		cli.UintFlag{
+			Name:  "wasm-memory-limit",
+			Usage: "maximum memory of clusterlint-check-*.wasm plugins, in MiB",
+			Value: external.DefaultWASMMemoryLimit / wasmPagesPerMiB,
+		},
+		cli.StringFlag{
+			Name:  "rules-dir",
+			Usage: "directory of YAML files declaring local checks",
 		},
 		cli.BoolFlag{
 			Name:  "in-cluster",
@@ -80,7 +104,7 @@ func main() {
 					Usage: "list all checks not in groups `GROUP1, GROUP2`",
 				},
 			},
-			Before: loadPlugins,
+			Before: loadChecks,
 			Action: listChecks,
 		},
 		{
@@ -123,10 +147,78 @@ func main() {
 					Name:  "no-color",
 					Usage: "Disable color output",
 				},
+				cli.StringSliceFlag{
+					Name:  "s, setting",
+					Usage: "configure a check, e.g. `CHECK.KEY=VALUE`",
+				},
+				cli.DurationFlag{
+					Name:  "check-timeout",
+					Usage: "maximum time each check may run, 0 for no limit. default: 1m",
+					Value: time.Minute,
+				},
+				cli.Int64Flag{
+					Name:  "page-size",
+					Usage: "number of objects to list per request, 0 to list all objects at once. default: 500",
+					Value: 500,
+				},
+				cli.BoolFlag{
+					Name:  "from-cache",
+					Usage: "list objects from the API server's watch cache, which is faster but may be slightly out of date",
+				},
+				cli.BoolFlag{
+					Name:  "lenient",
+					Usage: "skip checks that need objects clusterlint is not permitted to list, instead of failing",
+				},
 			},
-			Before: loadPlugins,
+			Before: loadChecks,
 			Action: runChecks,
 		},
+		{
+			Name:  "permissions",
+			Usage: "print the RBAC ClusterRole needed to run checks",
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{
+					Name:  "g, groups",
+					Usage: "include checks in groups `GROUP1, GROUP2`",
+				},
+				cli.StringSliceFlag{
+					Name:  "G, ignore-groups",
+					Usage: "include checks not in groups `GROUP1, GROUP2`",
+				},
+				cli.StringSliceFlag{
+					Name:  "c, checks",
+					Usage: "include a specific check",
+				},
+				cli.StringSliceFlag{
+					Name:  "C, ignore-checks",
+					Usage: "exclude a specific check",
+				},
+			},
+			Before: loadChecks,
+			Action: printPermissions,
+		},
+		{
+			Name:  "rules",
+			Usage: "work with checks declared in YAML",
+			Subcommands: []cli.Command{
+				{
+					Name:      "test",
+					Usage:     "run the rules in --rules-dir against manifests",
+					ArgsUsage: "MANIFEST...",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "output, o",
+							Usage: "output format [text|json]. Default: text",
+						},
+						cli.BoolFlag{
+							Name:  "no-color",
+							Usage: "Disable color output",
+						},
+					},
+					Action: testRules,
+				},
+			},
+		},
 	}
 	err := app.Run(os.Args)
 	if err != nil {
@@ -135,6 +227,17 @@ func main() {
 	}
 }
 
+// loadChecks registers the checks from the plugins and rules passed.
+func loadChecks(c *cli.Context) error {
+	if err := loadPlugins(c); err != nil {
+		return err
+	}
+	if err := loadExternalPlugins(c); err != nil {
+		return err
+	}
+	return loadRules(c)
+}
+
 func loadPlugins(c *cli.Context) error {
 	plugins := c.GlobalStringSlice("plugins")
 	for _, p := range plugins {
@@ -147,6 +250,85 @@ func loadPlugins(c *cli.Context) error {
 	return nil
 }
 
+// loadExternalPlugins registers the checks served by the plugin executables
+// and WebAssembly modules found in --plugin-dir. Plugins are only run from
+// directories passed explicitly, and a plugin that fails to load or conflicts
+// with another check is skipped with a warning, so that it does not keep the
+// other checks from running.
+func loadExternalPlugins(c *cli.Context) error {
+	dirs := c.GlobalStringSlice("plugin-dir")
+	limit := external.WithWASMMemoryLimit(uint32(c.GlobalUint("wasm-memory-limit") * wasmPagesPerMiB))
+	for _, path := range external.Discover(dirs) {
+		loaded, err := external.Load(path, limit)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[warning] %s (skipped)\n", err)
+			continue
+		}
+		for _, check := range loaded {
+			if err := checks.Register(check); err != nil {
+				fmt.Fprintf(os.Stderr, "[warning] plugin %s: %s (skipped)\n", path, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func loadRules(c *cli.Context) error {
+	dir := c.GlobalString("rules-dir")
+	if dir == "" {
+		return nil
+	}
+	loaded, err := rules.Load(dir)
+	if err != nil {
+		return err
+	}
+	for _, rule := range loaded {
+		if err := checks.Register(rule); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// testRules runs the rules from --rules-dir against the manifests passed,
+// instead of the objects in a cluster.
+func testRules(c *cli.Context) error {
+	dir := c.GlobalString("rules-dir")
+	if dir == "" {
+		return errors.New("no --rules-dir specified")
+	}
+	loaded, err := rules.Load(dir)
+	if err != nil {
+		return err
+	}
+
+	var manifests []map[string]interface{}
+	for _, path := range c.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		m, err := rules.ReadManifests(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		manifests = append(manifests, m...)
+	}
+
+	result := &checks.CheckResult{}
+	for _, rule := range loaded {
+		diagnostics, err := rule.Test(manifests)
+		if err != nil {
+			return fmt.Errorf("rule %s: %w", rule.Name(), err)
+		}
+		result.Diagnostics = append(result.Diagnostics, diagnostics...)
+	}
+	return write(result, c)
+}
+
 // listChecks lists the names and desc of all checks in the group if found
 // lists all checks in the registry if group is not specified
 func listChecks(c *cli.Context) error {
@@ -166,6 +348,36 @@ func listChecks(c *cli.Context) error {
 	return nil
 }
 
+// printPermissions prints a ClusterRole granting the permissions needed by
+// the checks selected with the flags passed.
+func printPermissions(c *cli.Context) error {
+	filter, err := checks.NewCheckFilter(c.StringSlice("g"), c.StringSlice("G"), c.StringSlice("c"), c.StringSlice("C"))
+	if err != nil {
+		return err
+	}
+	selected, err := filter.FilterChecks()
+	if err != nil {
+		return err
+	}
+
+	var resources []kube.Resource
+	for _, check := range selected {
+		resources = append(resources, checks.Resources(check)...)
+	}
+	role := map[string]interface{}{
+		"apiVersion": "rbac.authorization.k8s.io/v1",
+		"kind":       "ClusterRole",
+		"metadata":   map[string]string{"name": "clusterlint-role"},
+		"rules":      kube.PolicyRules(resources),
+	}
+	out, err := yaml.Marshal(role)
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(out))
+	return nil
+}
+
 // runChecks runs all the checks based on the flags passed.
 func runChecks(c *cli.Context) error {
 	var kubeconfigFilePaths []string
@@ -199,6 +411,11 @@ func runChecks(c *cli.Context) error {
 		return err
 	}
 
+	err = checks.Configure(c.StringSlice("s"))
+	if err != nil {
+		return err
+	}
+
 	diagnosticFilter := checks.DiagnosticFilter{Severity: checks.Severity(c.String("level"))}
 
 	objectFilter, err := kube.NewObjectFilter(c.String("n"), c.String("N"))
@@ -206,12 +423,30 @@ func runChecks(c *cli.Context) error {
 		return err
 	}
 
-	output, err := checks.Run(context.Background(), client, filter, diagnosticFilter, objectFilter)
+	fetchOpts := []kube.FetchOption{kube.WithPageSize(c.Int64("page-size"))}
+	if c.Bool("from-cache") {
+		fetchOpts = append(fetchOpts, kube.FromWatchCache())
+	}
+	if c.Bool("lenient") {
+		fetchOpts = append(fetchOpts, kube.Lenient())
+	}
+	runOpts := []checks.RunOption{
+		checks.WithCheckTimeout(c.Duration("check-timeout")),
+		checks.WithFetchOptions(fetchOpts...),
+	}
+
+	output, err := checks.Run(context.Background(), client, filter, diagnosticFilter, objectFilter, runOpts...)
 	if err != nil {
 		return err
 	}
 	err = write(output, c)
-	return err
+	if err != nil {
+		return err
+	}
+	if failed := output.Failed(); len(failed) > 0 {
+		return cli.NewExitError(fmt.Sprintf("%d checks failed to run", len(failed)), checksFailedExitCode)
+	}
+	return nil
 }
 
 func write(checkResult *checks.CheckResult, c *cli.Context) error {
@@ -242,6 +477,17 @@ func write(checkResult *checks.CheckResult, c *cli.Context) error {
 				fmt.Println(d)
 			}
 		}
+		for _, f := range checkResult.Checks {
+			switch {
+			case f.Status.Failed():
+				e.Fprintf(os.Stderr, "[%s] %s: %s\n", f.Status, f.Check, f.Error)
+				if f.Stack != "" {
+					fmt.Fprintln(os.Stderr, f.Stack)
+				}
+			case f.Status == checks.StatusSkipped:
+				w.Fprintf(os.Stderr, "[%s] %s: %s\n", f.Status, f.Check, f.Error)
+			}
+		}
 	}
 
 	return nil
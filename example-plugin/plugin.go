@@ -39,6 +39,11 @@ func (nc *check) Description() string {
 	return "A sample plugin."
 }
 
+// Resources returns the resources whose objects this check reads.
+func (nc *check) Resources() []kube.Resource {
+	return []kube.Resource{kube.Pods}
+}
+
 // Run runs this check on a set of Kubernetes objects.
 func (nc *check) Run(objects *kube.Objects) ([]checks.Diagnostic, error) {
 	d := make([]checks.Diagnostic, len(objects.Pods.Items))
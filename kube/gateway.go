@@ -0,0 +1,89 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// HTTPRouteResource identifies Gateway API HTTPRoutes. The Gateway API is
+// installed with CRDs, so HTTPRoutes are fetched with the dynamic client and
+// only the fields used by checks are decoded.
+var HTTPRouteResource = schema.GroupVersionResource{
+	Group:    "gateway.networking.k8s.io",
+	Version:  "v1",
+	Resource: "httproutes",
+}
+
+// HTTPRouteList is a list of Gateway API HTTPRoutes.
+type HTTPRouteList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HTTPRoute `json:"items"`
+}
+
+// HTTPRoute is the subset of a Gateway API HTTPRoute that checks use.
+type HTTPRoute struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              HTTPRouteSpec `json:"spec,omitempty"`
+}
+
+// HTTPRouteSpec describes the hostnames and rules of an HTTPRoute.
+type HTTPRouteSpec struct {
+	Hostnames []string        `json:"hostnames,omitempty"`
+	Rules     []HTTPRouteRule `json:"rules,omitempty"`
+}
+
+// HTTPRouteRule describes where an HTTPRoute forwards matching requests to.
+type HTTPRouteRule struct {
+	BackendRefs []HTTPBackendRef `json:"backendRefs,omitempty"`
+}
+
+// HTTPBackendRef refers to the object requests are forwarded to. An empty
+// group and kind refer to a Service.
+type HTTPBackendRef struct {
+	Group     *string `json:"group,omitempty"`
+	Kind      *string `json:"kind,omitempty"`
+	Name      string  `json:"name"`
+	Namespace *string `json:"namespace,omitempty"`
+	Port      *int32  `json:"port,omitempty"`
+}
+
+// IsService reports whether a backend reference refers to a Service.
+func (r HTTPBackendRef) IsService() bool {
+	return (r.Group == nil || *r.Group == "") && (r.Kind == nil || *r.Kind == "Service")
+}
+
+// fetchHTTPRoutes lists HTTPRoutes and decodes them. Clusters without the
+// Gateway API CRDs return a NotFound error.
+func fetchHTTPRoutes(ctx context.Context, client dynamic.Interface, opts metav1.ListOptions, fetchOpts fetchOptions) (*HTTPRouteList, error) {
+	list, err := listPages(ctx, fetchOpts, opts, client.Resource(HTTPRouteResource).Namespace(metav1.NamespaceAll).List)
+	if err != nil {
+		return nil, err
+	}
+	routes := &HTTPRouteList{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(list.UnstructuredContent(), routes); err != nil {
+		return nil, err
+	}
+	return routes, nil
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+// defaultPageSize is the number of objects listed per request by default.
+const defaultPageSize = 500
+
+// partialObjectMetadataList is the Accept header that makes the API server
+// return only the metadata of listed objects.
+const partialObjectMetadataList = "application/json;as=PartialObjectMetadataList;g=meta.k8s.io;v=v1"
+
+// listFunc lists a page of objects.
+type listFunc[L runtime.Object] func(context.Context, metav1.ListOptions) (L, error)
+
+// listObjects lists all objects of a resource. Resources that are only read
+// for their metadata are listed as PartialObjectMetadata, and the returned
+// list holds objects with only their metadata set.
+func listObjects[L runtime.Object](ctx context.Context, f *fetcher, resource Resource, opts metav1.ListOptions, list listFunc[L]) (L, error) {
+	if !f.metadataOnly(resource) {
+		return listPages(ctx, f.options, opts, list)
+	}
+	metadata, err := listPages(ctx, f.options, opts, func(ctx context.Context, opts metav1.ListOptions) (*metav1.PartialObjectMetadataList, error) {
+		return listMetadata(ctx, f.metadataClient, resource, opts)
+	})
+	if err != nil {
+		var empty L
+		return empty, err
+	}
+	return fromMetadata[L](metadata)
+}
+
+// listPages lists objects in pages of the configured size and returns them
+// as one list. If the continue token of a page expires before the next page
+// is listed, all objects are listed again in a single request.
+func listPages[L runtime.Object](ctx context.Context, options fetchOptions, opts metav1.ListOptions, list listFunc[L]) (L, error) {
+	opts.Limit = options.pageSize
+	if options.fromCache {
+		opts.ResourceVersion = "0"
+	}
+
+	var result L
+	var items []runtime.Object
+	for {
+		page, err := list(ctx, opts)
+		if kerrors.IsResourceExpired(err) && opts.Continue != "" {
+			opts.Limit, opts.Continue = 0, ""
+			items = nil
+			continue
+		}
+		if err != nil {
+			return result, err
+		}
+		accessor, err := meta.ListAccessor(page)
+		if err != nil {
+			return result, err
+		}
+		if opts.Continue == "" && accessor.GetContinue() == "" {
+			// The objects fit in a single page.
+			return page, nil
+		}
+
+		pageItems, err := meta.ExtractList(page)
+		if err != nil {
+			return result, err
+		}
+		items = append(items, pageItems...)
+		if opts.Continue == "" {
+			result = page
+		}
+		if accessor.GetContinue() == "" {
+			break
+		}
+		// Continued lists are served from the resource version of the first
+		// page, which must not be set again.
+		opts.Continue, opts.ResourceVersion = accessor.GetContinue(), ""
+	}
+
+	if err := meta.SetList(result, items); err != nil {
+		return result, err
+	}
+	accessor, err := meta.ListAccessor(result)
+	if err != nil {
+		return result, err
+	}
+	accessor.SetContinue("")
+	return result, nil
+}
+
+// listMetadata lists the metadata of the objects of a core resource.
+func listMetadata(ctx context.Context, client rest.Interface, resource Resource, opts metav1.ListOptions) (*metav1.PartialObjectMetadataList, error) {
+	body, err := client.Get().
+		Resource(resource.Resource).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		SetHeader("Accept", partialObjectMetadataList).
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list := &metav1.PartialObjectMetadataList{}
+	if err := json.Unmarshal(body, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// fromMetadata returns a typed list whose items only have their metadata set.
+func fromMetadata[L runtime.Object](metadata *metav1.PartialObjectMetadataList) (L, error) {
+	var list L
+	list = reflect.New(reflect.TypeOf(list).Elem()).Interface().(L)
+	itemsPtr, err := meta.GetItemsPtr(list)
+	if err != nil {
+		return list, err
+	}
+	items := reflect.ValueOf(itemsPtr).Elem()
+	items.Set(reflect.MakeSlice(items.Type(), len(metadata.Items), len(metadata.Items)))
+	for i := range metadata.Items {
+		items.Index(i).FieldByName("ObjectMeta").Set(reflect.ValueOf(metadata.Items[i].ObjectMeta))
+	}
+	accessor, err := meta.ListAccessor(list)
+	if err != nil {
+		return list, err
+	}
+	accessor.SetResourceVersion(metadata.ResourceVersion)
+	return list, nil
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// pagedPods serves three pods in pages of the requested size, recording the
+// options of each request.
+type pagedPods struct {
+	requests []metav1.ListOptions
+	expire   bool
+}
+
+func (p *pagedPods) list(_ context.Context, opts metav1.ListOptions) (*corev1.PodList, error) {
+	p.requests = append(p.requests, opts)
+	if p.expire && opts.Continue != "" {
+		p.expire = false
+		return nil, kerrors.NewResourceExpired("continue token expired")
+	}
+	names := []string{"pod-1", "pod-2", "pod-3"}
+	start := 0
+	if opts.Continue != "" {
+		start = int(opts.Continue[0] - '0')
+	}
+	end := len(names)
+	if opts.Limit > 0 && start+int(opts.Limit) < end {
+		end = start + int(opts.Limit)
+	}
+	list := &corev1.PodList{ListMeta: metav1.ListMeta{ResourceVersion: "42"}}
+	if end < len(names) {
+		list.Continue = string(rune('0' + end))
+	}
+	for _, name := range names[start:end] {
+		list.Items = append(list.Items, corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+	return list, nil
+}
+
+func podNames(list *corev1.PodList) []string {
+	var names []string
+	for _, pod := range list.Items {
+		names = append(names, pod.Name)
+	}
+	return names
+}
+
+func TestListPages(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  fetchOptions
+		expire   bool
+		expected []metav1.ListOptions
+	}{
+		{
+			name:    "single page",
+			options: fetchOptions{pageSize: defaultPageSize},
+			expected: []metav1.ListOptions{
+				{Limit: defaultPageSize},
+			},
+		},
+		{
+			name:    "unpaginated",
+			options: fetchOptions{},
+			expected: []metav1.ListOptions{
+				{},
+			},
+		},
+		{
+			name:    "multiple pages from cache",
+			options: fetchOptions{pageSize: 2, fromCache: true},
+			expected: []metav1.ListOptions{
+				{Limit: 2, ResourceVersion: "0"},
+				{Limit: 2, Continue: "2"},
+			},
+		},
+		{
+			name:    "expired continue token",
+			options: fetchOptions{pageSize: 1},
+			expire:  true,
+			expected: []metav1.ListOptions{
+				{Limit: 1},
+				{Limit: 1, Continue: "1"},
+				{},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pods := &pagedPods{expire: test.expire}
+			list, err := listPages(context.Background(), test.options, metav1.ListOptions{}, pods.list)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"pod-1", "pod-2", "pod-3"}, podNames(list))
+			assert.Empty(t, list.Continue)
+			assert.Equal(t, test.expected, pods.requests)
+		})
+	}
+}
+
+func TestListObjectsMetadataOnly(t *testing.T) {
+	var accept string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Get("Accept")
+		assert.Equal(t, "/api/v1/configmaps", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(metav1.PartialObjectMetadataList{
+			TypeMeta: metav1.TypeMeta{Kind: "PartialObjectMetadataList", APIVersion: "meta.k8s.io/v1"},
+			Items: []metav1.PartialObjectMetadata{
+				{ObjectMeta: metav1.ObjectMeta{Name: "config-1", Namespace: "default"}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	cs, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	assert.NoError(t, err)
+	f := &fetcher{metadataClient: cs.CoreV1().RESTClient()}
+	WithMetadataOnly(ConfigMaps)(&f.options)
+
+	list, err := listObjects(context.Background(), f, ConfigMaps, metav1.ListOptions{}, cs.CoreV1().ConfigMaps(corev1.NamespaceAll).List)
+	assert.NoError(t, err)
+	assert.Equal(t, partialObjectMetadataList, accept)
+	assert.Equal(t, []corev1.ConfigMap{{ObjectMeta: metav1.ObjectMeta{Name: "config-1", Namespace: "default"}}}, list.Items)
+}
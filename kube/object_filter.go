@@ -50,3 +50,22 @@ func (f ObjectFilter) NamespaceOptions(opts metav1.ListOptions) metav1.ListOptio
 	}
 	return opts
 }
+
+// AllNamespaces reports whether the filter passes the objects of every
+// namespace. Checks that relate cluster scoped objects to namespaced ones can
+// only tell that a cluster scoped object is unused if it does.
+func (f ObjectFilter) AllNamespaces() bool {
+	return f.IncludeNamespace == "" && f.ExcludeNamespace == ""
+}
+
+// Includes reports whether the objects of a namespace pass the filter.
+// Cluster scoped objects, which have no namespace, always do.
+func (f ObjectFilter) Includes(namespace string) bool {
+	if namespace == "" {
+		return true
+	}
+	if len(f.IncludeNamespace) > 0 {
+		return namespace == f.IncludeNamespace
+	}
+	return namespace != f.ExcludeNamespace
+}
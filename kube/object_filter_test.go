@@ -48,3 +48,25 @@ func TestNamespaceOptions(t *testing.T) {
 		filter.NamespaceOptions(metav1.ListOptions{}),
 	)
 }
+
+func TestIncludes(t *testing.T) {
+	include, err := NewObjectFilter("namespace-1", "")
+	assert.NoError(t, err)
+	assert.True(t, include.Includes("namespace-1"))
+	assert.False(t, include.Includes("namespace-2"))
+	assert.True(t, include.Includes(""))
+
+	exclude, err := NewObjectFilter("", "namespace-2")
+	assert.NoError(t, err)
+	assert.True(t, exclude.Includes("namespace-1"))
+	assert.False(t, exclude.Includes("namespace-2"))
+	assert.True(t, exclude.Includes(""))
+
+	assert.True(t, ObjectFilter{}.Includes("namespace-1"))
+}
+
+func TestAllNamespaces(t *testing.T) {
+	assert.True(t, ObjectFilter{}.AllNamespaces())
+	assert.False(t, ObjectFilter{IncludeNamespace: "namespace-1"}.AllNamespaces())
+	assert.False(t, ObjectFilter{ExcludeNamespace: "namespace-1"}.AllNamespaces())
+}
@@ -20,19 +20,29 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sort"
+	"sync"
 
 	csitypes "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
 	csitypesbeta "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1beta1"
 	csi "github.com/kubernetes-csi/external-snapshotter/client/v4/clientset/versioned"
 	"golang.org/x/sync/errgroup"
 	arv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
 	st "k8s.io/api/storage/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -41,14 +51,17 @@ import (
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 )
 
-//Identifier is used to identify a specific namspace scoped object.
+// Identifier is used to identify a specific namspace scoped object.
 type Identifier struct {
 	Name      string
 	Namespace string
 }
 
-// Objects encapsulates all the objects from a Kubernetes cluster.
+// Objects encapsulates all the objects from a Kubernetes cluster. Secrets only
+// have their metadata and type set, their values are removed when they are
+// fetched.
 type Objects struct {
+	ServerVersion                   *version.Info
 	Nodes                           *corev1.NodeList
 	PersistentVolumes               *corev1.PersistentVolumeList
 	SystemNamespace                 *corev1.Namespace
@@ -67,17 +80,54 @@ type Objects struct {
 	VolumeSnapshotsBetaContent      *csitypesbeta.VolumeSnapshotContentList
 	StorageClasses                  *st.StorageClassList
 	DefaultStorageClass             *st.StorageClass
+	VolumeAttachments               *st.VolumeAttachmentList
+	CSINodes                        *st.CSINodeList
 	MutatingWebhookConfigurations   *arv1.MutatingWebhookConfigurationList
 	ValidatingWebhookConfigurations *arv1.ValidatingWebhookConfigurationList
 	Namespaces                      *corev1.NamespaceList
 	CronJobs                        *batchv1.CronJobList
+	Jobs                            *batchv1.JobList
+	Deployments                     *appsv1.DeploymentList
+	StatefulSets                    *appsv1.StatefulSetList
+	DaemonSets                      *appsv1.DaemonSetList
+	PriorityClasses                 *schedulingv1.PriorityClassList
+	HorizontalPodAutoscalers        *autoscalingv2.HorizontalPodAutoscalerList
+	Roles                           *rbacv1.RoleList
+	ClusterRoles                    *rbacv1.ClusterRoleList
+	RoleBindings                    *rbacv1.RoleBindingList
+	ClusterRoleBindings             *rbacv1.ClusterRoleBindingList
+	NetworkPolicies                 *networkingv1.NetworkPolicyList
+	EndpointSlices                  *discoveryv1.EndpointSliceList
+	Ingresses                       *networkingv1.IngressList
+	IngressClasses                  *networkingv1.IngressClassList
+	HTTPRoutes                      *HTTPRouteList
+	// Unstructured holds the objects of resources requested with
+	// WithUnstructured, such as custom resources.
+	Unstructured *UnstructuredObjects
+	// Unavailable lists the resources that could not be fetched because
+	// clusterlint is not permitted to list them. Their lists are empty.
+	Unavailable []Resource
+	// Filter is the filter namespaced objects were fetched with. Objects in
+	// namespaces it excludes are missing from the lists.
+	Filter ObjectFilter
+}
+
+// Available reports whether the objects of a resource were fetched.
+func (o *Objects) Available(resource Resource) bool {
+	for _, r := range o.Unavailable {
+		if r == resource {
+			return false
+		}
+	}
+	return true
 }
 
 // Client encapsulates a client for a Kubernetes cluster.
 type Client struct {
-	KubeClient kubernetes.Interface
-	CSIClient  csi.Interface
-	httpClient *http.Client
+	KubeClient    kubernetes.Interface
+	CSIClient     csi.Interface
+	DynamicClient dynamic.Interface
+	httpClient    *http.Client
 }
 
 func (c *Client) Close() {
@@ -88,11 +138,26 @@ func (c *Client) Close() {
 
 // FetchObjects returns the objects from a Kubernetes cluster.
 // ctx is currently unused during API calls. More info: https://github.com/kubernetes/community/pull/1166
-func (c *Client) FetchObjects(ctx context.Context, filter ObjectFilter) (*Objects, error) {
+func (c *Client) FetchObjects(ctx context.Context, filter ObjectFilter, fetchOpts ...FetchOption) (*Objects, error) {
+	f := &fetcher{options: fetchOptions{pageSize: defaultPageSize}}
+	for _, opt := range fetchOpts {
+		opt(&f.options)
+	}
+	// Fake clientsets have no REST client, so metadata can't be listed.
+	if rc, ok := c.KubeClient.CoreV1().RESTClient().(*rest.RESTClient); ok && rc != nil {
+		f.metadataClient = rc
+	}
+
 	client := c.KubeClient.CoreV1()
 	admissionControllerClient := c.KubeClient.AdmissionregistrationV1()
 	batchClient := c.KubeClient.BatchV1()
+	appsClient := c.KubeClient.AppsV1()
+	schedulingClient := c.KubeClient.SchedulingV1()
+	autoscalingClient := c.KubeClient.AutoscalingV2()
 	storageClient := c.KubeClient.StorageV1()
+	rbacClient := c.KubeClient.RbacV1()
+	networkingClient := c.KubeClient.NetworkingV1()
+	discoveryClient := c.KubeClient.DiscoveryV1()
 	csiClient := c.CSIClient.SnapshotV1()
 	csiBetaClient := c.CSIClient.SnapshotV1beta1()
 	opts := metav1.ListOptions{}
@@ -100,127 +165,255 @@ func (c *Client) FetchObjects(ctx context.Context, filter ObjectFilter) (*Object
 
 	g, gCtx := errgroup.WithContext(ctx)
 	g.Go(func() (err error) {
-		objects.Nodes, err = client.Nodes().List(gCtx, opts)
+		objects.ServerVersion, err = c.KubeClient.Discovery().ServerVersion()
+		err = annotateFetchError("ServerVersion", err)
 		return
 	})
-	g.Go(func() (err error) {
-		objects.StorageClasses, err = storageClient.StorageClasses().List(gCtx, opts)
+	f.fetch(g, Nodes, "Nodes", func() (err error) {
+		objects.Nodes, err = listObjects(gCtx, f, Nodes, opts, client.Nodes().List)
+		return
+	})
+	f.fetch(g, StorageClasses, "StorageClasses", func() (err error) {
+		objects.StorageClasses, err = listObjects(gCtx, f, StorageClasses, opts, storageClient.StorageClasses().List)
 		if err != nil {
 			return err
 		}
-		for _, s := range objects.StorageClasses.Items {
-			if v, _ := s.Annotations["storageclass.kubernetes.io/is-default-class"]; v == "true" {
-				defaultStorageClass := s
-				objects.DefaultStorageClass = &defaultStorageClass
+		// The default-storage-class check reports clusters with more than
+		// one default, so pick the one Kubernetes uses.
+		if defaults := DefaultStorageClasses(objects.StorageClasses); len(defaults) > 0 {
+			objects.DefaultStorageClass = &defaults[0]
+		}
+		return
+	})
+	f.fetch(g, VolumeAttachments, "VolumeAttachments", func() (err error) {
+		objects.VolumeAttachments, err = listObjects(gCtx, f, VolumeAttachments, opts, storageClient.VolumeAttachments().List)
+		return
+	})
+	f.fetch(g, CSINodes, "CSINodes", func() (err error) {
+		objects.CSINodes, err = listObjects(gCtx, f, CSINodes, opts, storageClient.CSINodes().List)
+		return
+	})
+	f.fetch(g, PersistentVolumes, "PersistentVolumes", func() (err error) {
+		objects.PersistentVolumes, err = listObjects(gCtx, f, PersistentVolumes, opts, client.PersistentVolumes().List)
+		return
+	})
+	f.fetch(g, Pods, "Pods", func() (err error) {
+		objects.Pods, err = listObjects(gCtx, f, Pods, filter.NamespaceOptions(opts), client.Pods(corev1.NamespaceAll).List)
+		return
+	})
+	f.fetch(g, PodTemplates, "PodTemplates", func() (err error) {
+		objects.PodTemplates, err = listObjects(gCtx, f, PodTemplates, filter.NamespaceOptions(opts), client.PodTemplates(corev1.NamespaceAll).List)
+		return
+	})
+	f.fetch(g, PersistentVolumeClaims, "PersistentVolumeClaims", func() (err error) {
+		objects.PersistentVolumeClaims, err = listObjects(gCtx, f, PersistentVolumeClaims, filter.NamespaceOptions(opts), client.PersistentVolumeClaims(corev1.NamespaceAll).List)
+		return
+	})
+	f.fetch(g, ConfigMaps, "ConfigMaps", func() (err error) {
+		objects.ConfigMaps, err = listObjects(gCtx, f, ConfigMaps, filter.NamespaceOptions(opts), client.ConfigMaps(corev1.NamespaceAll).List)
+		return
+	})
+	f.fetch(g, Secrets, "Secrets", func() (err error) {
+		list := client.Secrets(corev1.NamespaceAll).List
+		if f.metadataOnly(Secrets) {
+			list = func(ctx context.Context, opts metav1.ListOptions) (*corev1.SecretList, error) {
+				return listSecretMetadata(ctx, f.metadataClient, opts)
 			}
 		}
+		// Secret values are never needed by checks and must not end up in
+		// the output, so they are removed as soon as they are listed. Their
+		// metadata still carries the last applied configuration.
+		objects.Secrets, err = listPages(gCtx, f.options, filter.NamespaceOptions(opts), withoutSecretData(list))
 		return
 	})
-	g.Go(func() (err error) {
-		objects.PersistentVolumes, err = client.PersistentVolumes().List(gCtx, opts)
-		err = annotateFetchError("PersistentVolumes", err)
+	f.fetch(g, Services, "Services", func() (err error) {
+		objects.Services, err = listObjects(gCtx, f, Services, filter.NamespaceOptions(opts), client.Services(corev1.NamespaceAll).List)
 		return
 	})
-	g.Go(func() (err error) {
-		objects.Pods, err = client.Pods(corev1.NamespaceAll).List(gCtx, filter.NamespaceOptions(opts))
-		err = annotateFetchError("Pods", err)
+	f.fetch(g, ServiceAccounts, "ServiceAccounts", func() (err error) {
+		objects.ServiceAccounts, err = listObjects(gCtx, f, ServiceAccounts, filter.NamespaceOptions(opts), client.ServiceAccounts(corev1.NamespaceAll).List)
 		return
 	})
-	g.Go(func() (err error) {
-		objects.PodTemplates, err = client.PodTemplates(corev1.NamespaceAll).List(gCtx, filter.NamespaceOptions(opts))
-		err = annotateFetchError("PodTemplates", err)
+	f.fetch(g, ResourceQuotas, "ResourceQuotas", func() (err error) {
+		objects.ResourceQuotas, err = listObjects(gCtx, f, ResourceQuotas, filter.NamespaceOptions(opts), client.ResourceQuotas(corev1.NamespaceAll).List)
 		return
 	})
-	g.Go(func() (err error) {
-		objects.PersistentVolumeClaims, err = client.PersistentVolumeClaims(corev1.NamespaceAll).List(gCtx, filter.NamespaceOptions(opts))
-		err = annotateFetchError("PersistentVolumeClaims", err)
+	f.fetch(g, LimitRanges, "LimitRanges", func() (err error) {
+		objects.LimitRanges, err = listObjects(gCtx, f, LimitRanges, filter.NamespaceOptions(opts), client.LimitRanges(corev1.NamespaceAll).List)
 		return
 	})
-	g.Go(func() (err error) {
-		objects.ConfigMaps, err = client.ConfigMaps(corev1.NamespaceAll).List(gCtx, filter.NamespaceOptions(opts))
-		err = annotateFetchError("ConfigMaps", err)
+	if f.options.wants(Namespaces) {
+		g.Go(func() (err error) {
+			objects.SystemNamespace, err = client.Namespaces().Get(gCtx, metav1.NamespaceSystem, metav1.GetOptions{})
+			if f.forbidden(Namespaces, err) {
+				return nil
+			}
+			if err != nil {
+				err = fmt.Errorf("failed to fetch namespace %q: %s", metav1.NamespaceSystem, err)
+			}
+			return
+		})
+	}
+	f.fetch(g, MutatingWebhookConfigurations, "MutatingWebhookConfigurations (v1)", func() (err error) {
+		objects.MutatingWebhookConfigurations, err = listObjects(gCtx, f, MutatingWebhookConfigurations, opts, admissionControllerClient.MutatingWebhookConfigurations().List)
 		return
 	})
-	g.Go(func() (err error) {
-		objects.Secrets, err = client.Secrets(corev1.NamespaceAll).List(gCtx, filter.NamespaceOptions(opts))
-		err = annotateFetchError("Secrets", err)
+	f.fetch(g, ValidatingWebhookConfigurations, "ValidatingWebhookConfigurations (v1)", func() (err error) {
+		objects.ValidatingWebhookConfigurations, err = listObjects(gCtx, f, ValidatingWebhookConfigurations, opts, admissionControllerClient.ValidatingWebhookConfigurations().List)
 		return
 	})
-	g.Go(func() (err error) {
-		objects.Services, err = client.Services(corev1.NamespaceAll).List(gCtx, filter.NamespaceOptions(opts))
-		err = annotateFetchError("Services", err)
+	f.fetch(g, Namespaces, "Namespaces", func() (err error) {
+		objects.Namespaces, err = listObjects(gCtx, f, Namespaces, opts, client.Namespaces().List)
 		return
 	})
-	g.Go(func() (err error) {
-		objects.ServiceAccounts, err = client.ServiceAccounts(corev1.NamespaceAll).List(gCtx, filter.NamespaceOptions(opts))
-		err = annotateFetchError("ServiceAccounts", err)
+	f.fetch(g, CronJobs, "CronJobs", func() (err error) {
+		objects.CronJobs, err = listObjects(gCtx, f, CronJobs, filter.NamespaceOptions(opts), batchClient.CronJobs(corev1.NamespaceAll).List)
 		return
 	})
-	g.Go(func() (err error) {
-		objects.ResourceQuotas, err = client.ResourceQuotas(corev1.NamespaceAll).List(gCtx, filter.NamespaceOptions(opts))
-		err = annotateFetchError("ResourceQuotas", err)
+	f.fetch(g, Jobs, "Jobs", func() (err error) {
+		objects.Jobs, err = listObjects(gCtx, f, Jobs, filter.NamespaceOptions(opts), batchClient.Jobs(corev1.NamespaceAll).List)
 		return
 	})
-	g.Go(func() (err error) {
-		objects.LimitRanges, err = client.LimitRanges(corev1.NamespaceAll).List(gCtx, filter.NamespaceOptions(opts))
-		err = annotateFetchError("LimitRanges", err)
+	f.fetch(g, Deployments, "Deployments", func() (err error) {
+		objects.Deployments, err = listObjects(gCtx, f, Deployments, filter.NamespaceOptions(opts), appsClient.Deployments(corev1.NamespaceAll).List)
 		return
 	})
-	g.Go(func() (err error) {
-		objects.SystemNamespace, err = client.Namespaces().Get(gCtx, metav1.NamespaceSystem, metav1.GetOptions{})
-		if err != nil {
-			err = fmt.Errorf("failed to fetch namespace %q: %s", metav1.NamespaceSystem, err)
-		}
+	f.fetch(g, StatefulSets, "StatefulSets", func() (err error) {
+		objects.StatefulSets, err = listObjects(gCtx, f, StatefulSets, filter.NamespaceOptions(opts), appsClient.StatefulSets(corev1.NamespaceAll).List)
 		return
 	})
-	g.Go(func() (err error) {
-		objects.MutatingWebhookConfigurations, err = admissionControllerClient.MutatingWebhookConfigurations().List(gCtx, opts)
-		err = annotateFetchError("MutatingWebhookConfigurations (v1)", err)
+	f.fetch(g, DaemonSets, "DaemonSets", func() (err error) {
+		objects.DaemonSets, err = listObjects(gCtx, f, DaemonSets, filter.NamespaceOptions(opts), appsClient.DaemonSets(corev1.NamespaceAll).List)
 		return
 	})
-	g.Go(func() (err error) {
-		objects.ValidatingWebhookConfigurations, err = admissionControllerClient.ValidatingWebhookConfigurations().List(gCtx, opts)
-		err = annotateFetchError("ValidatingWebhookConfigurations (v1)", err)
+	f.fetch(g, PriorityClasses, "PriorityClasses", func() (err error) {
+		objects.PriorityClasses, err = listObjects(gCtx, f, PriorityClasses, opts, schedulingClient.PriorityClasses().List)
 		return
 	})
-	g.Go(func() (err error) {
-		objects.Namespaces, err = client.Namespaces().List(gCtx, opts)
-		err = annotateFetchError("Namespaces", err)
+	f.fetch(g, HorizontalPodAutoscalers, "HorizontalPodAutoscalers", func() (err error) {
+		objects.HorizontalPodAutoscalers, err = listObjects(gCtx, f, HorizontalPodAutoscalers, filter.NamespaceOptions(opts), autoscalingClient.HorizontalPodAutoscalers(corev1.NamespaceAll).List)
 		return
 	})
-	g.Go(func() (err error) {
-		objects.CronJobs, err = batchClient.CronJobs(corev1.NamespaceAll).List(gCtx, filter.NamespaceOptions(opts))
-		err = annotateFetchError("CronJobs", err)
+	f.fetch(g, Roles, "Roles", func() (err error) {
+		objects.Roles, err = listObjects(gCtx, f, Roles, filter.NamespaceOptions(opts), rbacClient.Roles(corev1.NamespaceAll).List)
 		return
 	})
-	g.Go(func() (err error) {
-		objects.VolumeSnapshotsV1, err = csiClient.VolumeSnapshots(corev1.NamespaceAll).List(ctx, filter.NamespaceOptions(opts))
-		err = annotateFetchError("VolumeSnapshotsV1", err)
+	f.fetch(g, ClusterRoles, "ClusterRoles", func() (err error) {
+		objects.ClusterRoles, err = listObjects(gCtx, f, ClusterRoles, opts, rbacClient.ClusterRoles().List)
 		return
 	})
-	g.Go(func() (err error) {
-		objects.VolumeSnapshotsV1Content, err = csiClient.VolumeSnapshotContents().List(ctx, filter.NamespaceOptions(opts))
-		err = annotateFetchError("VolumeSnapshotsV1Contents", err)
+	f.fetch(g, RoleBindings, "RoleBindings", func() (err error) {
+		objects.RoleBindings, err = listObjects(gCtx, f, RoleBindings, filter.NamespaceOptions(opts), rbacClient.RoleBindings(corev1.NamespaceAll).List)
 		return
 	})
-	g.Go(func() (err error) {
-		objects.VolumeSnapshotsBeta, err = csiBetaClient.VolumeSnapshots(corev1.NamespaceAll).List(ctx, filter.NamespaceOptions(opts))
-		err = annotateFetchError("VolumeSnapshotsBeta", err)
+	f.fetch(g, ClusterRoleBindings, "ClusterRoleBindings", func() (err error) {
+		objects.ClusterRoleBindings, err = listObjects(gCtx, f, ClusterRoleBindings, opts, rbacClient.ClusterRoleBindings().List)
 		return
 	})
-	g.Go(func() (err error) {
-		objects.VolumeSnapshotsBetaContent, err = csiBetaClient.VolumeSnapshotContents().List(ctx, filter.NamespaceOptions(opts))
-		err = annotateFetchError("VolumeSnapshotsBetaContents", err)
+	f.fetch(g, NetworkPolicies, "NetworkPolicies", func() (err error) {
+		objects.NetworkPolicies, err = listObjects(gCtx, f, NetworkPolicies, filter.NamespaceOptions(opts), networkingClient.NetworkPolicies(corev1.NamespaceAll).List)
 		return
 	})
+	f.fetch(g, EndpointSlices, "EndpointSlices", func() (err error) {
+		objects.EndpointSlices, err = listObjects(gCtx, f, EndpointSlices, filter.NamespaceOptions(opts), discoveryClient.EndpointSlices(corev1.NamespaceAll).List)
+		return
+	})
+	f.fetch(g, Ingresses, "Ingresses", func() (err error) {
+		objects.Ingresses, err = listObjects(gCtx, f, Ingresses, filter.NamespaceOptions(opts), networkingClient.Ingresses(corev1.NamespaceAll).List)
+		return
+	})
+	f.fetch(g, IngressClasses, "IngressClasses", func() (err error) {
+		objects.IngressClasses, err = listObjects(gCtx, f, IngressClasses, opts, networkingClient.IngressClasses().List)
+		return
+	})
+	if c.DynamicClient != nil {
+		f.fetch(g, HTTPRoutes, "HTTPRoutes", func() (err error) {
+			objects.HTTPRoutes, err = fetchHTTPRoutes(gCtx, c.DynamicClient, filter.NamespaceOptions(opts), f.options)
+			return
+		})
+	}
+	f.fetch(g, VolumeSnapshots, "VolumeSnapshotsV1", func() (err error) {
+		objects.VolumeSnapshotsV1, err = listObjects(gCtx, f, VolumeSnapshots, filter.NamespaceOptions(opts), csiClient.VolumeSnapshots(corev1.NamespaceAll).List)
+		return
+	})
+	f.fetch(g, VolumeSnapshotContents, "VolumeSnapshotsV1Contents", func() (err error) {
+		objects.VolumeSnapshotsV1Content, err = listObjects(gCtx, f, VolumeSnapshotContents, filter.NamespaceOptions(opts), csiClient.VolumeSnapshotContents().List)
+		return
+	})
+	f.fetch(g, VolumeSnapshots, "VolumeSnapshotsBeta", func() (err error) {
+		objects.VolumeSnapshotsBeta, err = listObjects(gCtx, f, VolumeSnapshots, filter.NamespaceOptions(opts), csiBetaClient.VolumeSnapshots(corev1.NamespaceAll).List)
+		return
+	})
+	f.fetch(g, VolumeSnapshotContents, "VolumeSnapshotsBetaContents", func() (err error) {
+		objects.VolumeSnapshotsBetaContent, err = listObjects(gCtx, f, VolumeSnapshotContents, filter.NamespaceOptions(opts), csiBetaClient.VolumeSnapshotContents().List)
+		return
+	})
+	objects.Unstructured = NewUnstructuredObjects()
+	if c.DynamicClient != nil {
+		f.fetchUnstructured(gCtx, g, c.KubeClient.Discovery(), c.DynamicClient, filter, opts, objects.Unstructured)
+	}
 	err := g.Wait()
 	if err != nil {
 		return nil, err
 	}
 
+	objects.Filter = filter
+	objects.Unavailable = f.unavailable
+	sort.Slice(objects.Unavailable, func(i, j int) bool {
+		return objects.Unavailable[i].String() < objects.Unavailable[j].String()
+	})
 	return objectsWithoutNils(objects), nil
 }
 
+// fetcher keeps track of the resources that could not be fetched.
+type fetcher struct {
+	options        fetchOptions
+	metadataClient rest.Interface
+	mu             sync.Mutex
+	unavailable    []Resource
+}
+
+// metadataOnly reports whether only the metadata of a resource's objects
+// should be listed. This is supported for resources of the core API group.
+func (f *fetcher) metadataOnly(resource Resource) bool {
+	_, ok := f.options.metadataOnly[resource]
+	return ok && resource.Group == "" && f.metadataClient != nil
+}
+
+// fetch lists the objects of a resource in the group, unless the resource
+// is not wanted.
+func (f *fetcher) fetch(g *errgroup.Group, resource Resource, kind string, list func() error) {
+	if !f.options.wants(resource) {
+		return
+	}
+	g.Go(func() error {
+		err := list()
+		if f.forbidden(resource, err) {
+			return nil
+		}
+		return annotateFetchError(kind, err)
+	})
+}
+
+// forbidden reports whether err means that clusterlint is not permitted to
+// fetch a resource and the error should be ignored. The resource is then
+// recorded as unavailable. Permission errors are only ignored when fetching
+// leniently.
+func (f *fetcher) forbidden(resource Resource, err error) bool {
+	if !f.options.lenient || !kerrors.IsForbidden(err) {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, r := range f.unavailable {
+		if r == resource {
+			return true
+		}
+	}
+	f.unavailable = append(f.unavailable, resource)
+	return true
+}
+
 func annotateFetchError(kind string, err error) error {
 	if err == nil {
 		return nil
@@ -235,6 +428,9 @@ func annotateFetchError(kind string, err error) error {
 }
 
 func objectsWithoutNils(objects *Objects) *Objects {
+	if objects.ServerVersion == nil {
+		objects.ServerVersion = &version.Info{}
+	}
 	if objects.Nodes == nil {
 		objects.Nodes = &v1.NodeList{}
 	}
@@ -271,6 +467,12 @@ func objectsWithoutNils(objects *Objects) *Objects {
 	if objects.StorageClasses == nil {
 		objects.StorageClasses = &st.StorageClassList{}
 	}
+	if objects.VolumeAttachments == nil {
+		objects.VolumeAttachments = &st.VolumeAttachmentList{}
+	}
+	if objects.CSINodes == nil {
+		objects.CSINodes = &st.CSINodeList{}
+	}
 	if objects.MutatingWebhookConfigurations == nil {
 		objects.MutatingWebhookConfigurations = &arv1.MutatingWebhookConfigurationList{}
 	}
@@ -283,6 +485,51 @@ func objectsWithoutNils(objects *Objects) *Objects {
 	if objects.CronJobs == nil {
 		objects.CronJobs = &batchv1.CronJobList{}
 	}
+	if objects.Jobs == nil {
+		objects.Jobs = &batchv1.JobList{}
+	}
+	if objects.Deployments == nil {
+		objects.Deployments = &appsv1.DeploymentList{}
+	}
+	if objects.StatefulSets == nil {
+		objects.StatefulSets = &appsv1.StatefulSetList{}
+	}
+	if objects.DaemonSets == nil {
+		objects.DaemonSets = &appsv1.DaemonSetList{}
+	}
+	if objects.PriorityClasses == nil {
+		objects.PriorityClasses = &schedulingv1.PriorityClassList{}
+	}
+	if objects.HorizontalPodAutoscalers == nil {
+		objects.HorizontalPodAutoscalers = &autoscalingv2.HorizontalPodAutoscalerList{}
+	}
+	if objects.Roles == nil {
+		objects.Roles = &rbacv1.RoleList{}
+	}
+	if objects.ClusterRoles == nil {
+		objects.ClusterRoles = &rbacv1.ClusterRoleList{}
+	}
+	if objects.RoleBindings == nil {
+		objects.RoleBindings = &rbacv1.RoleBindingList{}
+	}
+	if objects.ClusterRoleBindings == nil {
+		objects.ClusterRoleBindings = &rbacv1.ClusterRoleBindingList{}
+	}
+	if objects.NetworkPolicies == nil {
+		objects.NetworkPolicies = &networkingv1.NetworkPolicyList{}
+	}
+	if objects.EndpointSlices == nil {
+		objects.EndpointSlices = &discoveryv1.EndpointSliceList{}
+	}
+	if objects.Ingresses == nil {
+		objects.Ingresses = &networkingv1.IngressList{}
+	}
+	if objects.IngressClasses == nil {
+		objects.IngressClasses = &networkingv1.IngressClassList{}
+	}
+	if objects.HTTPRoutes == nil {
+		objects.HTTPRoutes = &HTTPRouteList{}
+	}
 	if objects.VolumeSnapshotsV1 == nil {
 		objects.VolumeSnapshotsV1 = &csitypes.VolumeSnapshotList{}
 	}
@@ -292,6 +539,9 @@ func objectsWithoutNils(objects *Objects) *Objects {
 	if objects.VolumeSnapshotsBeta == nil {
 		objects.VolumeSnapshotsBeta = &csitypesbeta.VolumeSnapshotList{}
 	}
+	if objects.Unstructured == nil {
+		objects.Unstructured = NewUnstructuredObjects()
+	}
 	if objects.VolumeSnapshotsBetaContent == nil {
 		objects.VolumeSnapshotsBetaContent = &csitypesbeta.VolumeSnapshotContentList{}
 	}
@@ -372,9 +622,14 @@ func NewClient(opts ...Option) (*Client, error) {
 	if err != nil {
 		return nil, err
 	}
+	dynamicClient, err := dynamic.NewForConfigAndClient(config, httpClient)
+	if err != nil {
+		return nil, err
+	}
 	return &Client{
-		KubeClient: client,
-		CSIClient:  csiClient,
-		httpClient: httpClient,
+		KubeClient:    client,
+		CSIClient:     csiClient,
+		DynamicClient: dynamicClient,
+		httpClient:    httpClient,
 	}, nil
 }
@@ -28,7 +28,10 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes/fake"
 	ktesting "k8s.io/client-go/testing"
 )
@@ -80,6 +83,7 @@ func TestFetchObjects(t *testing.T) {
 		actual, err := api.FetchObjects(context.Background(), ObjectFilter{})
 		assert.NoError(t, err)
 
+		assert.NotNil(t, actual.ServerVersion)
 		assert.NotNil(t, actual.Nodes)
 		assert.NotNil(t, actual.PersistentVolumes)
 		assert.NotNil(t, actual.Pods)
@@ -91,10 +95,27 @@ func TestFetchObjects(t *testing.T) {
 		assert.NotNil(t, actual.ServiceAccounts)
 		assert.NotNil(t, actual.ResourceQuotas)
 		assert.NotNil(t, actual.LimitRanges)
+		assert.NotNil(t, actual.VolumeAttachments)
+		assert.NotNil(t, actual.CSINodes)
 		assert.NotNil(t, actual.ValidatingWebhookConfigurations)
 		assert.NotNil(t, actual.MutatingWebhookConfigurations)
 		assert.NotNil(t, actual.SystemNamespace)
 		assert.NotNil(t, actual.CronJobs)
+		assert.NotNil(t, actual.Jobs)
+		assert.NotNil(t, actual.Deployments)
+		assert.NotNil(t, actual.StatefulSets)
+		assert.NotNil(t, actual.DaemonSets)
+		assert.NotNil(t, actual.PriorityClasses)
+		assert.NotNil(t, actual.HorizontalPodAutoscalers)
+		assert.NotNil(t, actual.Roles)
+		assert.NotNil(t, actual.ClusterRoles)
+		assert.NotNil(t, actual.RoleBindings)
+		assert.NotNil(t, actual.ClusterRoleBindings)
+		assert.NotNil(t, actual.NetworkPolicies)
+		assert.NotNil(t, actual.EndpointSlices)
+		assert.NotNil(t, actual.Ingresses)
+		assert.NotNil(t, actual.IngressClasses)
+		assert.NotNil(t, actual.HTTPRoutes)
 		assert.NotNil(t, actual.VolumeSnapshotsV1)
 		assert.NotNil(t, actual.VolumeSnapshotsBeta)
 		assert.NotNil(t, actual.VolumeSnapshotsV1Content)
@@ -103,6 +124,110 @@ func TestFetchObjects(t *testing.T) {
 
 }
 
+func TestFetchObjectsForbidden(t *testing.T) {
+	forbidden := func(action ktesting.Action) (bool, runtime.Object, error) {
+		resource := action.GetResource()
+		return true, nil, kerrors.NewForbidden(resource.GroupResource(), "", errors.New("access denied"))
+	}
+	newClient := func() *Client {
+		cs := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: metav1.NamespaceSystem}})
+		cs.PrependReactor("list", "secrets", forbidden)
+		csifake := csi.NewSimpleClientset()
+		csifake.PrependReactor("list", "volumesnapshots", forbidden)
+		return &Client{KubeClient: cs, CSIClient: csifake}
+	}
+
+	_, err := newClient().FetchObjects(context.Background(), ObjectFilter{})
+	assert.Error(t, err)
+
+	actual, err := newClient().FetchObjects(context.Background(), ObjectFilter{}, Lenient())
+	assert.NoError(t, err)
+	assert.Equal(t, []Resource{Secrets, VolumeSnapshots}, actual.Unavailable)
+	assert.False(t, actual.Available(Secrets))
+	assert.True(t, actual.Available(Pods))
+	assert.NotNil(t, actual.Secrets)
+	assert.NotNil(t, actual.VolumeSnapshotsV1)
+}
+
+func TestFetchObjectsWithResources(t *testing.T) {
+	cs := fake.NewSimpleClientset()
+	csifake := csi.NewSimpleClientset()
+	api := &Client{KubeClient: cs, CSIClient: csifake}
+
+	filter := ObjectFilter{ExcludeNamespace: "kube-system"}
+	actual, err := api.FetchObjects(context.Background(), filter, WithResources(Pods, StorageClasses))
+	assert.NoError(t, err)
+	assert.Equal(t, filter, actual.Filter)
+	assert.NotNil(t, actual.Secrets)
+	assert.Nil(t, actual.SystemNamespace)
+
+	var listed []string
+	for _, action := range cs.Actions() {
+		if action.GetVerb() == "list" {
+			listed = append(listed, action.GetResource().Resource)
+		}
+	}
+	assert.ElementsMatch(t, []string{"pods", "storageclasses"}, listed)
+	assert.Empty(t, csifake.Actions())
+}
+
+func TestFetchHTTPRoutes(t *testing.T) {
+	listKinds := map[schema.GroupVersionResource]string{HTTPRouteResource: "HTTPRouteList"}
+	systemNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: metav1.NamespaceSystem}}
+	route := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "gateway.networking.k8s.io/v1",
+		"kind":       "HTTPRoute",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "k8s"},
+		"spec": map[string]interface{}{
+			"hostnames": []interface{}{"example.com"},
+			"rules": []interface{}{
+				map[string]interface{}{
+					"backendRefs": []interface{}{
+						map[string]interface{}{"name": "web", "port": int64(80)},
+					},
+				},
+			},
+		},
+	}}
+
+	t.Run("routes are decoded", func(t *testing.T) {
+		api := &Client{
+			KubeClient:    fake.NewSimpleClientset(systemNamespace),
+			CSIClient:     csi.NewSimpleClientset(),
+			DynamicClient: dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, route),
+		}
+
+		actual, err := api.FetchObjects(context.Background(), ObjectFilter{})
+		assert.NoError(t, err)
+		port := int32(80)
+		assert.Equal(t, []HTTPRoute{{
+			TypeMeta:   metav1.TypeMeta{Kind: "HTTPRoute", APIVersion: "gateway.networking.k8s.io/v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "k8s"},
+			Spec: HTTPRouteSpec{
+				Hostnames: []string{"example.com"},
+				Rules:     []HTTPRouteRule{{BackendRefs: []HTTPBackendRef{{Name: "web", Port: &port}}}},
+			},
+		}}, actual.HTTPRoutes.Items)
+		assert.True(t, actual.HTTPRoutes.Items[0].Spec.Rules[0].BackendRefs[0].IsService())
+	})
+
+	t.Run("CRDs not installed", func(t *testing.T) {
+		dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds)
+		dynamicClient.PrependReactor("list", "httproutes", func(action ktesting.Action) (bool, runtime.Object, error) {
+			return true, nil, kerrors.NewNotFound(action.GetResource().GroupResource(), "")
+		})
+		api := &Client{
+			KubeClient:    fake.NewSimpleClientset(systemNamespace),
+			CSIClient:     csi.NewSimpleClientset(),
+			DynamicClient: dynamicClient,
+		}
+
+		actual, err := api.FetchObjects(context.Background(), ObjectFilter{})
+		assert.NoError(t, err)
+		assert.Empty(t, actual.HTTPRoutes.Items)
+	})
+}
+
 func TestNewClientErrors(t *testing.T) {
 	t.Run("both yaml and filepath specified", func(t *testing.T) {
 		_, err := NewClient(WithConfigFile("some-path"), WithYaml([]byte("yaml")))
@@ -20,6 +20,8 @@ import (
 	"errors"
 	"net/http"
 	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 const delimiter = ":"
@@ -95,6 +97,106 @@ func InCluster() Option {
 	}
 }
 
+type fetchOptions struct {
+	lenient      bool
+	resources    map[Resource]struct{}
+	metadataOnly map[Resource]struct{}
+	pageSize     int64
+	fromCache    bool
+	unstructured []schema.GroupVersionResource
+}
+
+// FetchOption configures how objects are fetched by FetchObjects.
+type FetchOption func(*fetchOptions)
+
+// Lenient returns a FetchOption that tolerates missing permissions. Resources
+// that clusterlint is not permitted to list are recorded in
+// Objects.Unavailable instead of failing the fetch.
+func Lenient() FetchOption {
+	return func(o *fetchOptions) {
+		o.lenient = true
+	}
+}
+
+// WithResources returns a FetchOption that limits fetching to the given
+// resources. The lists of other resources are left empty. The server version
+// is always fetched.
+func WithResources(resources ...Resource) FetchOption {
+	return func(o *fetchOptions) {
+		if o.resources == nil {
+			o.resources = make(map[Resource]struct{})
+		}
+		for _, r := range resources {
+			o.resources[r] = struct{}{}
+		}
+	}
+}
+
+// WithMetadataOnly returns a FetchOption that lists only the metadata of the
+// objects of the given resources, which uses less memory and bandwidth for
+// large objects. The other fields of these objects are left empty. Only
+// resources of the core API group, such as secrets and config maps, are
+// supported; other resources are fetched in full.
+func WithMetadataOnly(resources ...Resource) FetchOption {
+	return func(o *fetchOptions) {
+		if o.metadataOnly == nil {
+			o.metadataOnly = make(map[Resource]struct{})
+		}
+		for _, r := range resources {
+			o.metadataOnly[r] = struct{}{}
+		}
+	}
+}
+
+// WithPageSize returns a FetchOption that lists objects in pages of at most
+// size objects, instead of the default of 500. A size of zero lists all
+// objects of a resource in a single request.
+func WithPageSize(size int64) FetchOption {
+	return func(o *fetchOptions) {
+		o.pageSize = size
+	}
+}
+
+// FromWatchCache returns a FetchOption that lists objects from the API
+// server's watch cache instead of etcd. This puts less load on the cluster,
+// but the objects may be slightly out of date.
+func FromWatchCache() FetchOption {
+	return func(o *fetchOptions) {
+		o.fromCache = true
+	}
+}
+
+// WithUnstructured returns a FetchOption that fetches the objects of
+// additional resources, such as custom resources, into Objects.Unstructured.
+// Resources that the cluster doesn't serve are skipped.
+func WithUnstructured(resources ...schema.GroupVersionResource) FetchOption {
+	return func(o *fetchOptions) {
+		for _, r := range resources {
+			if !containsGVR(o.unstructured, r) {
+				o.unstructured = append(o.unstructured, r)
+			}
+		}
+	}
+}
+
+func containsGVR(resources []schema.GroupVersionResource, resource schema.GroupVersionResource) bool {
+	for _, r := range resources {
+		if r == resource {
+			return true
+		}
+	}
+	return false
+}
+
+// wants reports whether objects of a resource should be fetched.
+func (o *fetchOptions) wants(resource Resource) bool {
+	if o.resources == nil {
+		return true
+	}
+	_, ok := o.resources[resource]
+	return ok
+}
+
 func (o *options) validate() error {
 	if o.yaml != nil && len(o.paths) != 0 {
 		return errors.New("cannot specify yaml and kubeconfig file paths")
@@ -0,0 +1,218 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package references indexes the references between the objects fetched from
+// a cluster, for example from a pod to the secrets it mounts or from an
+// ingress to its backend services. Checks that look for unused objects use
+// the graph instead of walking every object type themselves.
+package references
+
+import (
+	"sort"
+
+	"github.com/digitalocean/clusterlint/kube"
+)
+
+// Kind is the Kubernetes kind of a referenced or referencing object.
+type Kind string
+
+const (
+	Pod                            Kind = "Pod"
+	PodTemplate                    Kind = "PodTemplate"
+	Deployment                     Kind = "Deployment"
+	StatefulSet                    Kind = "StatefulSet"
+	DaemonSet                      Kind = "DaemonSet"
+	Job                            Kind = "Job"
+	CronJob                        Kind = "CronJob"
+	Secret                         Kind = "Secret"
+	ConfigMap                      Kind = "ConfigMap"
+	ServiceAccount                 Kind = "ServiceAccount"
+	Service                        Kind = "Service"
+	PersistentVolume               Kind = "PersistentVolume"
+	PersistentVolumeClaim          Kind = "PersistentVolumeClaim"
+	StorageClass                   Kind = "StorageClass"
+	PriorityClass                  Kind = "PriorityClass"
+	Node                           Kind = "Node"
+	Ingress                        Kind = "Ingress"
+	IngressClass                   Kind = "IngressClass"
+	HTTPRoute                      Kind = "HTTPRoute"
+	Role                           Kind = "Role"
+	ClusterRole                    Kind = "ClusterRole"
+	RoleBinding                    Kind = "RoleBinding"
+	ClusterRoleBinding             Kind = "ClusterRoleBinding"
+	MutatingWebhookConfiguration   Kind = "MutatingWebhookConfiguration"
+	ValidatingWebhookConfiguration Kind = "ValidatingWebhookConfiguration"
+	HorizontalPodAutoscaler        Kind = "HorizontalPodAutoscaler"
+)
+
+// WorkloadKinds are the kinds of objects that run pods or describe pods that
+// will be run.
+var WorkloadKinds = []Kind{Pod, PodTemplate, Deployment, StatefulSet, DaemonSet, Job, CronJob}
+
+// resources maps the kinds of referring objects to the resources holding them.
+var resources = map[Kind]kube.Resource{
+	Pod:                            kube.Pods,
+	PodTemplate:                    kube.PodTemplates,
+	Deployment:                     kube.Deployments,
+	StatefulSet:                    kube.StatefulSets,
+	DaemonSet:                      kube.DaemonSets,
+	Job:                            kube.Jobs,
+	CronJob:                        kube.CronJobs,
+	ServiceAccount:                 kube.ServiceAccounts,
+	Ingress:                        kube.Ingresses,
+	HTTPRoute:                      kube.HTTPRoutes,
+	PersistentVolume:               kube.PersistentVolumes,
+	PersistentVolumeClaim:          kube.PersistentVolumeClaims,
+	Node:                           kube.Nodes,
+	RoleBinding:                    kube.RoleBindings,
+	ClusterRoleBinding:             kube.ClusterRoleBindings,
+	MutatingWebhookConfiguration:   kube.MutatingWebhookConfigurations,
+	ValidatingWebhookConfiguration: kube.ValidatingWebhookConfigurations,
+	HorizontalPodAutoscaler:        kube.HorizontalPodAutoscalers,
+}
+
+// referrers lists the kinds of objects that may refer to objects of each
+// kind. It must be kept in line with the references added by NewGraph.
+var referrers = map[Kind][]Kind{
+	Secret:                workloadsAnd(ServiceAccount, Ingress, PersistentVolume),
+	ConfigMap:             workloadsAnd(Node),
+	ServiceAccount:        workloadsAnd(RoleBinding, ClusterRoleBinding),
+	PriorityClass:         workloadsAnd(),
+	PersistentVolumeClaim: workloadsAnd(PersistentVolume),
+	PersistentVolume:      {PersistentVolumeClaim},
+	StorageClass:          workloadsAnd(PersistentVolume, PersistentVolumeClaim),
+	Service:               {StatefulSet, Ingress, HTTPRoute, MutatingWebhookConfiguration, ValidatingWebhookConfiguration},
+	IngressClass:          {Ingress},
+	Role:                  {RoleBinding},
+	ClusterRole:           {RoleBinding, ClusterRoleBinding},
+	Deployment:            {HorizontalPodAutoscaler},
+	StatefulSet:           {Pod, HorizontalPodAutoscaler},
+	DaemonSet:             {Pod},
+	Job:                   {Pod},
+	CronJob:               {Job},
+}
+
+func workloadsAnd(kinds ...Kind) []Kind {
+	return append(append([]Kind{}, WorkloadKinds...), kinds...)
+}
+
+// ResourcesFor returns the resources holding the objects that may refer to
+// objects of the given kind, which checks using the graph for that kind need
+// to read. If referring kinds are given, only these are considered, as with
+// IsReferenced.
+func ResourcesFor(to Kind, from ...Kind) []kube.Resource {
+	var ret []kube.Resource
+	for _, kind := range referrers[to] {
+		if len(from) == 0 || hasKind(from, kind) {
+			ret = append(ret, resources[kind])
+		}
+	}
+	return ret
+}
+
+// Ref identifies an object in the graph. Namespace is empty for cluster
+// scoped objects.
+type Ref struct {
+	Kind      Kind
+	Namespace string
+	Name      string
+}
+
+// Graph indexes the references between objects.
+type Graph struct {
+	referrers  map[Ref]map[Ref]struct{}
+	references map[Ref]map[Ref]struct{}
+}
+
+// NewGraph indexes the references between all the given objects. Lists that
+// were not fetched are skipped.
+func NewGraph(objects *kube.Objects) *Graph {
+	g := &Graph{
+		referrers:  make(map[Ref]map[Ref]struct{}),
+		references: make(map[Ref]map[Ref]struct{}),
+	}
+	g.addWorkloads(objects)
+	g.addServiceAccounts(objects)
+	g.addNetworking(objects)
+	g.addStorage(objects)
+	g.addNodes(objects)
+	g.addRBAC(objects)
+	g.addWebhooks(objects)
+	g.addAutoscalers(objects)
+	return g
+}
+
+// Referrers returns the objects referring to the given object, sorted by kind,
+// namespace and name. If kinds are given, only referrers of these kinds are
+// returned.
+func (g *Graph) Referrers(to Ref, kinds ...Kind) []Ref {
+	return sorted(g.referrers[to], kinds)
+}
+
+// References returns the objects the given object refers to, sorted by kind,
+// namespace and name. If kinds are given, only references to objects of these
+// kinds are returned.
+func (g *Graph) References(from Ref, kinds ...Kind) []Ref {
+	return sorted(g.references[from], kinds)
+}
+
+// IsReferenced reports whether any object refers to the given object. If
+// kinds are given, only referrers of these kinds are considered.
+func (g *Graph) IsReferenced(to Ref, kinds ...Kind) bool {
+	return len(g.Referrers(to, kinds...)) > 0
+}
+
+func (g *Graph) add(from, to Ref) {
+	if to.Name == "" {
+		return
+	}
+	if g.referrers[to] == nil {
+		g.referrers[to] = make(map[Ref]struct{})
+	}
+	g.referrers[to][from] = struct{}{}
+	if g.references[from] == nil {
+		g.references[from] = make(map[Ref]struct{})
+	}
+	g.references[from][to] = struct{}{}
+}
+
+func sorted(set map[Ref]struct{}, kinds []Kind) []Ref {
+	var refs []Ref
+	for ref := range set {
+		if len(kinds) == 0 || hasKind(kinds, ref.Kind) {
+			refs = append(refs, ref)
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Kind != refs[j].Kind {
+			return refs[i].Kind < refs[j].Kind
+		}
+		if refs[i].Namespace != refs[j].Namespace {
+			return refs[i].Namespace < refs[j].Namespace
+		}
+		return refs[i].Name < refs[j].Name
+	})
+	return refs
+}
+
+func hasKind(kinds []Kind, kind Kind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,287 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package references
+
+import (
+	"testing"
+
+	"github.com/digitalocean/clusterlint/kube"
+	"github.com/stretchr/testify/assert"
+	arv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodSpecReferences(t *testing.T) {
+	spec := corev1.PodSpec{
+		ServiceAccountName: "app",
+		PriorityClassName:  "high",
+		ImagePullSecrets:   []corev1.LocalObjectReference{{Name: "registry"}},
+		Volumes: []corev1.Volume{
+			{Name: "config", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "config"}}}},
+			{Name: "data", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data"}}},
+			{Name: "store", VolumeSource: corev1.VolumeSource{CSI: &corev1.CSIVolumeSource{Driver: "secrets-store.csi.k8s.io", NodePublishSecretRef: &corev1.LocalObjectReference{Name: "store-credentials"}}}},
+			{Name: "projected", VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{Sources: []corev1.VolumeProjection{
+				{Secret: &corev1.SecretProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "projected-secret"}}},
+				{ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "kube-root-ca.crt"}}},
+			}}}},
+		},
+		InitContainers: []corev1.Container{{
+			Name: "migrate",
+			Env:  []corev1.EnvVar{{Name: "PASSWORD", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "db"}, Key: "password"}}}},
+		}},
+		Containers: []corev1.Container{{
+			Name:    "app",
+			EnvFrom: []corev1.EnvFromSource{{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "env"}}}},
+		}},
+	}
+	expected := []Ref{
+		{Kind: ConfigMap, Namespace: "k8s", Name: "config"},
+		{Kind: ConfigMap, Namespace: "k8s", Name: "env"},
+		{Kind: ConfigMap, Namespace: "k8s", Name: "kube-root-ca.crt"},
+		{Kind: PersistentVolumeClaim, Namespace: "k8s", Name: "data"},
+		{Kind: PriorityClass, Name: "high"},
+		{Kind: Secret, Namespace: "k8s", Name: "db"},
+		{Kind: Secret, Namespace: "k8s", Name: "projected-secret"},
+		{Kind: Secret, Namespace: "k8s", Name: "registry"},
+		{Kind: Secret, Namespace: "k8s", Name: "store-credentials"},
+		{Kind: ServiceAccount, Namespace: "k8s", Name: "app"},
+	}
+
+	tests := []struct {
+		name string
+		objs *kube.Objects
+		from Ref
+	}{
+		{
+			name: "pod",
+			objs: &kube.Objects{Pods: &corev1.PodList{Items: []corev1.Pod{{ObjectMeta: meta("web"), Spec: spec}}}},
+			from: Ref{Kind: Pod, Namespace: "k8s", Name: "web"},
+		},
+		{
+			name: "pod template",
+			objs: &kube.Objects{PodTemplates: &corev1.PodTemplateList{Items: []corev1.PodTemplate{{ObjectMeta: meta("web"), Template: corev1.PodTemplateSpec{Spec: spec}}}}},
+			from: Ref{Kind: PodTemplate, Namespace: "k8s", Name: "web"},
+		},
+		{
+			name: "deployment",
+			objs: &kube.Objects{Deployments: &appsv1.DeploymentList{Items: []appsv1.Deployment{{ObjectMeta: meta("web"), Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: spec}}}}}},
+			from: Ref{Kind: Deployment, Namespace: "k8s", Name: "web"},
+		},
+		{
+			name: "daemon set",
+			objs: &kube.Objects{DaemonSets: &appsv1.DaemonSetList{Items: []appsv1.DaemonSet{{ObjectMeta: meta("web"), Spec: appsv1.DaemonSetSpec{Template: corev1.PodTemplateSpec{Spec: spec}}}}}},
+			from: Ref{Kind: DaemonSet, Namespace: "k8s", Name: "web"},
+		},
+		{
+			name: "job",
+			objs: &kube.Objects{Jobs: &batchv1.JobList{Items: []batchv1.Job{{ObjectMeta: meta("web"), Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{Spec: spec}}}}}},
+			from: Ref{Kind: Job, Namespace: "k8s", Name: "web"},
+		},
+		{
+			name: "cron job",
+			objs: &kube.Objects{CronJobs: &batchv1.CronJobList{Items: []batchv1.CronJob{{ObjectMeta: meta("web"), Spec: batchv1.CronJobSpec{JobTemplate: batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{Spec: spec}}}}}}}},
+			from: Ref{Kind: CronJob, Namespace: "k8s", Name: "web"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewGraph(test.objs)
+			assert.Equal(t, expected, g.References(test.from))
+			for _, to := range expected {
+				assert.Equal(t, []Ref{test.from}, g.Referrers(to))
+			}
+		})
+	}
+}
+
+func TestDefaultServiceAccountReference(t *testing.T) {
+	g := NewGraph(&kube.Objects{Pods: &corev1.PodList{Items: []corev1.Pod{{ObjectMeta: meta("web")}}}})
+
+	assert.True(t, g.IsReferenced(Ref{Kind: ServiceAccount, Namespace: "k8s", Name: "default"}))
+}
+
+func TestOwnerReferences(t *testing.T) {
+	pod := corev1.Pod{ObjectMeta: meta("web-5d8f7c9b4-x2x7q")}
+	pod.OwnerReferences = []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-5d8f7c9b4"}}
+	g := NewGraph(&kube.Objects{Pods: &corev1.PodList{Items: []corev1.Pod{pod}}})
+
+	assert.Equal(t, []Ref{{Kind: Pod, Namespace: "k8s", Name: "web-5d8f7c9b4-x2x7q"}}, g.Referrers(Ref{Kind: "ReplicaSet", Namespace: "k8s", Name: "web-5d8f7c9b4"}))
+}
+
+func TestClusterObjectReferences(t *testing.T) {
+	class := "nginx"
+	storageClass := "do-block-storage"
+
+	tests := []struct {
+		name     string
+		objs     *kube.Objects
+		to       Ref
+		expected []Ref
+	}{
+		{
+			name: "service account secrets",
+			objs: &kube.Objects{ServiceAccounts: &corev1.ServiceAccountList{Items: []corev1.ServiceAccount{{
+				ObjectMeta:       meta("app"),
+				Secrets:          []corev1.ObjectReference{{Name: "app-token"}},
+				ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+			}}}},
+			to:       Ref{Kind: Secret, Namespace: "k8s", Name: "registry"},
+			expected: []Ref{{Kind: ServiceAccount, Namespace: "k8s", Name: "app"}},
+		},
+		{
+			name: "ingress tls",
+			objs: &kube.Objects{Ingresses: &networkingv1.IngressList{Items: []networkingv1.Ingress{{
+				ObjectMeta: meta("web"),
+				Spec:       networkingv1.IngressSpec{TLS: []networkingv1.IngressTLS{{SecretName: "web-tls"}}},
+			}}}},
+			to:       Ref{Kind: Secret, Namespace: "k8s", Name: "web-tls"},
+			expected: []Ref{{Kind: Ingress, Namespace: "k8s", Name: "web"}},
+		},
+		{
+			name: "ingress backend and class",
+			objs: &kube.Objects{Ingresses: &networkingv1.IngressList{Items: []networkingv1.Ingress{{
+				ObjectMeta: meta("web"),
+				Spec: networkingv1.IngressSpec{
+					IngressClassName: &class,
+					DefaultBackend:   &networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{Name: "web"}},
+				},
+			}}}},
+			to:       Ref{Kind: IngressClass, Name: "nginx"},
+			expected: []Ref{{Kind: Ingress, Namespace: "k8s", Name: "web"}},
+		},
+		{
+			name: "http route backend in another namespace",
+			objs: &kube.Objects{HTTPRoutes: &kube.HTTPRouteList{Items: []kube.HTTPRoute{{
+				ObjectMeta: meta("web"),
+				Spec: kube.HTTPRouteSpec{Rules: []kube.HTTPRouteRule{{
+					BackendRefs: []kube.HTTPBackendRef{{Name: "web", Namespace: &class}},
+				}}},
+			}}}},
+			to:       Ref{Kind: Service, Namespace: "nginx", Name: "web"},
+			expected: []Ref{{Kind: HTTPRoute, Namespace: "k8s", Name: "web"}},
+		},
+		{
+			name: "persistent volume csi secret",
+			objs: &kube.Objects{PersistentVolumes: &corev1.PersistentVolumeList{Items: []corev1.PersistentVolume{{
+				ObjectMeta: metav1.ObjectMeta{Name: "pvc-1234"},
+				Spec: corev1.PersistentVolumeSpec{
+					StorageClassName: storageClass,
+					PersistentVolumeSource: corev1.PersistentVolumeSource{CSI: &corev1.CSIPersistentVolumeSource{
+						NodePublishSecretRef: &corev1.SecretReference{Name: "credentials", Namespace: "storage"},
+					}},
+				},
+			}}}},
+			to:       Ref{Kind: Secret, Namespace: "storage", Name: "credentials"},
+			expected: []Ref{{Kind: PersistentVolume, Name: "pvc-1234"}},
+		},
+		{
+			name: "persistent volume claim storage class",
+			objs: &kube.Objects{PersistentVolumeClaims: &corev1.PersistentVolumeClaimList{Items: []corev1.PersistentVolumeClaim{{
+				ObjectMeta: meta("data"),
+				Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: &storageClass},
+			}}}},
+			to:       Ref{Kind: StorageClass, Name: "do-block-storage"},
+			expected: []Ref{{Kind: PersistentVolumeClaim, Namespace: "k8s", Name: "data"}},
+		},
+		{
+			name: "role binding subjects",
+			objs: &kube.Objects{RoleBindings: &rbacv1.RoleBindingList{Items: []rbacv1.RoleBinding{{
+				ObjectMeta: meta("app"),
+				RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "app"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "app"}},
+			}}}},
+			to:       Ref{Kind: ServiceAccount, Namespace: "k8s", Name: "app"},
+			expected: []Ref{{Kind: RoleBinding, Namespace: "k8s", Name: "app"}},
+		},
+		{
+			name: "webhook service",
+			objs: &kube.Objects{ValidatingWebhookConfigurations: &arv1.ValidatingWebhookConfigurationList{Items: []arv1.ValidatingWebhookConfiguration{{
+				ObjectMeta: metav1.ObjectMeta{Name: "policy"},
+				Webhooks: []arv1.ValidatingWebhook{{
+					ClientConfig: arv1.WebhookClientConfig{Service: &arv1.ServiceReference{Name: "policy", Namespace: "policy-system"}},
+				}},
+			}}}},
+			to:       Ref{Kind: Service, Namespace: "policy-system", Name: "policy"},
+			expected: []Ref{{Kind: ValidatingWebhookConfiguration, Name: "policy"}},
+		},
+		{
+			name: "stateful set service",
+			objs: &kube.Objects{StatefulSets: &appsv1.StatefulSetList{Items: []appsv1.StatefulSet{{
+				ObjectMeta: meta("db"),
+				Spec:       appsv1.StatefulSetSpec{ServiceName: "db-headless"},
+			}}}},
+			to:       Ref{Kind: Service, Namespace: "k8s", Name: "db-headless"},
+			expected: []Ref{{Kind: StatefulSet, Namespace: "k8s", Name: "db"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewGraph(test.objs)
+			assert.Equal(t, test.expected, g.Referrers(test.to))
+			for _, from := range test.expected {
+				assert.Contains(t, ResourcesFor(test.to.Kind), resources[from.Kind])
+			}
+		})
+	}
+}
+
+func TestReferrersOfKind(t *testing.T) {
+	g := NewGraph(&kube.Objects{
+		Pods: &corev1.PodList{Items: []corev1.Pod{{ObjectMeta: meta("web"), Spec: corev1.PodSpec{ServiceAccountName: "app"}}}},
+		RoleBindings: &rbacv1.RoleBindingList{Items: []rbacv1.RoleBinding{{
+			ObjectMeta: meta("app"),
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "app"}},
+		}}},
+	})
+	sa := Ref{Kind: ServiceAccount, Namespace: "k8s", Name: "app"}
+
+	assert.Len(t, g.Referrers(sa), 2)
+	assert.Equal(t, []Ref{{Kind: Pod, Namespace: "k8s", Name: "web"}}, g.Referrers(sa, WorkloadKinds...))
+	assert.False(t, g.IsReferenced(sa, Deployment))
+	assert.False(t, g.IsReferenced(Ref{Kind: Secret, Namespace: "k8s", Name: "app"}))
+}
+
+func meta(name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{Name: name, Namespace: "k8s"}
+}
+
+func TestResourcesFor(t *testing.T) {
+	assert.Equal(t, []kube.Resource{
+		kube.Pods, kube.PodTemplates, kube.Deployments, kube.StatefulSets,
+		kube.DaemonSets, kube.Jobs, kube.CronJobs, kube.Nodes,
+	}, ResourcesFor(ConfigMap))
+	assert.Equal(t, []kube.Resource{
+		kube.Pods, kube.PodTemplates, kube.Deployments, kube.StatefulSets,
+		kube.DaemonSets, kube.Jobs, kube.CronJobs,
+	}, ResourcesFor(ServiceAccount, WorkloadKinds...))
+	assert.Equal(t, []kube.Resource{kube.Pods}, ResourcesFor(PersistentVolumeClaim, Pod))
+	assert.Empty(t, ResourcesFor(Node))
+
+	for to, kinds := range referrers {
+		for _, kind := range kinds {
+			_, ok := resources[kind]
+			assert.True(t, ok, "no resource for %s referring to %s", kind, to)
+		}
+	}
+}
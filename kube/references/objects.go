@@ -0,0 +1,391 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package references
+
+import (
+	"github.com/digitalocean/clusterlint/kube"
+	arv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const defaultServiceAccount = "default"
+
+func (g *Graph) addWorkloads(objects *kube.Objects) {
+	if objects.Pods != nil {
+		for _, pod := range objects.Pods.Items {
+			from := Ref{Kind: Pod, Namespace: pod.Namespace, Name: pod.Name}
+			g.addOwners(from, pod.ObjectMeta)
+			g.addPodSpec(from, pod.Spec)
+		}
+	}
+	if objects.PodTemplates != nil {
+		for _, template := range objects.PodTemplates.Items {
+			g.addPodSpec(Ref{Kind: PodTemplate, Namespace: template.Namespace, Name: template.Name}, template.Template.Spec)
+		}
+	}
+	if objects.Deployments != nil {
+		for _, deployment := range objects.Deployments.Items {
+			g.addPodSpec(Ref{Kind: Deployment, Namespace: deployment.Namespace, Name: deployment.Name}, deployment.Spec.Template.Spec)
+		}
+	}
+	if objects.StatefulSets != nil {
+		for _, statefulSet := range objects.StatefulSets.Items {
+			from := Ref{Kind: StatefulSet, Namespace: statefulSet.Namespace, Name: statefulSet.Name}
+			g.addPodSpec(from, statefulSet.Spec.Template.Spec)
+			g.add(from, Ref{Kind: Service, Namespace: statefulSet.Namespace, Name: statefulSet.Spec.ServiceName})
+		}
+	}
+	if objects.DaemonSets != nil {
+		for _, daemonSet := range objects.DaemonSets.Items {
+			g.addPodSpec(Ref{Kind: DaemonSet, Namespace: daemonSet.Namespace, Name: daemonSet.Name}, daemonSet.Spec.Template.Spec)
+		}
+	}
+	if objects.Jobs != nil {
+		for _, job := range objects.Jobs.Items {
+			from := Ref{Kind: Job, Namespace: job.Namespace, Name: job.Name}
+			g.addOwners(from, job.ObjectMeta)
+			g.addPodSpec(from, job.Spec.Template.Spec)
+		}
+	}
+	if objects.CronJobs != nil {
+		for _, cronJob := range objects.CronJobs.Items {
+			g.addPodSpec(Ref{Kind: CronJob, Namespace: cronJob.Namespace, Name: cronJob.Name}, cronJob.Spec.JobTemplate.Spec.Template.Spec)
+		}
+	}
+}
+
+// addOwners adds references to the controllers of an object, for example from
+// a pod to its replica set.
+func (g *Graph) addOwners(from Ref, meta metav1.ObjectMeta) {
+	for _, owner := range meta.OwnerReferences {
+		g.add(from, Ref{Kind: Kind(owner.Kind), Namespace: meta.Namespace, Name: owner.Name})
+	}
+}
+
+// addPodSpec adds the references of a pod spec, which may be part of a pod or
+// of the template of a workload.
+func (g *Graph) addPodSpec(from Ref, spec corev1.PodSpec) {
+	namespaced := func(kind Kind, name string) {
+		g.add(from, Ref{Kind: kind, Namespace: from.Namespace, Name: name})
+	}
+
+	serviceAccount := spec.ServiceAccountName
+	if serviceAccount == "" {
+		serviceAccount = spec.DeprecatedServiceAccount
+	}
+	if serviceAccount == "" {
+		serviceAccount = defaultServiceAccount
+	}
+	namespaced(ServiceAccount, serviceAccount)
+	g.add(from, Ref{Kind: PriorityClass, Name: spec.PriorityClassName})
+
+	for _, secret := range spec.ImagePullSecrets {
+		namespaced(Secret, secret.Name)
+	}
+	for _, volume := range spec.Volumes {
+		g.addVolume(from, volume.VolumeSource)
+	}
+	for _, container := range spec.InitContainers {
+		g.addEnv(from, container.Env, container.EnvFrom)
+	}
+	for _, container := range spec.Containers {
+		g.addEnv(from, container.Env, container.EnvFrom)
+	}
+	for _, container := range spec.EphemeralContainers {
+		g.addEnv(from, container.Env, container.EnvFrom)
+	}
+}
+
+func (g *Graph) addVolume(from Ref, volume corev1.VolumeSource) {
+	namespaced := func(kind Kind, name string) {
+		g.add(from, Ref{Kind: kind, Namespace: from.Namespace, Name: name})
+	}
+	secret := func(ref *corev1.LocalObjectReference) {
+		if ref != nil {
+			namespaced(Secret, ref.Name)
+		}
+	}
+
+	if volume.Secret != nil {
+		namespaced(Secret, volume.Secret.SecretName)
+	}
+	if volume.ConfigMap != nil {
+		namespaced(ConfigMap, volume.ConfigMap.Name)
+	}
+	if volume.PersistentVolumeClaim != nil {
+		namespaced(PersistentVolumeClaim, volume.PersistentVolumeClaim.ClaimName)
+	}
+	if volume.Projected != nil {
+		for _, source := range volume.Projected.Sources {
+			if source.Secret != nil {
+				namespaced(Secret, source.Secret.Name)
+			}
+			if source.ConfigMap != nil {
+				namespaced(ConfigMap, source.ConfigMap.Name)
+			}
+		}
+	}
+	if volume.Ephemeral != nil && volume.Ephemeral.VolumeClaimTemplate != nil {
+		if class := volume.Ephemeral.VolumeClaimTemplate.Spec.StorageClassName; class != nil {
+			g.add(from, Ref{Kind: StorageClass, Name: *class})
+		}
+	}
+	if volume.CSI != nil {
+		secret(volume.CSI.NodePublishSecretRef)
+	}
+	if volume.FlexVolume != nil {
+		secret(volume.FlexVolume.SecretRef)
+	}
+	if volume.CephFS != nil {
+		secret(volume.CephFS.SecretRef)
+	}
+	if volume.RBD != nil {
+		secret(volume.RBD.SecretRef)
+	}
+	if volume.ISCSI != nil {
+		secret(volume.ISCSI.SecretRef)
+	}
+	if volume.Cinder != nil {
+		secret(volume.Cinder.SecretRef)
+	}
+	if volume.ScaleIO != nil {
+		secret(volume.ScaleIO.SecretRef)
+	}
+	if volume.StorageOS != nil {
+		secret(volume.StorageOS.SecretRef)
+	}
+	if volume.AzureFile != nil {
+		namespaced(Secret, volume.AzureFile.SecretName)
+	}
+}
+
+func (g *Graph) addEnv(from Ref, env []corev1.EnvVar, envFrom []corev1.EnvFromSource) {
+	namespaced := func(kind Kind, name string) {
+		g.add(from, Ref{Kind: kind, Namespace: from.Namespace, Name: name})
+	}
+
+	for _, source := range envFrom {
+		if source.SecretRef != nil {
+			namespaced(Secret, source.SecretRef.Name)
+		}
+		if source.ConfigMapRef != nil {
+			namespaced(ConfigMap, source.ConfigMapRef.Name)
+		}
+	}
+	for _, variable := range env {
+		if variable.ValueFrom == nil {
+			continue
+		}
+		if ref := variable.ValueFrom.SecretKeyRef; ref != nil {
+			namespaced(Secret, ref.Name)
+		}
+		if ref := variable.ValueFrom.ConfigMapKeyRef; ref != nil {
+			namespaced(ConfigMap, ref.Name)
+		}
+	}
+}
+
+func (g *Graph) addServiceAccounts(objects *kube.Objects) {
+	if objects.ServiceAccounts == nil {
+		return
+	}
+	for _, sa := range objects.ServiceAccounts.Items {
+		from := Ref{Kind: ServiceAccount, Namespace: sa.Namespace, Name: sa.Name}
+		for _, secret := range sa.ImagePullSecrets {
+			g.add(from, Ref{Kind: Secret, Namespace: sa.Namespace, Name: secret.Name})
+		}
+		for _, secret := range sa.Secrets {
+			namespace := secret.Namespace
+			if namespace == "" {
+				namespace = sa.Namespace
+			}
+			g.add(from, Ref{Kind: Secret, Namespace: namespace, Name: secret.Name})
+		}
+	}
+}
+
+func (g *Graph) addNetworking(objects *kube.Objects) {
+	if objects.Ingresses != nil {
+		for _, ingress := range objects.Ingresses.Items {
+			from := Ref{Kind: Ingress, Namespace: ingress.Namespace, Name: ingress.Name}
+			if ingress.Spec.IngressClassName != nil {
+				g.add(from, Ref{Kind: IngressClass, Name: *ingress.Spec.IngressClassName})
+			}
+			for _, tls := range ingress.Spec.TLS {
+				g.add(from, Ref{Kind: Secret, Namespace: ingress.Namespace, Name: tls.SecretName})
+			}
+			g.addIngressBackend(from, ingress.Spec.DefaultBackend)
+			for _, rule := range ingress.Spec.Rules {
+				if rule.HTTP == nil {
+					continue
+				}
+				for _, path := range rule.HTTP.Paths {
+					g.addIngressBackend(from, &path.Backend)
+				}
+			}
+		}
+	}
+	if objects.HTTPRoutes != nil {
+		for _, route := range objects.HTTPRoutes.Items {
+			from := Ref{Kind: HTTPRoute, Namespace: route.Namespace, Name: route.Name}
+			for _, rule := range route.Spec.Rules {
+				for _, backend := range rule.BackendRefs {
+					if !backend.IsService() {
+						continue
+					}
+					namespace := route.Namespace
+					if backend.Namespace != nil {
+						namespace = *backend.Namespace
+					}
+					g.add(from, Ref{Kind: Service, Namespace: namespace, Name: backend.Name})
+				}
+			}
+		}
+	}
+}
+
+func (g *Graph) addIngressBackend(from Ref, backend *networkingv1.IngressBackend) {
+	if backend == nil || backend.Service == nil {
+		return
+	}
+	g.add(from, Ref{Kind: Service, Namespace: from.Namespace, Name: backend.Service.Name})
+}
+
+func (g *Graph) addStorage(objects *kube.Objects) {
+	if objects.PersistentVolumes != nil {
+		for _, pv := range objects.PersistentVolumes.Items {
+			from := Ref{Kind: PersistentVolume, Name: pv.Name}
+			g.add(from, Ref{Kind: StorageClass, Name: pv.Spec.StorageClassName})
+			if claim := pv.Spec.ClaimRef; claim != nil {
+				g.add(from, Ref{Kind: PersistentVolumeClaim, Namespace: claim.Namespace, Name: claim.Name})
+			}
+			if csi := pv.Spec.CSI; csi != nil {
+				for _, secret := range []*corev1.SecretReference{
+					csi.ControllerPublishSecretRef,
+					csi.NodeStageSecretRef,
+					csi.NodePublishSecretRef,
+					csi.ControllerExpandSecretRef,
+					csi.NodeExpandSecretRef,
+				} {
+					if secret != nil {
+						g.add(from, Ref{Kind: Secret, Namespace: secret.Namespace, Name: secret.Name})
+					}
+				}
+			}
+		}
+	}
+	if objects.PersistentVolumeClaims != nil {
+		for _, pvc := range objects.PersistentVolumeClaims.Items {
+			from := Ref{Kind: PersistentVolumeClaim, Namespace: pvc.Namespace, Name: pvc.Name}
+			g.add(from, Ref{Kind: PersistentVolume, Name: pvc.Spec.VolumeName})
+			if pvc.Spec.StorageClassName != nil {
+				g.add(from, Ref{Kind: StorageClass, Name: *pvc.Spec.StorageClassName})
+			}
+		}
+	}
+}
+
+func (g *Graph) addNodes(objects *kube.Objects) {
+	if objects.Nodes == nil {
+		return
+	}
+	for _, node := range objects.Nodes.Items {
+		source := node.Spec.ConfigSource
+		if source == nil || source.ConfigMap == nil {
+			continue
+		}
+		g.add(Ref{Kind: Node, Name: node.Name}, Ref{Kind: ConfigMap, Namespace: source.ConfigMap.Namespace, Name: source.ConfigMap.Name})
+	}
+}
+
+func (g *Graph) addRBAC(objects *kube.Objects) {
+	if objects.RoleBindings != nil {
+		for _, binding := range objects.RoleBindings.Items {
+			from := Ref{Kind: RoleBinding, Namespace: binding.Namespace, Name: binding.Name}
+			g.addRoleRef(from, binding.RoleRef)
+			g.addSubjects(from, binding.Subjects)
+		}
+	}
+	if objects.ClusterRoleBindings != nil {
+		for _, binding := range objects.ClusterRoleBindings.Items {
+			from := Ref{Kind: ClusterRoleBinding, Name: binding.Name}
+			g.addRoleRef(from, binding.RoleRef)
+			g.addSubjects(from, binding.Subjects)
+		}
+	}
+}
+
+func (g *Graph) addRoleRef(from Ref, role rbacv1.RoleRef) {
+	switch role.Kind {
+	case string(Role):
+		g.add(from, Ref{Kind: Role, Namespace: from.Namespace, Name: role.Name})
+	case string(ClusterRole):
+		g.add(from, Ref{Kind: ClusterRole, Name: role.Name})
+	}
+}
+
+func (g *Graph) addSubjects(from Ref, subjects []rbacv1.Subject) {
+	for _, subject := range subjects {
+		if subject.Kind != rbacv1.ServiceAccountKind {
+			continue
+		}
+		namespace := subject.Namespace
+		if namespace == "" {
+			namespace = from.Namespace
+		}
+		g.add(from, Ref{Kind: ServiceAccount, Namespace: namespace, Name: subject.Name})
+	}
+}
+
+func (g *Graph) addWebhooks(objects *kube.Objects) {
+	if objects.MutatingWebhookConfigurations != nil {
+		for _, config := range objects.MutatingWebhookConfigurations.Items {
+			from := Ref{Kind: MutatingWebhookConfiguration, Name: config.Name}
+			for _, webhook := range config.Webhooks {
+				g.addWebhookService(from, webhook.ClientConfig)
+			}
+		}
+	}
+	if objects.ValidatingWebhookConfigurations != nil {
+		for _, config := range objects.ValidatingWebhookConfigurations.Items {
+			from := Ref{Kind: ValidatingWebhookConfiguration, Name: config.Name}
+			for _, webhook := range config.Webhooks {
+				g.addWebhookService(from, webhook.ClientConfig)
+			}
+		}
+	}
+}
+
+func (g *Graph) addWebhookService(from Ref, config arv1.WebhookClientConfig) {
+	if config.Service == nil {
+		return
+	}
+	g.add(from, Ref{Kind: Service, Namespace: config.Service.Namespace, Name: config.Service.Name})
+}
+
+func (g *Graph) addAutoscalers(objects *kube.Objects) {
+	if objects.HorizontalPodAutoscalers == nil {
+		return
+	}
+	for _, hpa := range objects.HorizontalPodAutoscalers.Items {
+		target := hpa.Spec.ScaleTargetRef
+		g.add(Ref{Kind: HorizontalPodAutoscaler, Namespace: hpa.Namespace, Name: hpa.Name}, Ref{Kind: Kind(target.Kind), Namespace: hpa.Namespace, Name: target.Name})
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"sort"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// Resource identifies a kind of object fetched from the cluster by its API
+// group and resource name, as used in RBAC rules.
+type Resource struct {
+	Group    string
+	Resource string
+}
+
+// String returns the resource name, qualified with the API group for
+// resources outside the core group, e.g. "volumesnapshots.snapshot.storage.k8s.io".
+func (r Resource) String() string {
+	if r.Group == "" {
+		return r.Resource
+	}
+	return r.Resource + "." + r.Group
+}
+
+// The resources fetched by FetchObjects.
+var (
+	Nodes                           = Resource{Resource: "nodes"}
+	PersistentVolumes               = Resource{Resource: "persistentvolumes"}
+	Pods                            = Resource{Resource: "pods"}
+	PodTemplates                    = Resource{Resource: "podtemplates"}
+	PersistentVolumeClaims          = Resource{Resource: "persistentvolumeclaims"}
+	ConfigMaps                      = Resource{Resource: "configmaps"}
+	Services                        = Resource{Resource: "services"}
+	Secrets                         = Resource{Resource: "secrets"}
+	ServiceAccounts                 = Resource{Resource: "serviceaccounts"}
+	ResourceQuotas                  = Resource{Resource: "resourcequotas"}
+	LimitRanges                     = Resource{Resource: "limitranges"}
+	Namespaces                      = Resource{Resource: "namespaces"}
+	VolumeSnapshots                 = Resource{Group: "snapshot.storage.k8s.io", Resource: "volumesnapshots"}
+	VolumeSnapshotContents          = Resource{Group: "snapshot.storage.k8s.io", Resource: "volumesnapshotcontents"}
+	StorageClasses                  = Resource{Group: "storage.k8s.io", Resource: "storageclasses"}
+	VolumeAttachments               = Resource{Group: "storage.k8s.io", Resource: "volumeattachments"}
+	CSINodes                        = Resource{Group: "storage.k8s.io", Resource: "csinodes"}
+	MutatingWebhookConfigurations   = Resource{Group: "admissionregistration.k8s.io", Resource: "mutatingwebhookconfigurations"}
+	ValidatingWebhookConfigurations = Resource{Group: "admissionregistration.k8s.io", Resource: "validatingwebhookconfigurations"}
+	CronJobs                        = Resource{Group: "batch", Resource: "cronjobs"}
+	Jobs                            = Resource{Group: "batch", Resource: "jobs"}
+	Deployments                     = Resource{Group: "apps", Resource: "deployments"}
+	StatefulSets                    = Resource{Group: "apps", Resource: "statefulsets"}
+	DaemonSets                      = Resource{Group: "apps", Resource: "daemonsets"}
+	PriorityClasses                 = Resource{Group: "scheduling.k8s.io", Resource: "priorityclasses"}
+	HorizontalPodAutoscalers        = Resource{Group: "autoscaling", Resource: "horizontalpodautoscalers"}
+	Roles                           = Resource{Group: "rbac.authorization.k8s.io", Resource: "roles"}
+	ClusterRoles                    = Resource{Group: "rbac.authorization.k8s.io", Resource: "clusterroles"}
+	RoleBindings                    = Resource{Group: "rbac.authorization.k8s.io", Resource: "rolebindings"}
+	ClusterRoleBindings             = Resource{Group: "rbac.authorization.k8s.io", Resource: "clusterrolebindings"}
+	NetworkPolicies                 = Resource{Group: "networking.k8s.io", Resource: "networkpolicies"}
+	Ingresses                       = Resource{Group: "networking.k8s.io", Resource: "ingresses"}
+	IngressClasses                  = Resource{Group: "networking.k8s.io", Resource: "ingressclasses"}
+	EndpointSlices                  = Resource{Group: "discovery.k8s.io", Resource: "endpointslices"}
+	HTTPRoutes                      = Resource{Group: HTTPRouteResource.Group, Resource: HTTPRouteResource.Resource}
+)
+
+// AllResources returns all the resources fetched by FetchObjects.
+func AllResources() []Resource {
+	return []Resource{
+		Nodes, PersistentVolumes, Pods, PodTemplates, PersistentVolumeClaims,
+		ConfigMaps, Services, Secrets, ServiceAccounts, ResourceQuotas,
+		LimitRanges, Namespaces, VolumeSnapshots, VolumeSnapshotContents,
+		StorageClasses, VolumeAttachments, CSINodes,
+		MutatingWebhookConfigurations, ValidatingWebhookConfigurations,
+		CronJobs, Jobs, Deployments, StatefulSets, DaemonSets, PriorityClasses,
+		HorizontalPodAutoscalers, Roles, ClusterRoles, RoleBindings,
+		ClusterRoleBindings, NetworkPolicies, Ingresses, IngressClasses,
+		EndpointSlices, HTTPRoutes,
+	}
+}
+
+// PolicyRules returns the RBAC rules needed to fetch the given resources.
+// Objects are listed across all namespaces, and the kube-system namespace is
+// read with get. Access to the server version, which is always fetched, is
+// included as well.
+func PolicyRules(resources []Resource) []rbacv1.PolicyRule {
+	byGroup := make(map[string]map[string]struct{})
+	for _, r := range resources {
+		if byGroup[r.Group] == nil {
+			byGroup[r.Group] = make(map[string]struct{})
+		}
+		byGroup[r.Group][r.Resource] = struct{}{}
+	}
+
+	groups := make([]string, 0, len(byGroup))
+	for group := range byGroup {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	var rules []rbacv1.PolicyRule
+	for _, group := range groups {
+		names := make([]string, 0, len(byGroup[group]))
+		for name := range byGroup[group] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups: []string{group},
+			Resources: names,
+			Verbs:     []string{"list"},
+		})
+		if _, ok := byGroup[group][Namespaces.Resource]; ok && group == Namespaces.Group {
+			rules = append(rules, rbacv1.PolicyRule{
+				APIGroups:     []string{group},
+				Resources:     []string{Namespaces.Resource},
+				ResourceNames: []string{"kube-system"},
+				Verbs:         []string{"get"},
+			})
+		}
+	}
+	return append(rules, rbacv1.PolicyRule{
+		NonResourceURLs: []string{"/version"},
+		Verbs:           []string{"get"},
+	})
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestResourceString(t *testing.T) {
+	assert.Equal(t, "secrets", Secrets.String())
+	assert.Equal(t, "volumesnapshots.snapshot.storage.k8s.io", VolumeSnapshots.String())
+}
+
+func TestPolicyRules(t *testing.T) {
+	rules := PolicyRules([]Resource{Secrets, Deployments, Pods, Namespaces, StatefulSets, Pods})
+	assert.Equal(t, []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"namespaces", "pods", "secrets"}, Verbs: []string{"list"}},
+		{APIGroups: []string{""}, Resources: []string{"namespaces"}, ResourceNames: []string{"kube-system"}, Verbs: []string{"get"}},
+		{APIGroups: []string{"apps"}, Resources: []string{"deployments", "statefulsets"}, Verbs: []string{"list"}},
+		{NonResourceURLs: []string{"/version"}, Verbs: []string{"get"}},
+	}, rules)
+}
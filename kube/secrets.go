@@ -0,0 +1,115 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+// lastAppliedConfigAnnotation holds the last manifest applied with kubectl
+// apply, which for secrets includes their data.
+const lastAppliedConfigAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// secretTable is the Accept header that makes the API server return secrets
+// as a table, which has a column for their type but none for their data.
+const secretTable = "application/json;as=Table;g=meta.k8s.io;v=v1"
+
+// listSecretMetadata lists the metadata and type of secrets. The type is not
+// part of an object's metadata, so the secrets are listed as the table that
+// kubectl shows, with the metadata of each secret included in its row.
+func listSecretMetadata(ctx context.Context, client rest.Interface, opts metav1.ListOptions) (*corev1.SecretList, error) {
+	body, err := client.Get().
+		Resource(Secrets.Resource).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Param("includeObject", string(metav1.IncludeMetadata)).
+		SetHeader("Accept", secretTable).
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+	table := &metav1.Table{}
+	if err := json.Unmarshal(body, table); err != nil {
+		return nil, err
+	}
+	column := -1
+	for i, definition := range table.ColumnDefinitions {
+		if definition.Name == "Type" {
+			column = i
+		}
+	}
+	if column < 0 {
+		return nil, fmt.Errorf("secrets table has no Type column")
+	}
+
+	secrets := &corev1.SecretList{ListMeta: table.ListMeta}
+	secrets.Items = make([]corev1.Secret, len(table.Rows))
+	for i, row := range table.Rows {
+		metadata := &metav1.PartialObjectMetadata{}
+		if err := json.Unmarshal(row.Object.Raw, metadata); err != nil {
+			return nil, err
+		}
+		secrets.Items[i].ObjectMeta = metadata.ObjectMeta
+		if column < len(row.Cells) {
+			if t, ok := row.Cells[column].(string); ok {
+				secrets.Items[i].Type = corev1.SecretType(t)
+			}
+		}
+	}
+	return secrets, nil
+}
+
+// withoutSecretData removes the values of secrets from each page as it is
+// listed, so that they are never held in memory for long.
+func withoutSecretData(list listFunc[*corev1.SecretList]) listFunc[*corev1.SecretList] {
+	return func(ctx context.Context, opts metav1.ListOptions) (*corev1.SecretList, error) {
+		secrets, err := list(ctx, opts)
+		if err != nil {
+			return secrets, err
+		}
+		return redactSecrets(secrets), nil
+	}
+}
+
+// redactSecrets removes everything from secrets that may contain their values
+// and is not needed by checks, keeping their metadata and type. Besides data
+// and stringData, this drops the last applied configuration annotation and
+// managed fields.
+func redactSecrets(secrets *corev1.SecretList) *corev1.SecretList {
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		secret.Data = nil
+		secret.StringData = nil
+		secret.ManagedFields = nil
+		if _, ok := secret.Annotations[lastAppliedConfigAnnotation]; ok {
+			annotations := make(map[string]string, len(secret.Annotations)-1)
+			for k, v := range secret.Annotations {
+				if k != lastAppliedConfigAnnotation {
+					annotations[k] = v
+				}
+			}
+			secret.Annotations = annotations
+		}
+	}
+	return secrets
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	csi "github.com/kubernetes-csi/external-snapshotter/client/v4/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+func TestFetchObjectsRedactsSecrets(t *testing.T) {
+	cs := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db",
+			Namespace: "default",
+			Annotations: map[string]string{
+				lastAppliedConfigAnnotation: `{"stringData":{"password":"hunter2"}}`,
+				"owner":                     "team-a",
+			},
+			ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+		},
+		Type:       corev1.SecretTypeOpaque,
+		Data:       map[string][]byte{"password": []byte("hunter2")},
+		StringData: map[string]string{"user": "admin"},
+	})
+	api := &Client{KubeClient: cs, CSIClient: csi.NewSimpleClientset()}
+
+	actual, err := api.FetchObjects(context.Background(), ObjectFilter{}, WithResources(Secrets))
+	assert.NoError(t, err)
+	assert.Equal(t, []corev1.Secret{{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "db",
+			Namespace:   "default",
+			Annotations: map[string]string{"owner": "team-a"},
+		},
+		Type: corev1.SecretTypeOpaque,
+	}}, actual.Secrets.Items)
+}
+
+func TestFetchSecretMetadata(t *testing.T) {
+	var accept, includeObject string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/secrets" {
+			http.NotFound(w, r)
+			return
+		}
+		accept = r.Header.Get("Accept")
+		includeObject = r.URL.Query().Get("includeObject")
+		metadata, err := json.Marshal(metav1.PartialObjectMetadata{
+			TypeMeta: metav1.TypeMeta{Kind: "PartialObjectMetadata", APIVersion: "meta.k8s.io/v1"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "token",
+				Namespace: "default",
+				Annotations: map[string]string{
+					lastAppliedConfigAnnotation: `{"stringData":{"token":"hunter2"}}`,
+				},
+			},
+		})
+		assert.NoError(t, err)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(metav1.Table{
+			TypeMeta: metav1.TypeMeta{Kind: "Table", APIVersion: "meta.k8s.io/v1"},
+			ColumnDefinitions: []metav1.TableColumnDefinition{
+				{Name: "Name"}, {Name: "Type"}, {Name: "Data"}, {Name: "Age"},
+			},
+			Rows: []metav1.TableRow{{
+				Cells:  []interface{}{"token", string(corev1.SecretTypeServiceAccountToken), 3, "5d"},
+				Object: runtime.RawExtension{Raw: metadata},
+			}},
+		})
+	}))
+	defer server.Close()
+
+	cs, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	assert.NoError(t, err)
+	api := &Client{KubeClient: cs, CSIClient: csi.NewSimpleClientset()}
+
+	actual, err := api.FetchObjects(context.Background(), ObjectFilter{}, WithResources(Secrets), WithMetadataOnly(Secrets))
+	assert.NoError(t, err)
+	assert.Equal(t, secretTable, accept)
+	assert.Equal(t, "Metadata", includeObject)
+	assert.Equal(t, []corev1.Secret{{
+		ObjectMeta: metav1.ObjectMeta{Name: "token", Namespace: "default", Annotations: map[string]string{}},
+		Type:       corev1.SecretTypeServiceAccountToken,
+	}}, actual.Secrets.Items)
+}
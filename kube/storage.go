@@ -0,0 +1,56 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"sort"
+
+	st "k8s.io/api/storage/v1"
+)
+
+// Annotations that mark a storage class as the cluster's default.
+const (
+	defaultStorageClassAnnotation     = "storageclass.kubernetes.io/is-default-class"
+	betaDefaultStorageClassAnnotation = "storageclass.beta.kubernetes.io/is-default-class"
+)
+
+// IsDefaultStorageClass reports whether a storage class is marked as the
+// cluster's default.
+func IsDefaultStorageClass(class st.StorageClass) bool {
+	return class.Annotations[defaultStorageClassAnnotation] == "true" ||
+		class.Annotations[betaDefaultStorageClassAnnotation] == "true"
+}
+
+// DefaultStorageClasses returns the storage classes marked as default. If
+// there is more than one, Kubernetes assigns the most recently created one to
+// claims without a storage class, so that one is returned first.
+func DefaultStorageClasses(classes *st.StorageClassList) []st.StorageClass {
+	var defaults []st.StorageClass
+	for _, class := range classes.Items {
+		if IsDefaultStorageClass(class) {
+			defaults = append(defaults, class)
+		}
+	}
+	sort.SliceStable(defaults, func(i, j int) bool {
+		ti, tj := defaults[i].CreationTimestamp, defaults[j].CreationTimestamp
+		if !ti.Equal(&tj) {
+			return tj.Before(&ti)
+		}
+		return defaults[i].Name < defaults[j].Name
+	})
+	return defaults
+}
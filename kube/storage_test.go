@@ -0,0 +1,77 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	csi "github.com/kubernetes-csi/external-snapshotter/client/v4/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	st "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDefaultStorageClasses(t *testing.T) {
+	created := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	class := func(name, annotation string, age time.Duration) st.StorageClass {
+		c := st.StorageClass{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(created.Add(-age)),
+		}}
+		if annotation != "" {
+			c.Annotations = map[string]string{annotation: "true"}
+		}
+		return c
+	}
+
+	classes := &st.StorageClassList{Items: []st.StorageClass{
+		class("old", defaultStorageClassAnnotation, 2*time.Hour),
+		class("standard", "", 0),
+		class("beta", betaDefaultStorageClassAnnotation, time.Hour),
+		class("new-b", defaultStorageClassAnnotation, 0),
+		class("new-a", defaultStorageClassAnnotation, 0),
+	}}
+
+	var names []string
+	for _, c := range DefaultStorageClasses(classes) {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"new-a", "new-b", "beta", "old"}, names)
+	assert.Empty(t, DefaultStorageClasses(&st.StorageClassList{Items: classes.Items[1:2]}))
+}
+
+func TestFetchObjectsDefaultStorageClass(t *testing.T) {
+	older := metav1.NewTime(time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC))
+	newer := metav1.NewTime(older.Add(time.Hour))
+	annotations := map[string]string{defaultStorageClassAnnotation: "true"}
+	cs := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: metav1.NamespaceSystem}},
+		&st.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "b", Annotations: annotations, CreationTimestamp: newer}},
+		&st.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "a", Annotations: annotations, CreationTimestamp: older}},
+	)
+	api := &Client{KubeClient: cs, CSIClient: csi.NewSimpleClientset()}
+
+	objects, err := api.FetchObjects(context.Background(), ObjectFilter{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "b", objects.DefaultStorageClass.Name)
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"golang.org/x/sync/errgroup"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+)
+
+// UnstructuredObjects holds the objects of resources that have no field in
+// Objects, such as custom resources, keyed by resource. Resources that are
+// not served by the cluster have no objects.
+type UnstructuredObjects struct {
+	lists     map[schema.GroupVersionResource][]unstructured.Unstructured
+	resources map[schema.GroupVersionKind]schema.GroupVersionResource
+}
+
+// NewUnstructuredObjects returns an empty store of unstructured objects.
+func NewUnstructuredObjects() *UnstructuredObjects {
+	return &UnstructuredObjects{
+		lists:     make(map[schema.GroupVersionResource][]unstructured.Unstructured),
+		resources: make(map[schema.GroupVersionKind]schema.GroupVersionResource),
+	}
+}
+
+// Add adds objects of a resource, whose objects are of the given kind, to the
+// store.
+func (u *UnstructuredObjects) Add(resource schema.GroupVersionResource, kind string, objects ...unstructured.Unstructured) {
+	u.resources[resource.GroupVersion().WithKind(kind)] = resource
+	u.lists[resource] = append(u.lists[resource], objects...)
+}
+
+// List returns the objects of a resource.
+func (u *UnstructuredObjects) List(resource schema.GroupVersionResource) []unstructured.Unstructured {
+	return u.lists[resource]
+}
+
+// ListKind returns the objects of a kind.
+func (u *UnstructuredObjects) ListKind(kind schema.GroupVersionKind) []unstructured.Unstructured {
+	resource, ok := u.resources[kind]
+	if !ok {
+		return nil
+	}
+	return u.lists[resource]
+}
+
+// Get returns the object of a kind with the given namespace and name. The
+// namespace is empty for cluster scoped objects.
+func (u *UnstructuredObjects) Get(kind schema.GroupVersionKind, namespace, name string) (*unstructured.Unstructured, bool) {
+	objects := u.ListKind(kind)
+	for i := range objects {
+		if objects[i].GetNamespace() == namespace && objects[i].GetName() == name {
+			return &objects[i], true
+		}
+	}
+	return nil, false
+}
+
+// unstructuredList is the JSON form of the objects of a resource in
+// UnstructuredObjects.
+type unstructuredList struct {
+	Resource schema.GroupVersionResource
+	Kind     string
+	Items    []unstructured.Unstructured
+}
+
+// MarshalJSON encodes the objects in the store along with their resource and
+// kind.
+func (u *UnstructuredObjects) MarshalJSON() ([]byte, error) {
+	kinds := make(map[schema.GroupVersionResource]string, len(u.resources))
+	for gvk, gvr := range u.resources {
+		kinds[gvr] = gvk.Kind
+	}
+	lists := make([]unstructuredList, 0, len(u.lists))
+	for gvr, items := range u.lists {
+		lists = append(lists, unstructuredList{Resource: gvr, Kind: kinds[gvr], Items: items})
+	}
+	sort.Slice(lists, func(i, j int) bool {
+		return lists[i].Resource.String() < lists[j].Resource.String()
+	})
+	return json.Marshal(lists)
+}
+
+// UnmarshalJSON decodes objects encoded with MarshalJSON into the store.
+func (u *UnstructuredObjects) UnmarshalJSON(data []byte) error {
+	var lists []unstructuredList
+	if err := json.Unmarshal(data, &lists); err != nil {
+		return err
+	}
+	*u = *NewUnstructuredObjects()
+	for _, list := range lists {
+		u.Add(list.Resource, list.Kind, list.Items...)
+	}
+	return nil
+}
+
+// fetchUnstructured lists the objects of the requested unstructured resources
+// with the dynamic client. Discovery tells whether a resource is namespaced
+// and which kind its objects are.
+func (f *fetcher) fetchUnstructured(ctx context.Context, g *errgroup.Group, disc discovery.DiscoveryInterface, client dynamic.Interface, filter ObjectFilter, opts metav1.ListOptions, objects *UnstructuredObjects) {
+	for _, gvr := range f.options.unstructured {
+		gvr := gvr
+		g.Go(func() error {
+			err := f.listUnstructured(ctx, disc, client, gvr, filter, opts, objects)
+			if f.forbidden(Resource{Group: gvr.Group, Resource: gvr.Resource}, err) {
+				return nil
+			}
+			return annotateFetchError(gvr.String(), err)
+		})
+	}
+}
+
+func (f *fetcher) listUnstructured(ctx context.Context, disc discovery.DiscoveryInterface, client dynamic.Interface, gvr schema.GroupVersionResource, filter ObjectFilter, opts metav1.ListOptions, objects *UnstructuredObjects) error {
+	resources, err := disc.ServerResourcesForGroupVersion(gvr.GroupVersion().String())
+	if err != nil {
+		return err
+	}
+	var resource *metav1.APIResource
+	for i := range resources.APIResources {
+		if resources.APIResources[i].Name == gvr.Resource {
+			resource = &resources.APIResources[i]
+		}
+	}
+	if resource == nil {
+		// The group version is served, but not the resource.
+		return nil
+	}
+
+	var list listFunc[*unstructured.UnstructuredList] = client.Resource(gvr).List
+	if resource.Namespaced {
+		list = client.Resource(gvr).Namespace(metav1.NamespaceAll).List
+		opts = filter.NamespaceOptions(opts)
+	}
+	result, err := listPages(ctx, f.options, opts, list)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	objects.Add(gvr, resource.Kind, result.Items...)
+	return nil
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2022 DigitalOcean
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	csi "github.com/kubernetes-csi/external-snapshotter/client/v4/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestFetchUnstructured(t *testing.T) {
+	certificates := schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+	issuers := schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "clusterissuers"}
+	rollouts := schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "rollouts"}
+	newObject := func(kind, namespace, name string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "cert-manager.io/v1",
+			"kind":       kind,
+			"metadata":   map[string]interface{}{"name": name, "namespace": namespace},
+		}}
+	}
+
+	cs := fake.NewSimpleClientset()
+	cs.Fake.Resources = []*metav1.APIResourceList{{
+		GroupVersion: "cert-manager.io/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "certificates", Kind: "Certificate", Namespaced: true},
+			{Name: "clusterissuers", Kind: "ClusterIssuer"},
+		},
+	}}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		certificates: "CertificateList",
+		issuers:      "ClusterIssuerList",
+		rollouts:     "RolloutList",
+	}, newObject("Certificate", "web", "tls"), newObject("ClusterIssuer", "", "letsencrypt"))
+	api := &Client{KubeClient: cs, CSIClient: csi.NewSimpleClientset(), DynamicClient: dynamicClient}
+
+	actual, err := api.FetchObjects(context.Background(), ObjectFilter{}, WithResources(), WithUnstructured(certificates, issuers, rollouts))
+	assert.NoError(t, err)
+	assert.Len(t, actual.Unstructured.List(certificates), 1)
+	assert.Len(t, actual.Unstructured.ListKind(schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "ClusterIssuer"}), 1)
+	assert.Empty(t, actual.Unstructured.List(rollouts))
+
+	certificate, ok := actual.Unstructured.Get(schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}, "web", "tls")
+	assert.True(t, ok)
+	assert.Equal(t, "tls", certificate.GetName())
+	_, ok = actual.Unstructured.Get(schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}, "default", "tls")
+	assert.False(t, ok)
+}
+
+func TestUnstructuredObjectsJSON(t *testing.T) {
+	certificates := schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+	certificate := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "Certificate",
+		"metadata":   map[string]interface{}{"name": "tls", "namespace": "web"},
+	}}
+	objects := NewUnstructuredObjects()
+	objects.Add(certificates, "Certificate", certificate)
+
+	data, err := json.Marshal(objects)
+	assert.NoError(t, err)
+	decoded := NewUnstructuredObjects()
+	assert.NoError(t, json.Unmarshal(data, decoded))
+
+	assert.Equal(t, []unstructured.Unstructured{certificate}, decoded.List(certificates))
+	actual, ok := decoded.Get(certificates.GroupVersion().WithKind("Certificate"), "web", "tls")
+	assert.True(t, ok)
+	assert.Equal(t, &certificate, actual)
+}